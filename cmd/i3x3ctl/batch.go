@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/proto"
+	"github.com/seeruk/i3x3/internal/rpc"
+	"github.com/seeruk/i3x3/internal/version"
+)
+
+// batchTimeout is how long i3x3ctl waits for a batch to be handled by i3x3d.
+const batchTimeout = rpc.BatchTimeout + rpc.DefaultTimeout
+
+// stepSetup defines a command's flags on the given flag set, and returns the function that turns
+// it's positional arguments into a batch step, once the flags have been parsed.
+type stepSetup func(flags *flag.FlagSet) func(args []string) (*proto.BatchStep, error)
+
+// sendStep returns a function that runs a command on it's own, by sending the step returned by the
+// given function with the matching RPC.
+func sendStep(step func(args []string) (*proto.BatchStep, error)) func(args []string) error {
+	return func(args []string) error {
+		s, err := step(args)
+		if err != nil {
+			return err
+		}
+
+		return withClient(commandTimeout, stepFeatures(s), func(ctx context.Context, client proto.DaemonServiceClient) error {
+			var resp *proto.DaemonCommandResponse
+
+			switch cmd := s.GetCommand().(type) {
+			case *proto.BatchStep_DaemonCommand:
+				resp, err = client.HandleCommand(ctx, cmd.DaemonCommand)
+			case *proto.BatchStep_Jump:
+				resp, err = client.Jump(ctx, cmd.Jump)
+			case *proto.BatchStep_History:
+				resp, err = client.History(ctx, cmd.History)
+			default:
+				err = fmt.Errorf("unknown step type: %T", s.GetCommand())
+			}
+
+			if err != nil {
+				return err
+			}
+
+			respond(resp)
+			return nil
+		})
+	}
+}
+
+// stepFeatures returns the features that i3x3d needs to support to handle the given step.
+func stepFeatures(step *proto.BatchStep) []string {
+	var features []string
+
+	switch cmd := step.GetCommand().(type) {
+	case *proto.BatchStep_DaemonCommand:
+		if cmd.DaemonCommand.MoveMode != "" {
+			features = append(features, version.FeatureMoveMode)
+		}
+
+		switch grid.Direction(cmd.DaemonCommand.Direction) {
+		case grid.NextPage, grid.PrevPage:
+			features = append(features, version.FeaturePages)
+		}
+
+		features = append(features, directionFeatures(cmd.DaemonCommand.Direction)...)
+	case *proto.BatchStep_Jump:
+		features = append(features, version.FeatureJump)
+
+		if cmd.Jump.Page != 0 {
+			features = append(features, version.FeaturePages)
+		}
+	case *proto.BatchStep_History:
+		features = append(features, version.FeatureHistory)
+	}
+
+	return features
+}
+
+// batchCommand defines the batch command.
+func batchCommand(flags *flag.FlagSet) func(args []string) error {
+	disableOverlay := flags.Bool("no-overlay", false, "Don't show the overlay at the end of the batch")
+
+	return func(args []string) error {
+		err := noArgs(args)
+		if err != nil {
+			return err
+		}
+
+		steps, err := readBatch(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		if len(steps) == 0 {
+			return newUsageError("no commands were given on stdin")
+		}
+
+		features := []string{version.FeatureBatch}
+		for _, step := range steps {
+			features = append(features, stepFeatures(step)...)
+		}
+
+		return withClient(batchTimeout, features, func(ctx context.Context, client proto.DaemonServiceClient) error {
+			resp, err := client.Batch(ctx, &proto.BatchCommand{
+				Steps:   steps,
+				Overlay: !*disableOverlay,
+			})
+
+			if err != nil {
+				return err
+			}
+
+			respond(resp)
+			return nil
+		})
+	}
+}
+
+// readBatch reads commands from the given reader, one per line, returning them as batch steps. An
+// error is returned for the first line that isn't a valid command that can be used in a batch.
+func readBatch(r io.Reader) ([]*proto.BatchStep, error) {
+	var steps []*proto.BatchStep
+	var lineNum int
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNum++
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		step, err := parseStep(fields)
+		if err != nil {
+			return nil, newUsageError("line %d: %v", lineNum, err)
+		}
+
+		steps = append(steps, step)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading commands: %v", err)
+	}
+
+	return steps, nil
+}
+
+// parseStep parses the given command and it's arguments as a batch step.
+func parseStep(fields []string) (*proto.BatchStep, error) {
+	cmd, ok := findCommand(fields[0])
+	if !ok || cmd.step == nil {
+		return nil, fmt.Errorf("%q can't be used in a batch", fields[0])
+	}
+
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+
+	step := cmd.step(flags)
+
+	args, err := parse(flags, fields[1:])
+	if err == flag.ErrHelp {
+		return nil, fmt.Errorf("%s: help isn't available in a batch", cmd.name)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", cmd.name, err)
+	}
+
+	s, err := step(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", cmd.name, err)
+	}
+
+	return s, nil
+}
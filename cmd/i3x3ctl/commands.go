@@ -0,0 +1,977 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/i3config"
+	"github.com/seeruk/i3x3/internal/overlay"
+	"github.com/seeruk/i3x3/internal/proto"
+	"github.com/seeruk/i3x3/internal/rpc"
+	"github.com/seeruk/i3x3/internal/version"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// commandTimeout is how long i3x3ctl waits for most commands to be handled by i3x3d.
+const commandTimeout = rpc.DefaultTimeout + time.Second
+
+// command is an i3x3ctl subcommand.
+type command struct {
+	// name is the name the command is run by.
+	name string
+	// args describes the command's positional arguments, for it's usage.
+	args string
+	// summary is a short description of what the command does.
+	summary string
+	// help is a longer description of the command, shown in it's usage, if it needs one.
+	help string
+	// values are the values that the command's positional arguments can take, for completion.
+	values []string
+	// setup defines the command's flags on the given flag set, and returns the function that runs
+	// the command with it's positional arguments, once the flags have been parsed.
+	setup func(flags *flag.FlagSet) func(args []string) error
+	// step is set instead of setup for commands that can be used in a batch. It returns the function
+	// that turns the command's positional arguments into a batch step, which is sent on it's own
+	// when the command isn't run as part of a batch.
+	step stepSetup
+}
+
+// flagSet creates a flag set for the command, returning it along with the function that runs the
+// command once the flag set has been parsed.
+func (c command) flagSet() (*flag.FlagSet, func(args []string) error) {
+	name := "i3x3ctl"
+	if c.name != "" {
+		name += " " + c.name
+	}
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	var run func(args []string) error
+	if c.step != nil {
+		run = sendStep(c.step(flags))
+	} else {
+		run = c.setup(flags)
+	}
+
+	flags.Usage = func() {
+		w := flags.Output()
+
+		fmt.Fprintf(w, "Usage: %s", name)
+		if hasFlags(flags) {
+			fmt.Fprint(w, " [flags]")
+		}
+
+		if c.args != "" {
+			fmt.Fprintf(w, " %s", c.args)
+		}
+
+		fmt.Fprintf(w, "\n\n%s\n", c.summary)
+
+		if c.help != "" {
+			fmt.Fprintf(w, "\n%s\n", c.help)
+		}
+
+		if hasFlags(flags) {
+			fmt.Fprintln(w, "\nFlags:")
+			flags.PrintDefaults()
+		}
+	}
+
+	return flags, run
+}
+
+// hasFlags returns true if any flags are defined on the given flag set.
+func hasFlags(flags *flag.FlagSet) bool {
+	var found bool
+
+	flags.VisitAll(func(*flag.Flag) {
+		found = true
+	})
+
+	return found
+}
+
+// commands are all of i3x3ctl's commands, in the order they're listed in it's usage. It's set in
+// init, because some commands (e.g. completion) refer to it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{
+			name:    "go",
+			args:    "<direction>",
+			summary: "Switch to the adjacent workspace in the given direction",
+			values:  gridDirections,
+			step:    goStep,
+		},
+		{
+			name:    "move",
+			args:    "<direction>",
+			summary: "Move the focused container to the adjacent workspace in the given direction",
+			values:  gridDirections,
+			step:    moveStep,
+		},
+		{
+			name:    "jump",
+			args:    "[<workspace>]",
+			summary: "Switch straight to a workspace, by it's number, or by it's cell in the grid",
+			help: "Either a workspace number, or both -x and -y must be given. Columns and rows start at 1,\n" +
+				"from the top left of the current output's grid. The cell is on the current page, unless\n" +
+				"-page is given.",
+			step: jumpStep,
+		},
+		{
+			name:    "page",
+			args:    "<" + strings.Join(pages, "|") + "|page>",
+			summary: "Switch to the same cell on the next or previous page of the grid, or on a given page",
+			help: "Pages are numbered from 1. i3x3d only has one page, unless I3X3_PAGES is set to the\n" +
+				"number of pages it should have.",
+			values: pages,
+			step:   pageStep,
+		},
+		{
+			name:    "back",
+			summary: "Go back to the previous workspace switched to with i3x3",
+			step:    historyStep(false),
+		},
+		{
+			name:    "forward",
+			summary: "Go forward to the next workspace switched to with i3x3, after going back",
+			step:    historyStep(true),
+		},
+		{
+			name:    "batch",
+			summary: "Run several commands from stdin, one per line, as one",
+			help: "The go, move, jump, page, back, and forward commands can be used, with the same arguments\n" +
+				"and flags as on their own. Blank lines, and lines starting with # are ignored. The commands are\n" +
+				"checked before any are sent, and are then run by i3x3d in order, without any other commands\n" +
+				"running in between them. The batch stops at the first command that fails. The overlay is\n" +
+				"shown once, at the end.\n\n" +
+				"For example:\n\n" +
+				"  printf 'move right\\ngo down\\njump -x 1 -y 1\\n' | i3x3ctl batch",
+			setup: batchCommand,
+		},
+		{
+			name:    "swap",
+			args:    "[<direction>]",
+			summary: "Swap the current workspace with the workspace in a direction, or at a cell",
+			help:    "Either a direction, or both -x and -y must be given.",
+			values:  gridDirections,
+			setup:   swapCommand,
+		},
+		{
+			name:    "move-workspace",
+			args:    "[<direction>]",
+			summary: "Move the current workspace's containers to the same cell on another output",
+			help:    "Either a direction, or -output must be given.",
+			values:  directions,
+			setup:   moveWorkspaceCommand,
+		},
+		{
+			name:    "label",
+			args:    "[<text>...]",
+			summary: "Label the current workspace, or remove it's label if no text is given",
+			setup:   labelCommand,
+		},
+		{
+			name:    "pick",
+			summary: "Show the overlay, and switch to the workspace picked with the keyboard or mouse",
+			setup:   pickCommand,
+		},
+		{
+			name:    "compact",
+			summary: "Renumber workspaces to fill the holes in each output's grid",
+			setup:   compactCommand,
+		},
+		{
+			name:    "state",
+			summary: "Show the current output's grid, and the workspaces on every output",
+			setup:   stateCommand,
+		},
+		{
+			name:    "watch",
+			summary: "Show the state of the grid each time it changes, until interrupted",
+			setup:   watchCommand,
+		},
+		{
+			name:    "redistribute",
+			summary: "Move every workspace to the output it belongs on, straight away",
+			setup:   redistributeCommand,
+		},
+		{
+			name:    "reload",
+			summary: "Reload the workspace labels from disk, and apply them",
+			setup:   reloadCommand,
+		},
+		{
+			name:    "status",
+			summary: "Check whether i3x3d is running",
+			help:    "Exits with status 1 if i3x3d isn't running.",
+			setup:   statusCommand,
+		},
+		{
+			name:    "version",
+			summary: "Show the versions of i3x3ctl and i3x3d",
+			setup:   versionCommand,
+		},
+		{
+			name:    "gen-config",
+			summary: "Generate i3 (or Sway) config with bindings for i3x3, or check a config for conflicts",
+			help: "The config is written to stdout, to be added to your config. With -check, the bindings that\n" +
+				"would be generated are compared with the given config instead, and any that are already\n" +
+				"bound to something else are listed (exiting with status 1).",
+			setup: genConfigCommand,
+		},
+		{
+			name:    "completion",
+			args:    "<" + strings.Join(shells, "|") + ">",
+			summary: "Generate a shell completion script",
+			help: "For example, add one of these to your shell's configuration:\n\n" +
+				"  source <(i3x3ctl completion bash)\n" +
+				"  source <(i3x3ctl completion zsh)\n" +
+				"  i3x3ctl completion fish | source",
+			values: shells,
+			setup:  completionCommand,
+		},
+	}
+}
+
+// legacyCommand is the command that is run when i3x3ctl is only given flags, as it was before it
+// had subcommands. It's not listed in the usage.
+var legacyCommand = command{
+	summary: "Switch to the adjacent workspace in the given direction (deprecated, use go or move)",
+	step:    legacyStep,
+}
+
+// findCommand finds the command with the given name.
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+// flagValues are the values that flags with a fixed set of values can take, keyed by flag name,
+// for completion.
+var flagValues = map[string][]string{
+	"direction": gridDirections,
+	"keys":      {string(i3config.KeysArrows), string(i3config.KeysVim), string(i3config.KeysBoth)},
+	"mode":      moveModes,
+	"move-mode": moveModes,
+	"wm":        {string(i3config.WMi3), string(i3config.WMSway)},
+}
+
+// direction returns the direction given in the given positional arguments, or the given flag
+// value if there are no positional arguments.
+func direction(args []string, flagValue string) (string, error) {
+	switch {
+	case len(args) > 1:
+		return "", newUsageError("too many arguments: %s", strings.Join(args, " "))
+	case len(args) == 1 && flagValue != "":
+		return "", newUsageError("a direction can't be given as both an argument, and with -direction")
+	case len(args) == 1:
+		return args[0], nil
+	}
+
+	return flagValue, nil
+}
+
+// noArgs returns a usage error if any positional arguments were given.
+func noArgs(args []string) error {
+	if len(args) > 0 {
+		return newUsageError("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	return nil
+}
+
+// goStep defines the go command.
+func goStep(flags *flag.FlagSet) func(args []string) (*proto.BatchStep, error) {
+	disableOverlay := flags.Bool("no-overlay", false, "Don't show the overlay")
+
+	return func(args []string) (*proto.BatchStep, error) {
+		dir, err := direction(args, "")
+		if err == nil {
+			err = validateDirection(dir, gridDirections)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return daemonCommandStep(&proto.DaemonCommand{
+			Direction: dir,
+			Overlay:   !*disableOverlay,
+		}), nil
+	}
+}
+
+// moveStep defines the move command.
+func moveStep(flags *flag.FlagSet) func(args []string) (*proto.BatchStep, error) {
+	disableOverlay := flags.Bool("no-overlay", false, "Don't show the overlay")
+	mode := flags.String("mode", "follow", "How to move containers ("+strings.Join(moveModes, ", ")+")")
+
+	return func(args []string) (*proto.BatchStep, error) {
+		dir, err := direction(args, "")
+		if err == nil {
+			err = validateDirection(dir, gridDirections)
+		}
+
+		if err == nil {
+			err = validateOneOf("move mode", *mode, moveModes)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Following is what moving does without a move mode, so it's left out, which means that it
+		// works with versions of i3x3d from before move modes.
+		moveMode := *mode
+		if moveMode == "follow" {
+			moveMode = ""
+		}
+
+		return daemonCommandStep(&proto.DaemonCommand{
+			Direction: dir,
+			Overlay:   !*disableOverlay,
+			Move:      true,
+			MoveMode:  moveMode,
+		}), nil
+	}
+}
+
+// legacyStep defines the flags that i3x3ctl took before it had subcommands. Unlike before, a
+// direction must be given, instead of defaulting to down.
+func legacyStep(flags *flag.FlagSet) func(args []string) (*proto.BatchStep, error) {
+	move := flags.Bool("move", false, "Whether or not to move the focused container too")
+	moveMode := flags.String("move-mode", "", "How to move containers ("+strings.Join(moveModes, ", ")+"), implies -move")
+	dir := flags.String("direction", "", "The direction to move in ("+strings.Join(gridDirections, ", ")+")")
+	disableOverlay := flags.Bool("no-overlay", false, "Don't show the overlay")
+
+	return func(args []string) (*proto.BatchStep, error) {
+		err := noArgs(args)
+		if err == nil {
+			err = validateDirection(*dir, gridDirections)
+		}
+
+		if err == nil {
+			err = validateMoveMode(*moveMode)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return daemonCommandStep(&proto.DaemonCommand{
+			Direction: *dir,
+			Overlay:   !*disableOverlay,
+			Move:      *move,
+			MoveMode:  *moveMode,
+		}), nil
+	}
+}
+
+// daemonCommandStep wraps the given command in a batch step.
+func daemonCommandStep(cmd *proto.DaemonCommand) *proto.BatchStep {
+	return &proto.BatchStep{
+		Command: &proto.BatchStep_DaemonCommand{DaemonCommand: cmd},
+	}
+}
+
+// jumpStep defines the jump command.
+func jumpStep(flags *flag.FlagSet) func(args []string) (*proto.BatchStep, error) {
+	disableOverlay := flags.Bool("no-overlay", false, "Don't show the overlay")
+	move := flags.Bool("move", false, "Move the focused container to the workspace too")
+	mode := flags.String("mode", "", "How to move containers ("+strings.Join(moveModes, ", ")+"), implies -move")
+	x := flags.Int("x", 0, "The column of the cell to jump to, if no workspace is given")
+	y := flags.Int("y", 0, "The row of the cell to jump to, if no workspace is given")
+	page := flags.Int("page", 0, "The page of the cell to jump to (defaults to the current page)")
+
+	return func(args []string) (*proto.BatchStep, error) {
+		var workspace int
+
+		switch {
+		case len(args) > 1:
+			return nil, newUsageError("too many arguments: %s", strings.Join(args, " "))
+		case len(args) == 1 && (*x != 0 || *y != 0 || *page != 0):
+			return nil, newUsageError("a workspace can't be given along with -x, -y, or -page")
+		case len(args) == 1:
+			num, err := strconv.Atoi(args[0])
+			if err != nil || num < 1 {
+				return nil, newUsageError("invalid workspace: %q (must be a number, from 1)", args[0])
+			}
+
+			workspace = num
+		case *x < 1 || *y < 1:
+			return nil, newUsageError("either a workspace, or both -x and -y (from 1) must be given")
+		case *page < 0:
+			return nil, newUsageError("invalid page: %d (must be from 1)", *page)
+		}
+
+		err := validateMoveMode(*mode)
+		if err != nil {
+			return nil, err
+		}
+
+		return &proto.BatchStep{
+			Command: &proto.BatchStep_Jump{Jump: &proto.JumpCommand{
+				Workspace: int32(workspace),
+				X:         int32(*x),
+				Y:         int32(*y),
+				Page:      int32(*page),
+				Move:      *move || *mode != "",
+				MoveMode:  *mode,
+				Overlay:   !*disableOverlay,
+			}},
+		}, nil
+	}
+}
+
+// pageStep defines the page command.
+func pageStep(flags *flag.FlagSet) func(args []string) (*proto.BatchStep, error) {
+	disableOverlay := flags.Bool("no-overlay", false, "Don't show the overlay")
+	move := flags.Bool("move", false, "Move the focused container to the workspace too")
+	mode := flags.String("mode", "", "How to move containers ("+strings.Join(moveModes, ", ")+"), implies -move")
+
+	return func(args []string) (*proto.BatchStep, error) {
+		if len(args) != 1 {
+			return nil, newUsageError("a page is required (%s, or a number from 1)", strings.Join(pages, ", "))
+		}
+
+		err := validateMoveMode(*mode)
+		if err != nil {
+			return nil, err
+		}
+
+		var dir grid.Direction
+
+		switch args[0] {
+		case "next":
+			dir = grid.NextPage
+		case "prev":
+			dir = grid.PrevPage
+		}
+
+		if dir != "" {
+			return daemonCommandStep(&proto.DaemonCommand{
+				Direction: string(dir),
+				Overlay:   !*disableOverlay,
+				Move:      *move || *mode != "",
+				MoveMode:  *mode,
+			}), nil
+		}
+
+		num, err := strconv.Atoi(args[0])
+		if err != nil || num < 1 {
+			return nil, newUsageError("invalid page: %q (must be %s, or a number from 1)", args[0], strings.Join(pages, ", "))
+		}
+
+		return &proto.BatchStep{
+			Command: &proto.BatchStep_Jump{Jump: &proto.JumpCommand{
+				Page:     int32(num),
+				Move:     *move || *mode != "",
+				MoveMode: *mode,
+				Overlay:  !*disableOverlay,
+			}},
+		}, nil
+	}
+}
+
+// historyStep returns the function that defines the back command, or the forward command.
+func historyStep(forward bool) stepSetup {
+	return func(flags *flag.FlagSet) func(args []string) (*proto.BatchStep, error) {
+		disableOverlay := flags.Bool("no-overlay", false, "Don't show the overlay")
+
+		return func(args []string) (*proto.BatchStep, error) {
+			err := noArgs(args)
+			if err != nil {
+				return nil, err
+			}
+
+			return &proto.BatchStep{
+				Command: &proto.BatchStep_History{History: &proto.HistoryCommand{
+					Forward: forward,
+					Overlay: !*disableOverlay,
+				}},
+			}, nil
+		}
+	}
+}
+
+// swapCommand defines the swap command, which swaps the contents of the current workspace with the
+// workspace in a given direction, or at a given cell.
+func swapCommand(flags *flag.FlagSet) func(args []string) error {
+	dirFlag := flags.String("direction", "", "The direction to swap with ("+strings.Join(gridDirections, ", ")+")")
+	x := flags.Int("x", 0, "The column of the cell to swap with, if no direction is given")
+	y := flags.Int("y", 0, "The row of the cell to swap with, if no direction is given")
+	disableOverlay := flags.Bool("no-overlay", false, "Don't show the overlay")
+
+	return func(args []string) error {
+		dir, err := direction(args, *dirFlag)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case dir != "":
+			err = validateDirection(dir, gridDirections)
+		case *x < 1 || *y < 1:
+			err = newUsageError("either a direction, or both -x and -y (from 1) must be given")
+		}
+
+		if err != nil {
+			return err
+		}
+
+		features := append([]string{version.FeatureSwap}, directionFeatures(dir)...)
+
+		return withClient(commandTimeout, features, func(ctx context.Context, client proto.DaemonServiceClient) error {
+			resp, err := client.Swap(ctx, &proto.SwapCommand{
+				Direction: dir,
+				X:         int32(*x),
+				Y:         int32(*y),
+				Overlay:   !*disableOverlay,
+			})
+
+			if err != nil {
+				return err
+			}
+
+			respond(resp)
+			return nil
+		})
+	}
+}
+
+// moveWorkspaceCommand defines the move-workspace command, which moves all containers on the
+// current workspace to the same cell on another output.
+func moveWorkspaceCommand(flags *flag.FlagSet) func(args []string) error {
+	dirFlag := flags.String("direction", "", "The direction of the output to move to ("+strings.Join(directions, ", ")+")")
+	output := flags.String("output", "", "The name of the output to move to, if no direction is given")
+	follow := flags.Bool("follow", false, "Whether or not to switch to the workspace on the other output")
+	disableOverlay := flags.Bool("no-overlay", false, "Don't show the overlay")
+
+	return func(args []string) error {
+		dir, err := direction(args, *dirFlag)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case dir != "" && *output != "":
+			err = newUsageError("a direction can't be given along with -output")
+		case dir != "":
+			err = validateDirection(dir, directions)
+		case *output == "":
+			err = newUsageError("either a direction, or -output must be given")
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return withClient(commandTimeout, []string{version.FeatureMoveWorkspace}, func(ctx context.Context, client proto.DaemonServiceClient) error {
+			resp, err := client.MoveWorkspace(ctx, &proto.MoveWorkspaceCommand{
+				Direction: dir,
+				Output:    *output,
+				Follow:    *follow,
+				Overlay:   !*disableOverlay,
+			})
+
+			if err != nil {
+				return err
+			}
+
+			respond(resp)
+			return nil
+		})
+	}
+}
+
+// labelCommand defines the label command.
+func labelCommand(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		return withClient(commandTimeout, []string{version.FeatureLabel}, func(ctx context.Context, client proto.DaemonServiceClient) error {
+			resp, err := client.Label(ctx, &proto.LabelCommand{
+				Label: strings.Join(args, " "),
+			})
+
+			if err != nil {
+				return err
+			}
+
+			respond(resp)
+			return nil
+		})
+	}
+}
+
+// pickCommand defines the pick command. It waits until a workspace is picked, or the pick is
+// cancelled.
+func pickCommand(flags *flag.FlagSet) func(args []string) error {
+	moveMode := flags.String("move-mode", "", "How to move containers when picking with shift held ("+strings.Join(moveModes, ", ")+")")
+
+	return func(args []string) error {
+		err := noArgs(args)
+		if err == nil {
+			err = validateMoveMode(*moveMode)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return withClient(rpc.PickTimeout+time.Second, []string{version.FeaturePick}, func(ctx context.Context, client proto.DaemonServiceClient) error {
+			resp, err := client.Pick(ctx, &proto.PickCommand{
+				MoveMode: *moveMode,
+			})
+
+			if err != nil {
+				return err
+			}
+
+			respond(resp)
+			return nil
+		})
+	}
+}
+
+// compactCommand defines the compact command. The renames are printed, which is especially useful
+// for a dry run.
+func compactCommand(flags *flag.FlagSet) func(args []string) error {
+	dryRun := flags.Bool("dry-run", false, "List the renames that would be made, without making them")
+	shrink := flags.Bool("shrink", false, "Merge workspaces that don't fit in the grid into the last cell")
+
+	return func(args []string) error {
+		err := noArgs(args)
+		if err != nil {
+			return err
+		}
+
+		return withClient(commandTimeout, []string{version.FeatureCompact}, func(ctx context.Context, client proto.DaemonServiceClient) error {
+			resp, err := client.Compact(ctx, &proto.CompactCommand{
+				DryRun: *dryRun,
+				Shrink: *shrink,
+			})
+
+			if err != nil {
+				return err
+			}
+
+			for _, rename := range resp.Renames {
+				if rename.Merge {
+					fmt.Printf("%d -> %d (merge)\n", rename.From, rename.To)
+				} else {
+					fmt.Printf("%d -> %d\n", rename.From, rename.To)
+				}
+			}
+
+			return nil
+		})
+	}
+}
+
+// stateCommand defines the state command.
+func stateCommand(flags *flag.FlagSet) func(args []string) error {
+	asJSON := flags.Bool("json", false, "Print the state as JSON")
+
+	return func(args []string) error {
+		err := noArgs(args)
+		if err != nil {
+			return err
+		}
+
+		return withClient(commandTimeout, []string{version.FeatureState}, func(ctx context.Context, client proto.DaemonServiceClient) error {
+			res, err := client.State(ctx, &proto.StateRequest{})
+			if err != nil {
+				return err
+			}
+
+			if *asJSON {
+				return printJSON(os.Stdout, res, "  ")
+			}
+
+			printState(os.Stdout, res)
+			return nil
+		})
+	}
+}
+
+// watchCommand defines the watch command.
+func watchCommand(flags *flag.FlagSet) func(args []string) error {
+	asJSON := flags.Bool("json", false, "Print the state as JSON, one line each time it changes")
+
+	return func(args []string) error {
+		err := noArgs(args)
+		if err != nil {
+			return err
+		}
+
+		return withClient(0, []string{version.FeatureWatch}, func(ctx context.Context, client proto.DaemonServiceClient) error {
+			stream, err := client.Watch(ctx, &proto.WatchRequest{})
+			if err != nil {
+				return err
+			}
+
+			for {
+				res, err := stream.Recv()
+				if err == io.EOF {
+					return nil
+				}
+
+				if err != nil {
+					return err
+				}
+
+				if *asJSON {
+					err = printJSON(os.Stdout, res, "")
+					if err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				fmt.Print(overlay.TerminalClear)
+				printState(os.Stdout, res)
+			}
+		})
+	}
+}
+
+// printJSON writes the given value to the given writer as JSON, followed by a new line. If indent
+// is empty, it's written on a single line.
+func printJSON(w io.Writer, v interface{}, indent string) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", indent)
+
+	return encoder.Encode(v)
+}
+
+// printState writes the given state to the given writer; the current output's grid, followed by a
+// table of every workspace.
+func printState(w io.Writer, res *proto.StateResponse) {
+	fmt.Fprintln(w, strings.TrimRight(res.Grid, "\n"))
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "WORKSPACE\tCELL\tOUTPUT\tSTATE\tLABEL")
+
+	for _, ws := range res.Workspaces {
+		var states []string
+		if ws.Focused {
+			states = append(states, "focused")
+		} else if ws.Visible {
+			states = append(states, "visible")
+		}
+
+		if ws.Urgent {
+			states = append(states, "urgent")
+		}
+
+		cell := "-"
+		if ws.X > 0 && ws.Y > 0 {
+			cell = fmt.Sprintf("%d,%d", ws.X, ws.Y)
+		}
+
+		if res.Pages > 1 && ws.Page > 0 {
+			cell += fmt.Sprintf(" (page %d)", ws.Page)
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", ws.Num, cell, ws.Output, strings.Join(states, ","), ws.Label)
+	}
+
+	tw.Flush()
+}
+
+// redistributeCommand defines the redistribute command.
+func redistributeCommand(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		err := noArgs(args)
+		if err != nil {
+			return err
+		}
+
+		return withClient(commandTimeout, []string{version.FeatureRedistribute}, func(ctx context.Context, client proto.DaemonServiceClient) error {
+			resp, err := client.Redistribute(ctx, &proto.RedistributeCommand{})
+			if err != nil {
+				return err
+			}
+
+			respond(resp)
+			return nil
+		})
+	}
+}
+
+// reloadCommand defines the reload command.
+func reloadCommand(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		err := noArgs(args)
+		if err != nil {
+			return err
+		}
+
+		return withClient(commandTimeout, []string{version.FeatureReload}, func(ctx context.Context, client proto.DaemonServiceClient) error {
+			resp, err := client.Reload(ctx, &proto.ReloadCommand{})
+			if err != nil {
+				return err
+			}
+
+			respond(resp)
+			return nil
+		})
+	}
+}
+
+// statusCommand defines the status command.
+func statusCommand(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		err := noArgs(args)
+		if err != nil {
+			return err
+		}
+
+		return withRunningClient(commandTimeout, nil, func(ctx context.Context, client proto.DaemonServiceClient) error {
+			res, err := client.State(ctx, &proto.StateRequest{})
+			switch status.Code(err) {
+			case codes.OK:
+			case codes.Unavailable:
+				return errors.New("i3x3d is not running")
+			case codes.Unimplemented:
+				return errors.New("i3x3d is running, but is older than i3x3ctl; restart i3x3d to use the new version")
+			default:
+				return fmt.Errorf("i3x3d isn't responding: %s", describe(err))
+			}
+
+			fmt.Printf("i3x3d is running: %d output(s), %dx%d grid, on workspace %d\n",
+				len(res.Outputs), res.Columns, res.Rows, res.CurrentWorkspace)
+
+			return nil
+		})
+	}
+}
+
+// genConfigCommand defines the gen-config command.
+func genConfigCommand(flags *flag.FlagSet) func(args []string) error {
+	opts := i3config.DefaultOptions()
+	opts.Columns = envAsInt("I3X3_X_SIZE", opts.Columns)
+	opts.Rows = envAsInt("I3X3_Y_SIZE", opts.Rows)
+	opts.Pages = envAsInt("I3X3_PAGES", opts.Pages)
+
+	wm := flags.String("wm", string(opts.WM), "The window manager to generate config for (i3, sway)")
+	keys := flags.String("keys", string(opts.Keys), "The keys to use for directions (arrows, hjkl, both)")
+	flags.StringVar(&opts.Mod, "mod", opts.Mod, "The modifiers to use for switching workspace")
+	flags.StringVar(&opts.MoveMod, "move-mod", opts.MoveMod, "The modifiers to use for moving containers")
+	flags.IntVar(&opts.Columns, "x", opts.Columns, "The number of columns in the grid")
+	flags.IntVar(&opts.Rows, "y", opts.Rows, "The number of rows in the grid")
+	flags.IntVar(&opts.Pages, "pages", opts.Pages, "The number of pages of the grid")
+	check := flags.String("check", "", "The path to an existing config to check for conflicting bindings")
+
+	return func(args []string) error {
+		err := noArgs(args)
+		if err != nil {
+			return err
+		}
+
+		opts.WM = i3config.WM(*wm)
+		opts.Keys = i3config.Keys(*keys)
+
+		err = opts.Validate()
+		if err != nil {
+			return newUsageError("%s", strings.TrimPrefix(err.Error(), "i3config: "))
+		}
+
+		if *check == "" {
+			return i3config.Generate(os.Stdout, opts)
+		}
+
+		file, err := os.Open(*check)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		config, err := i3config.Parse(file)
+		if err != nil {
+			return err
+		}
+
+		conflicts := i3config.Conflicts(config, i3config.Bindings(opts))
+		for _, conflict := range conflicts {
+			fmt.Printf("%s:%d: %s is bound to %q, which i3x3 would bind to %q\n",
+				*check, conflict.Existing.Line, conflict.Existing.Keys, conflict.Existing.Command, conflict.Generated.Command)
+		}
+
+		if len(conflicts) > 0 {
+			return fmt.Errorf("found %d conflicting binding(s)", len(conflicts))
+		}
+
+		fmt.Println("no conflicting bindings found")
+		return nil
+	}
+}
+
+// envAsInt returns the value of the given environment variable as an int, or the given fallback if
+// it isn't set, or isn't a valid int.
+func envAsInt(key string, fallback int) int {
+	val, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+
+	return val
+}
+
+// versionCommand defines the version command.
+func versionCommand(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		err := noArgs(args)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("i3x3ctl %s\n", version.String(version.Version, version.Commit, version.Date))
+
+		return withRunningClient(commandTimeout, nil, func(ctx context.Context, client proto.DaemonServiceClient) error {
+			res, err := client.Version(ctx, &proto.VersionRequest{})
+			switch status.Code(err) {
+			case codes.OK:
+			case codes.Unavailable:
+				fmt.Println("i3x3d isn't running")
+				return nil
+			case codes.Unimplemented:
+				fmt.Println("i3x3d is too old to report it's version; restart i3x3d to use the new version")
+				return nil
+			default:
+				return err
+			}
+
+			fmt.Printf("i3x3d %s\n", version.String(res.Version, res.Commit, res.Date))
+
+			if missing := version.Missing(res.Features, version.Features...); len(missing) > 0 {
+				fmt.Printf("i3x3d doesn't support %s; restart i3x3d to use the new version\n", strings.Join(missing, ", "))
+			} else if res.Version != version.Version || res.Commit != version.Commit {
+				fmt.Println("i3x3d is a different version to i3x3ctl; restart i3x3d to use the new version")
+			}
+
+			return nil
+		})
+	}
+}
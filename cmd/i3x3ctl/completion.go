@@ -0,0 +1,250 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// shells are the shells that completion scripts can be generated for.
+var shells = []string{"bash", "zsh", "fish"}
+
+// helpSummary is the summary of the help command, which isn't in the command table, because it
+// refers to the command table.
+const helpSummary = "Show the usage of i3x3ctl, or of a command"
+
+// completionFlag is a flag that is completed, along with the values it can take, if it only takes
+// a fixed set of values.
+type completionFlag struct {
+	name   string
+	usage  string
+	values []string
+}
+
+// completionFlags returns the flags of the given command, for completion.
+func completionFlags(cmd command) []completionFlag {
+	var flags []completionFlag
+
+	fs, _ := cmd.flagSet()
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, completionFlag{
+			name:   f.Name,
+			usage:  f.Usage,
+			values: flagValues[f.Name],
+		})
+	})
+
+	return flags
+}
+
+// commandNames returns the names of every command, including help.
+func commandNames() []string {
+	names := []string{"help"}
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+
+	return names
+}
+
+// valueFlagNames returns the names of the flags in flagValues, sorted so that the generated
+// scripts don't change between runs.
+func valueFlagNames() []string {
+	var names []string
+	for name := range flagValues {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// completionCommand defines the completion command.
+func completionCommand(flags *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) != 1 {
+			return newUsageError("a shell is required (%s)", strings.Join(shells, ", "))
+		}
+
+		switch args[0] {
+		case "bash":
+			writeBashCompletion(os.Stdout)
+		case "zsh":
+			writeZshCompletion(os.Stdout)
+		case "fish":
+			writeFishCompletion(os.Stdout)
+		default:
+			return validateOneOf("shell", args[0], shells)
+		}
+
+		return nil
+	}
+}
+
+// writeBashCompletion writes a bash completion script for i3x3ctl to the given writer.
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintln(w, `# bash completion for i3x3ctl, generated by "i3x3ctl completion bash".`)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "_i3x3ctl() {")
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}"`)
+	fmt.Fprintln(w, `    local prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `    if [ "$COMP_CWORD" -eq 1 ]; then`)
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(commandNames(), " "))
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `    case "$prev" in`)
+
+	for _, name := range valueFlagNames() {
+		fmt.Fprintf(w, "        -%s|--%s)\n", name, name)
+		fmt.Fprintf(w, "            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(flagValues[name], " "))
+		fmt.Fprintln(w, "            return")
+		fmt.Fprintln(w, "            ;;")
+	}
+
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `    case "${COMP_WORDS[1]}" in`)
+	fmt.Fprintln(w, "        help)")
+	fmt.Fprintf(w, "            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(commandNames()[1:], " "))
+	fmt.Fprintln(w, "            ;;")
+
+	for _, cmd := range commands {
+		words := append([]string(nil), cmd.values...)
+		for _, f := range completionFlags(cmd) {
+			words = append(words, "-"+f.name)
+		}
+
+		if len(words) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "        %s)\n", cmd.name)
+		fmt.Fprintf(w, "            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(words, " "))
+		fmt.Fprintln(w, "            ;;")
+	}
+
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "complete -F _i3x3ctl i3x3ctl")
+}
+
+// writeZshCompletion writes a zsh completion script for i3x3ctl to the given writer.
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprintln(w, "#compdef i3x3ctl")
+	fmt.Fprintln(w, `# zsh completion for i3x3ctl, generated by "i3x3ctl completion zsh".`)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "_i3x3ctl() {")
+	fmt.Fprintln(w, "    local -a commands")
+	fmt.Fprintln(w, "    commands=(")
+	fmt.Fprintf(w, "        %s\n", shellQuote("help:"+helpSummary))
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "        %s\n", shellQuote(cmd.name+":"+cmd.summary))
+	}
+
+	fmt.Fprintln(w, "    )")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "    if (( CURRENT == 2 )); then")
+	fmt.Fprintln(w, "        _describe -t commands 'i3x3ctl command' commands")
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `    case "${words[CURRENT-1]}" in`)
+
+	for _, name := range valueFlagNames() {
+		fmt.Fprintf(w, "        -%s|--%s)\n", name, name)
+		fmt.Fprintf(w, "            compadd -- %s\n", strings.Join(flagValues[name], " "))
+		fmt.Fprintln(w, "            return")
+		fmt.Fprintln(w, "            ;;")
+	}
+
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `    case "${words[2]}" in`)
+	fmt.Fprintln(w, "        help)")
+	fmt.Fprintln(w, "            _describe -t commands 'i3x3ctl command' commands")
+	fmt.Fprintln(w, "            ;;")
+
+	for _, cmd := range commands {
+		flags := completionFlags(cmd)
+		if len(flags) == 0 && len(cmd.values) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "        %s)\n", cmd.name)
+
+		if len(flags) > 0 {
+			fmt.Fprintln(w, "            local -a flags")
+			fmt.Fprintln(w, "            flags=(")
+
+			for _, f := range flags {
+				fmt.Fprintf(w, "                %s\n", shellQuote("-"+f.name+":"+f.usage))
+			}
+
+			fmt.Fprintln(w, "            )")
+			fmt.Fprintln(w, "            _describe -t flags 'flag' flags")
+		}
+
+		if len(cmd.values) > 0 {
+			fmt.Fprintf(w, "            compadd -- %s\n", strings.Join(cmd.values, " "))
+		}
+
+		fmt.Fprintln(w, "            ;;")
+	}
+
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "compdef _i3x3ctl i3x3ctl")
+}
+
+// writeFishCompletion writes a fish completion script for i3x3ctl to the given writer.
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintln(w, `# fish completion for i3x3ctl, generated by "i3x3ctl completion fish".`)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "complete -c i3x3ctl -f")
+	fmt.Fprintf(w, "complete -c i3x3ctl -n __fish_use_subcommand -a help -d %s\n", fishQuote(helpSummary))
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "complete -c i3x3ctl -n __fish_use_subcommand -a %s -d %s\n", cmd.name, fishQuote(cmd.summary))
+	}
+
+	fmt.Fprintf(w, "complete -c i3x3ctl -n '__fish_seen_subcommand_from help' -a %s\n", fishQuote(strings.Join(commandNames()[1:], " ")))
+
+	for _, cmd := range commands {
+		condition := fishQuote("__fish_seen_subcommand_from " + cmd.name)
+
+		for _, f := range completionFlags(cmd) {
+			if len(f.values) > 0 {
+				fmt.Fprintf(w, "complete -c i3x3ctl -n %s -o %s -x -a %s -d %s\n", condition, f.name, fishQuote(strings.Join(f.values, " ")), fishQuote(f.usage))
+			} else {
+				fmt.Fprintf(w, "complete -c i3x3ctl -n %s -o %s -d %s\n", condition, f.name, fishQuote(f.usage))
+			}
+		}
+
+		if len(cmd.values) > 0 {
+			fmt.Fprintf(w, "complete -c i3x3ctl -n %s -a %s\n", condition, fishQuote(strings.Join(cmd.values, " ")))
+		}
+	}
+}
+
+// shellQuote quotes the given string for bash or zsh, using single quotes.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// fishQuote quotes the given string for fish, using single quotes. Unlike bash and zsh, fish allows
+// quotes to be escaped inside single quotes.
+func fishQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "'", `\'`, -1)
+
+	return "'" + s + "'"
+}
@@ -1,55 +1,363 @@
+// Command i3x3ctl controls i3x3d, the i3x3 daemon. It's usually run from i3 key bindings, e.g.:
+//
+//	bindsym $mod+Left exec --no-startup-id i3x3ctl go left
+//	bindsym $mod+Shift+Left exec --no-startup-id i3x3ctl move left
+//
+// Run "i3x3ctl help" for the full list of commands. The flags that i3x3ctl used to take on their
+// own (e.g. "i3x3ctl -direction left -move") still work, so existing i3 configs keep working.
 package main
 
 import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/seeruk/i3x3/internal/grid"
 	"github.com/seeruk/i3x3/internal/proto"
 	"github.com/seeruk/i3x3/internal/rpc"
+	"github.com/seeruk/i3x3/internal/version"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// Exit codes used by i3x3ctl.
+const (
+	// exitError is used when a command fails, e.g. if the daemon returns an error.
+	exitError = 1
+	// exitUsage is used when i3x3ctl is used incorrectly, e.g. with an invalid argument.
+	exitUsage = 2
+)
+
+// directions are the directions that can be given to commands that move between outputs.
+var directions = []string{
+	string(grid.Up),
+	string(grid.Down),
+	string(grid.Left),
+	string(grid.Right),
+}
+
+// diagonals are the diagonal directions, which can only be used within the grid.
+var diagonals = []string{
+	string(grid.UpLeft),
+	string(grid.UpRight),
+	string(grid.DownLeft),
+	string(grid.DownRight),
+}
+
+// gridDirections are the directions that can be given to commands that move around the grid.
+var gridDirections = append(append([]string(nil), directions...), diagonals...)
+
+// pages are the pages, other than by number, that can be given to the page command.
+var pages = []string{"next", "prev"}
+
+// moveModes are the ways that containers can be moved to another workspace.
+var moveModes = []string{"follow", "stay", "all"}
+
+// usageError is an error caused by i3x3ctl being used incorrectly. It's reported along with a hint
+// about where to find the command's usage.
+type usageError struct {
+	message string
+}
+
+// newUsageError creates a new usageError, formatting it's message in the same way as fmt.Sprintf.
+func newUsageError(format string, args ...interface{}) error {
+	return usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// Error returns the error's message.
+func (e usageError) Error() string {
+	return e.message
+}
+
 func main() {
-	var direction string
-	var disableOverlay bool
-	var move bool
+	log.SetFlags(0)
 
-	flag.BoolVar(&move, "move", false, "Whether or not to move the focused container too")
-	flag.StringVar(&direction, "direction", "down", "The direction to move in (up, down, left, right)")
-	flag.BoolVar(&disableOverlay, "no-overlay", false, "Used to disable the GTK-based overlay")
-	flag.Parse()
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs i3x3ctl with the given arguments, returning the exit code.
+func run(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		return help(args[1:])
+	}
+
+	// Existing i3 configs run i3x3ctl with only flags, e.g. "i3x3ctl -direction left -move".
+	if strings.HasPrefix(args[0], "-") {
+		return runCommand(legacyCommand, args)
+	}
+
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		log.Printf("i3x3ctl: unknown command: %q\n\n", args[0])
+		usage(os.Stderr)
+		return exitUsage
+	}
+
+	return runCommand(cmd, args[1:])
+}
+
+// help shows the usage of the command named by the given arguments, or of i3x3ctl itself if no
+// command is named.
+func help(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stdout)
+		return 0
+	}
+
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		log.Printf("i3x3ctl: unknown command: %q\n", args[0])
+		return exitUsage
+	}
+
+	flags, _ := cmd.flagSet()
+	flags.SetOutput(os.Stdout)
+	flags.Usage()
+
+	return 0
+}
+
+// usage writes i3x3ctl's usage, listing every command, to the given writer.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: i3x3ctl <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "i3x3ctl help <command>" for more information about a command.`)
+}
+
+// runCommand parses the given arguments with the given command's flags, and then runs it, returning
+// the exit code.
+func runCommand(cmd command, args []string) int {
+	flags, run := cmd.flagSet()
+
+	positional, err := parse(flags, args)
+	if err == flag.ErrHelp {
+		return 0
+	}
+
+	if err != nil {
+		// The flag package has already reported the error, along with the command's usage.
+		return exitUsage
+	}
+
+	err = run(positional)
+	if err == nil {
+		return 0
+	}
+
+	prefix := "i3x3ctl"
+	if cmd.name != "" {
+		prefix += " " + cmd.name
+	}
+
+	if _, ok := err.(usageError); ok {
+		log.Printf("%s: %v\n", prefix, err)
+		log.Printf("Run %q for usage.\n", strings.TrimSpace("i3x3ctl help "+cmd.name))
+		return exitUsage
+	}
+
+	log.Printf("%s: %s\n", prefix, describe(err))
+	return exitError
+}
+
+// parse parses the given arguments with the given flag set, returning the positional arguments.
+// Unlike the flag package on it's own, flags may come after positional arguments, e.g.
+// "go left -no-overlay". Everything after "--" is positional.
+func parse(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		err := flags.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+
+		consumed := len(args) - flags.NArg()
+		if consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, flags.Args()...), nil
+		}
+
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// describe returns a description of the given error for the user. Errors from the daemon are
+// stripped of the gRPC details that aren't useful to the user.
+func describe(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err.Error()
+	}
+
+	switch st.Code() {
+	case codes.Unavailable:
+		if autoStart() {
+			return "couldn't connect to i3x3d, is it running?"
+		}
+
+		return fmt.Sprintf("couldn't connect to i3x3d, is it running? (set %s=1 to start it automatically)", autoStartEnv)
+	case codes.DeadlineExceeded:
+		return "timed out waiting for i3x3d"
+	}
+
+	return st.Message()
+}
+
+// withClient connects to i3x3d, checks that it supports the given features, and calls the given
+// function with a client for it. The context given to the function is cancelled after the given
+// timeout, unless it's 0. If auto-starting is enabled, i3x3d is started first if it isn't running.
+func withClient(timeout time.Duration, features []string, fn func(ctx context.Context, client proto.DaemonServiceClient) error) error {
+	if autoStart() {
+		err := ensureRunning()
+		if err != nil {
+			return err
+		}
+	}
+
+	return withRunningClient(timeout, features, fn)
+}
+
+// withRunningClient is like withClient, but never starts i3x3d. It's used by commands that report
+// whether i3x3d is running.
+func withRunningClient(timeout time.Duration, features []string, fn func(ctx context.Context, client proto.DaemonServiceClient) error) error {
+	ctx, cfn := context.WithCancel(context.Background())
+	if timeout > 0 {
+		ctx, cfn = context.WithTimeout(context.Background(), timeout)
+	}
 
-	ctx, cfn := context.WithTimeout(context.Background(), rpc.DefaultTimeout+time.Second)
 	defer cfn()
 
-	// @TODO: Use a secure connection? Is it important?
-	// @TODO: Investigate connection via unix socket.
-	conn, err := grpc.DialContext(ctx, fmt.Sprintf("127.0.0.1:%v", rpc.DefaultPort), grpc.WithInsecure())
-	fatal(err)
+	conn, err := dial(ctx)
+	if err != nil {
+		return err
+	}
 
 	defer conn.Close()
 
 	client := proto.NewDaemonServiceClient(conn)
 
-	resp, err := client.HandleCommand(ctx, &proto.DaemonCommand{
-		Direction: direction,
-		Overlay:   !disableOverlay,
-		Move:      move,
-	})
+	err = checkFeatures(ctx, client, features)
+	if err != nil {
+		return err
+	}
+
+	return fn(ctx, client)
+}
+
+// checkFeatures returns an error if i3x3d doesn't support all of the given features. Without this,
+// an i3x3d that's older than i3x3ctl would ignore the parts of a request that it doesn't know about,
+// e.g. after upgrading i3x3 without restarting i3x3d.
+func checkFeatures(ctx context.Context, client proto.DaemonServiceClient, features []string) error {
+	if len(features) == 0 {
+		return nil
+	}
+
+	res, err := client.Version(ctx, &proto.VersionRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return fmt.Errorf("i3x3d is older than i3x3ctl %s, and doesn't support %s; restart i3x3d to use the new version",
+			version.Version, strings.Join(features, ", "))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	missing := version.Missing(res.Features, features...)
+	if len(missing) > 0 {
+		return fmt.Errorf("i3x3d %s doesn't support %s, which i3x3ctl %s needs; restart i3x3d to use the new version",
+			res.Version, strings.Join(missing, ", "), version.Version)
+	}
+
+	return nil
+}
+
+// dial connects to i3x3d.
+func dial(ctx context.Context) (*grpc.ClientConn, error) {
+	// @TODO: Use a secure connection? Is it important?
+	// @TODO: Investigate connection via unix socket.
+	conn, err := grpc.DialContext(ctx, address(), grpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to i3x3d: %v", err)
+	}
 
-	fatal(err)
+	return conn, nil
+}
+
+// address returns the address that i3x3d listens on.
+func address() string {
+	return fmt.Sprintf("127.0.0.1:%v", rpc.DefaultPort)
+}
 
+// respond logs the message in the given response, if there is one.
+func respond(resp *proto.DaemonCommandResponse) {
 	if resp.Message != "" {
 		log.Printf("i3x3ctl: response from server: %s\n", resp.Message)
 	}
 }
 
-// fatal panics if the given error is not nil.
-func fatal(err error) {
-	if err != nil {
-		panic(err)
+// validateDirection returns a usage error if the given direction isn't one of the given valid
+// directions.
+func validateDirection(direction string, valid []string) error {
+	if direction == "" {
+		return newUsageError("a direction is required (%s)", strings.Join(valid, ", "))
+	}
+
+	return validateOneOf("direction", direction, valid)
+}
+
+// directionFeatures returns the features that i3x3d needs to support to move in the given direction.
+func directionFeatures(direction string) []string {
+	if _, ok := grid.Diagonals[grid.Direction(direction)]; ok {
+		return []string{version.FeatureDiagonal}
+	}
+
+	return nil
+}
+
+// validateMoveMode returns a usage error if the given move mode isn't empty, or one of the move
+// modes.
+func validateMoveMode(mode string) error {
+	if mode == "" {
+		return nil
 	}
+
+	return validateOneOf("move mode", mode, moveModes)
+}
+
+// validateOneOf returns a usage error if the given value isn't one of the given valid values.
+func validateOneOf(name, value string, valid []string) error {
+	for _, v := range valid {
+		if value == v {
+			return nil
+		}
+	}
+
+	return newUsageError("invalid %s: %q (must be one of %s)", name, value, strings.Join(valid, ", "))
 }
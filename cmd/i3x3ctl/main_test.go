@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+// testFlags creates a flag set with a bool and a string flag, that doesn't write anything.
+func testFlags() (*flag.FlagSet, *bool, *string) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+
+	b := flags.Bool("b", false, "")
+	s := flags.String("s", "", "")
+
+	return flags, b, s
+}
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		args       []string
+		positional []string
+		b          bool
+		s          string
+	}{
+		{nil, nil, false, ""},
+		{[]string{"left"}, []string{"left"}, false, ""},
+		{[]string{"-b", "left"}, []string{"left"}, true, ""},
+		{[]string{"left", "-b"}, []string{"left"}, true, ""},
+		{[]string{"left", "-s", "x", "right"}, []string{"left", "right"}, false, "x"},
+		{[]string{"left", "--", "-b"}, []string{"left", "-b"}, false, ""},
+		{[]string{"-s", "x", "--", "left", "-b"}, []string{"left", "-b"}, false, "x"},
+		{[]string{"--"}, nil, false, ""},
+	}
+
+	for _, test := range tests {
+		flags, b, s := testFlags()
+
+		positional, err := parse(flags, test.args)
+		if err != nil {
+			t.Errorf("Expected no error parsing %q, got %v", test.args, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(positional, test.positional) {
+			t.Errorf("Expected positional arguments %q for %q, got %q", test.positional, test.args, positional)
+		}
+
+		if *b != test.b || *s != test.s {
+			t.Errorf("Expected flags %v and %q for %q, got %v and %q", test.b, test.s, test.args, *b, *s)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for _, args := range [][]string{{"-unknown"}, {"left", "-unknown"}, {"left", "-s"}} {
+		flags, _, _ := testFlags()
+
+		_, err := parse(flags, args)
+		if err == nil {
+			t.Errorf("Expected an error parsing %q", args)
+		}
+	}
+}
+
+func TestDirection(t *testing.T) {
+	var tests = []struct {
+		args      []string
+		flagValue string
+		expected  string
+		usage     bool
+	}{
+		{nil, "", "", false},
+		{nil, "up", "up", false},
+		{[]string{"left"}, "", "left", false},
+		{[]string{"left"}, "up", "", true},
+		{[]string{"left", "right"}, "", "", true},
+	}
+
+	for _, test := range tests {
+		dir, err := direction(test.args, test.flagValue)
+		if _, ok := err.(usageError); ok != test.usage {
+			t.Errorf("Expected usage error to be %v for %q and %q, got %v", test.usage, test.args, test.flagValue, err)
+		}
+
+		if dir != test.expected {
+			t.Errorf("Expected direction %q for %q and %q, got %q", test.expected, test.args, test.flagValue, dir)
+		}
+	}
+}
+
+func TestValidateDirection(t *testing.T) {
+	var tests = []struct {
+		direction string
+		valid     []string
+		usage     bool
+	}{
+		{"left", directions, false},
+		{"up-left", gridDirections, false},
+		{"up-left", directions, true},
+		{"sideways", gridDirections, true},
+		{"", gridDirections, true},
+	}
+
+	for _, test := range tests {
+		err := validateDirection(test.direction, test.valid)
+		if _, ok := err.(usageError); ok != test.usage {
+			t.Errorf("Expected usage error to be %v for %q, got %v", test.usage, test.direction, err)
+		}
+	}
+}
+
+func TestValidateMoveMode(t *testing.T) {
+	var tests = []struct {
+		mode  string
+		usage bool
+	}{
+		{"", false},
+		{"follow", false},
+		{"stay", false},
+		{"all", false},
+		{"leave", true},
+	}
+
+	for _, test := range tests {
+		err := validateMoveMode(test.mode)
+		if _, ok := err.(usageError); ok != test.usage {
+			t.Errorf("Expected usage error to be %v for %q, got %v", test.usage, test.mode, err)
+		}
+	}
+}
+
+func TestLegacyStep(t *testing.T) {
+	var tests = []struct {
+		args     []string
+		expected *proto.DaemonCommand
+	}{
+		{[]string{"-direction", "left"}, &proto.DaemonCommand{Direction: "left", Overlay: true}},
+		{[]string{"-direction", "down", "-move", "-no-overlay"}, &proto.DaemonCommand{Direction: "down", Move: true}},
+		{[]string{"-move-mode", "stay", "-direction", "up-right"}, &proto.DaemonCommand{Direction: "up-right", Overlay: true, MoveMode: "stay"}},
+		// Unlike before subcommands, a direction is required.
+		{[]string{"-move"}, nil},
+		{[]string{"-direction", "sideways"}, nil},
+		{[]string{"-direction", "left", "-move-mode", "leave"}, nil},
+		{[]string{"-direction", "left", "right"}, nil},
+	}
+
+	for _, test := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(ioutil.Discard)
+
+		step := legacyStep(flags)
+
+		positional, err := parse(flags, test.args)
+		if err != nil {
+			t.Errorf("Expected no error parsing %q, got %v", test.args, err)
+			continue
+		}
+
+		s, err := step(positional)
+		if test.expected == nil {
+			if _, ok := err.(usageError); !ok {
+				t.Errorf("Expected usage error for %q, got %v", test.args, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", test.args, err)
+			continue
+		}
+
+		if cmd := s.GetDaemonCommand(); !reflect.DeepEqual(cmd, test.expected) {
+			t.Errorf("Expected command %+v for %q, got %+v", test.expected, test.args, cmd)
+		}
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	var tests = []struct {
+		shell    string
+		write    func(*bytes.Buffer)
+		expected []string
+	}{
+		{"bash", func(b *bytes.Buffer) { writeBashCompletion(b) }, []string{
+			"complete -F _i3x3ctl i3x3ctl",
+			"        go)\n",
+			"        -mode|--mode)\n",
+			`compgen -W "follow stay all"`,
+		}},
+		{"zsh", func(b *bytes.Buffer) { writeZshCompletion(b) }, []string{
+			"#compdef i3x3ctl",
+			"'go:Switch to the adjacent workspace in the given direction'",
+			"'label:Label the current workspace, or remove it'\\''s label if no text is given'",
+			"compadd -- follow stay all",
+		}},
+		{"fish", func(b *bytes.Buffer) { writeFishCompletion(b) }, []string{
+			"complete -c i3x3ctl -n __fish_use_subcommand -a help",
+			"complete -c i3x3ctl -n '__fish_seen_subcommand_from move' -o mode -x -a 'follow stay all'",
+			"-d 'Label the current workspace, or remove it\\'s label if no text is given'",
+		}},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		test.write(&buf)
+
+		script := buf.String()
+
+		for _, expected := range test.expected {
+			if !strings.Contains(script, expected) {
+				t.Errorf("Expected %s completion to contain %q", test.shell, expected)
+			}
+		}
+
+		// Every command should be completed.
+		for _, name := range commandNames() {
+			if !strings.Contains(script, name) {
+				t.Errorf("Expected %s completion to complete %q", test.shell, name)
+			}
+		}
+	}
+}
+
+func TestCompletionCommand(t *testing.T) {
+	for _, args := range [][]string{nil, {"tcsh"}, {"bash", "zsh"}} {
+		err := completionCommand(flag.NewFlagSet("test", flag.ContinueOnError))(args)
+		if _, ok := err.(usageError); !ok {
+			t.Errorf("Expected usage error for %q, got %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/seeruk/i3x3/internal/daemon"
+)
+
+// autoStartEnv is the environment variable that enables starting i3x3d when it isn't running.
+const autoStartEnv = "I3X3_AUTO_START"
+
+// startTimeout is how long i3x3ctl waits for i3x3d to be ready, after starting it.
+const startTimeout = 10 * time.Second
+
+// autoStart returns true if i3x3d should be started when it isn't running.
+func autoStart() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(autoStartEnv))
+	return enabled
+}
+
+// ensureRunning starts i3x3d if it isn't running, and waits for it to be ready. Only one i3x3ctl
+// starts i3x3d at a time, so that several commands run at once (e.g. from key presses in quick
+// succession) don't start several daemons.
+func ensureRunning() error {
+	if isRunning() {
+		return nil
+	}
+
+	dir, err := daemon.RuntimeDir()
+	if err != nil {
+		return err
+	}
+
+	lock, err := os.OpenFile(filepath.Join(dir, "start.lock"), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening start lock: %v", err)
+	}
+
+	// The lock is released when the file is closed.
+	defer lock.Close()
+
+	err = syscall.Flock(int(lock.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		return fmt.Errorf("error taking start lock: %v", err)
+	}
+
+	// Another i3x3ctl may have started i3x3d while this one was waiting for the lock.
+	if isRunning() {
+		return nil
+	}
+
+	return startDaemon(filepath.Join(dir, "i3x3d.log"))
+}
+
+// isRunning returns true if i3x3d is accepting connections.
+func isRunning() bool {
+	conn, err := net.DialTimeout("tcp", address(), time.Second)
+	if err != nil {
+		return false
+	}
+
+	conn.Close()
+	return true
+}
+
+// startDaemon starts i3x3d in the background, logging to the given file, and waits for it to signal
+// that it's ready.
+func startDaemon(logPath string) error {
+	path, err := daemonPath()
+	if err != nil {
+		return err
+	}
+
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening i3x3d log: %v", err)
+	}
+
+	defer logFile.Close()
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("error creating readiness pipe: %v", err)
+	}
+
+	defer readyReader.Close()
+
+	cmd := exec.Command(path)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// The first extra file is file descriptor 3 in i3x3d.
+	cmd.ExtraFiles = []*os.File{readyWriter}
+	cmd.Env = append(os.Environ(), daemon.ReadyFDEnv+"=3")
+	// i3x3d is started in it's own session, so that it keeps running after i3x3ctl exits.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	err = cmd.Start()
+	readyWriter.Close()
+
+	if err != nil {
+		return fmt.Errorf("error starting i3x3d: %v", err)
+	}
+
+	// If i3x3d exits before it's ready, the pipe is closed without the ready message being written.
+	readyCh := make(chan bool, 1)
+	go func() {
+		line, _ := bufio.NewReader(readyReader).ReadString('\n')
+		readyCh <- strings.TrimSpace(line) == daemon.ReadyMessage
+	}()
+
+	select {
+	case ready := <-readyCh:
+		if !ready {
+			return fmt.Errorf("i3x3d exited before it was ready, see %s", logPath)
+		}
+	case <-time.After(startTimeout):
+		return fmt.Errorf("timed out waiting for i3x3d to start, see %s", logPath)
+	}
+
+	return cmd.Process.Release()
+}
+
+// daemonPath returns the path to i3x3d. The i3x3d next to i3x3ctl is preferred, because they're
+// usually installed together, and the PATH that i3 runs commands with may not include them.
+func daemonPath() (string, error) {
+	if exe, err := os.Executable(); err == nil {
+		path := filepath.Join(filepath.Dir(exe), "i3x3d")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	path, err := exec.LookPath("i3x3d")
+	if err != nil {
+		return "", fmt.Errorf("error finding i3x3d: %v", err)
+	}
+
+	return path, nil
+}
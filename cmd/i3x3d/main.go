@@ -2,16 +2,20 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/inconshreveable/log15"
 	"github.com/seeruk/i3x3/internal/daemon"
 	"github.com/seeruk/i3x3/internal/metrics"
 	"github.com/seeruk/i3x3/internal/rpc"
+	"github.com/seeruk/i3x3/internal/version"
 	"github.com/seeruk/i3x3/internal/workspace"
 	"github.com/seeruk/i3x3/internal/xserver"
 )
@@ -35,13 +39,43 @@ import (
 //   After initially building this into the i3x3ctl command, performance became an issue. Having the
 //   overlay in i3x3d means GTK can start up and be initialised, leaving as little work as possible
 //   left to do when we want the overlay to be shown. The result is a much more responsive overlay.
+//   The overlay can also be shown as a desktop notification, or in a terminal, instead; and i3x3d
+//   can be built without GTK at all, using the nogtk build tag.
+
+// replaceTimeout is how long to wait for a running i3x3d to stop when replacing it. It's longer than
+// i3x3d waits for it's threads to stop before giving up on stopping gracefully.
+const replaceTimeout = 10 * time.Second
 
 func main() {
 	var debug bool
+	var labelsPath string
+	var overlayAllOutputs bool
+	var overlayBackendName string
+	var overlayConfigPath string
+	var overlayThumbnails bool
+	var overlayWindowsName string
+	var replace bool
+	var showVersion bool
 
 	flag.BoolVar(&debug, "debug", false, "Enabled debug logging")
+	flag.StringVar(&labelsPath, "labels", workspace.DefaultLabelsPath(), "Path to the file workspace labels are saved in")
+	flag.BoolVar(&overlayAllOutputs, "overlay-all-outputs", false, "Show every output's grid in the overlay, not just the current output's")
+	flag.StringVar(&overlayBackendName, "overlay-backend", "", "How to show the overlay (gtk, notification, terminal, none), overriding the overlay configuration file")
+	flag.StringVar(&overlayConfigPath, "overlay-config", workspace.DefaultOverlayConfigPath(), "Path to the overlay configuration file")
+	flag.BoolVar(&overlayThumbnails, "overlay-thumbnails", false, "Show a thumbnail of each workspace in the overlay")
+	flag.StringVar(&overlayWindowsName, "overlay-windows", string(workspace.OverlayWindowsIcon), "How to show windows in the overlay (none, icon, class, title)")
+	flag.BoolVar(&replace, "replace", false, "Ask the running i3x3d to stop, and take over from it")
+	flag.BoolVar(&showVersion, "version", false, "Show the version of i3x3d, and exit")
 	flag.Parse()
 
+	if showVersion {
+		fmt.Printf("i3x3d %s\n", version.String(version.Version, version.Commit, version.Date))
+		return
+	}
+
+	// If i3x3d was started by i3x3ctl, then i3x3ctl is waiting to be told that it's ready.
+	readyFile := daemon.ReadyFile()
+
 	logLevel := log15.LvlInfo
 	if debug {
 		logLevel = log15.LvlDebug
@@ -53,26 +87,82 @@ func main() {
 	ctx, cfn := context.WithCancel(context.Background())
 
 	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, os.Interrupt, os.Kill)
+	signal.Notify(signals, os.Interrupt, os.Kill, syscall.SIGTERM)
 
 	rpcMessages := make(chan rpc.Message)
 	switchMessages := make(chan workspace.SwitchMessage)
 	xeventMessages := make(chan struct{})
 
 	logger := baseLogger.New("module", "main/main")
-	logger.Info("starting background threads")
+
+	// Only one i3x3d can run at once. This is checked before anything else is started, so that a
+	// second i3x3d doesn't get as far as connecting to X, or initialising GTK.
+	lock, err := lockInstance(logger, replace)
+	if err != nil {
+		logger.Crit("error starting i3x3d", "error", err)
+		os.Exit(1)
+	}
+
+	defer lock.Close()
+
+	overlayWindows, err := workspace.NewOverlayWindows(overlayWindowsName)
+	if err != nil {
+		logger.Crit("error parsing flags", "error", err)
+		os.Exit(1)
+	}
+
+	labels := workspace.NewLabels(labelsPath)
+	if err := labels.Load(); err != nil {
+		logger.Error("error loading workspace labels", "error", err)
+	}
+
+	// Thumbnails are only captured if they're enabled, because taking screenshots isn't free.
+	var capturer *xserver.Capturer
+	if overlayThumbnails {
+		capturer = xserver.NewCapturer()
+		defer capturer.Close()
+	}
+
+	thumbnails := workspace.NewThumbnails(baseLogger, capturer)
+
+	logger.Info("starting background threads", "version", version.Version)
 
 	rpcService := rpc.NewService(baseLogger, rpcMessages)
 	rpcThread := rpc.NewThread(baseLogger, rpcService)
 	rpcThreadDone := daemon.NewBackgroundThread(ctx, rpcThread)
 
-	workspaceDistributorThread := workspace.NewDistributorThread(baseLogger, xeventMessages)
+	go func() {
+		select {
+		case <-rpcThread.Ready():
+			if err := daemon.NotifyReady(readyFile); err != nil {
+				logger.Error("error notifying readiness", "error", err)
+			}
+		case <-ctx.Done():
+		}
+	}()
+
+	workspaceDistributorThread := workspace.NewDistributorThread(baseLogger, labels, xeventMessages)
 	workspaceDistributorDone := daemon.NewBackgroundThread(ctx, workspaceDistributorThread)
 
-	workspaceOverlayThread := workspace.NewOverlayThread(baseLogger, switchMessages)
+	workspaceTreeThread := workspace.NewTreeThread(baseLogger)
+	workspaceTreeDone := daemon.NewBackgroundThread(ctx, workspaceTreeThread)
+
+	workspaceOverlayThread, err := workspace.NewOverlay(baseLogger, workspace.OverlayOptions{
+		ConfigPath: overlayConfigPath,
+		Backend:    workspace.OverlayBackendName(overlayBackendName),
+		Windows:    overlayWindows,
+		AllOutputs: overlayAllOutputs,
+		Thumbnails: thumbnails,
+	}, switchMessages)
+
+	if err != nil {
+		logger.Crit("error creating workspace overlay", "error", err)
+		os.Exit(1)
+	}
+
 	workspaceOverlayDone := daemon.NewBackgroundThread(ctx, workspaceOverlayThread)
 
-	workspaceSwitchThread := workspace.NewSwitchThread(baseLogger, rpcMessages, switchMessages)
+	workspaceSwitchThread := workspace.NewSwitchThread(baseLogger, labels, workspaceTreeThread, thumbnails, rpcMessages, switchMessages)
 	workspaceSwitchDone := daemon.NewBackgroundThread(ctx, workspaceSwitchThread)
 
 	xserverEventThread := xserver.NewEventThread(baseLogger, xeventMessages)
@@ -86,12 +176,17 @@ func main() {
 
 	select {
 	case sig := <-signals:
-		fmt.Println() // Skip the ^C
+		if sig == os.Interrupt {
+			fmt.Println() // Skip the ^C
+		}
+
 		logger.Info("stopping background threads", "signal", sig)
 	case res := <-rpcThreadDone:
 		logger.Crit("error starting RPC thread", "error", res.Error)
 	case res := <-workspaceDistributorDone:
 		logger.Crit("error starting workspace distributor thread", "error", res.Error)
+	case res := <-workspaceTreeDone:
+		logger.Crit("error starting workspace tree thread", "error", res.Error)
 	case res := <-workspaceOverlayDone:
 		logger.Crit("error starting workspace overlay thread", "error", res.Error)
 	case res := <-workspaceSwitchDone:
@@ -112,6 +207,7 @@ func main() {
 	// Wait for our background threads to clean up.
 	<-rpcThreadDone
 	<-workspaceDistributorDone
+	<-workspaceTreeDone
 	<-workspaceOverlayDone
 	<-workspaceSwitchDone
 	<-xserverEventDone
@@ -122,3 +218,41 @@ func main() {
 
 	logger.Info("threads stopped, exiting")
 }
+
+// lockInstance takes the lock that stops more than one i3x3d running at once. If another i3x3d holds
+// it, and replace is true, the other i3x3d is asked to stop, and the lock is taken once it has.
+func lockInstance(logger log15.Logger, replace bool) (*os.File, error) {
+	dir, err := daemon.RuntimeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, "i3x3d.lock")
+
+	lock, err := daemon.Lock(path)
+	lockedErr, ok := err.(*daemon.LockedError)
+	if !ok {
+		return lock, err
+	}
+
+	if !replace {
+		if lockedErr.PID == 0 {
+			return nil, errors.New("i3x3d is already running; use --replace to replace it")
+		}
+
+		return nil, fmt.Errorf("i3x3d is already running (pid %d); use --replace to replace it", lockedErr.PID)
+	}
+
+	if lockedErr.PID == 0 {
+		return nil, errors.New("i3x3d is already running, but it's pid is unknown, so it can't be replaced")
+	}
+
+	logger.Info("replacing running i3x3d", "pid", lockedErr.PID)
+
+	err = syscall.Kill(lockedErr.PID, syscall.SIGTERM)
+	if err != nil {
+		return nil, fmt.Errorf("error stopping running i3x3d (pid %d): %v", lockedErr.PID, err)
+	}
+
+	return daemon.WaitForLock(path, replaceTimeout)
+}
@@ -0,0 +1,102 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// LockedError is returned when a lock is already held by another process.
+type LockedError struct {
+	// PID is the ID of the process holding the lock, or 0 if it isn't known.
+	PID int
+}
+
+// Error returns a description of the error, including the PID if it's known.
+func (e *LockedError) Error() string {
+	if e.PID == 0 {
+		return "daemon: lock is held by another process"
+	}
+
+	return fmt.Sprintf("daemon: lock is held by another process (pid %d)", e.PID)
+}
+
+// Lock takes the lock in the file at the given path, creating it if it doesn't exist, and writes the
+// current process' ID to it. If another process already holds the lock, a *LockedError is returned.
+// The lock is released when the returned file is closed, or when the process exits.
+func Lock(path string) (*os.File, error) {
+	return lock(path, syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+// WaitForLock is like Lock, but if another process holds the lock, it waits for the lock to be
+// released, for up to the given timeout.
+func WaitForLock(path string, timeout time.Duration) (*os.File, error) {
+	type result struct {
+		file *os.File
+		err  error
+	}
+
+	resCh := make(chan result, 1)
+	go func() {
+		file, err := lock(path, syscall.LOCK_EX)
+		resCh <- result{file, err}
+	}()
+
+	select {
+	case res := <-resCh:
+		return res.file, res.err
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("daemon: timed out waiting for lock: %s", path)
+	}
+}
+
+// lock takes the lock in the file at the given path, with the given flock operation.
+func lock(path string, how int) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("daemon: error opening lock file: %v", err)
+	}
+
+	err = syscall.Flock(int(file.Fd()), how)
+	if err == syscall.EWOULDBLOCK {
+		file.Close()
+		return nil, &LockedError{PID: lockPID(path)}
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("daemon: error taking lock: %v", err)
+	}
+
+	err = file.Truncate(0)
+	if err == nil {
+		_, err = file.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("daemon: error writing lock file: %v", err)
+	}
+
+	return file, nil
+}
+
+// lockPID returns the process ID written to the lock file at the given path, or 0 if it can't be
+// read.
+func lockPID(path string) int {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(bs)))
+	if err != nil {
+		return 0
+	}
+
+	return pid
+}
@@ -0,0 +1,126 @@
+package daemon_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seeruk/i3x3/internal/daemon"
+)
+
+// tempLockPath returns the path to a lock file in a new temporary directory, and a function to
+// remove the directory.
+func tempLockPath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "i3x3-lock")
+	if err != nil {
+		t.Fatalf("Expected no error creating temporary directory, got %v", err)
+	}
+
+	return filepath.Join(dir, "test.lock"), func() {
+		os.RemoveAll(dir)
+	}
+}
+
+// Locks are held per open file, so they conflict within one process, just like between processes.
+func TestLock(t *testing.T) {
+	path, cleanup := tempLockPath(t)
+	defer cleanup()
+
+	file, err := daemon.Lock(path)
+	if err != nil {
+		t.Fatalf("Expected no error taking lock, got %v", err)
+	}
+
+	bs, _ := ioutil.ReadFile(path)
+	if strings.TrimSpace(string(bs)) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("Expected lock file to contain pid %v, got %q", os.Getpid(), bs)
+	}
+
+	_, err = daemon.Lock(path)
+	if lerr, ok := err.(*daemon.LockedError); !ok || lerr.PID != os.Getpid() {
+		t.Errorf("Expected *LockedError with pid %v, got %v", os.Getpid(), err)
+	}
+
+	file.Close()
+
+	file, err = daemon.Lock(path)
+	if err != nil {
+		t.Fatalf("Expected no error taking released lock, got %v", err)
+	}
+
+	file.Close()
+}
+
+func TestLockError(t *testing.T) {
+	_, err := daemon.Lock(filepath.Join("does", "not", "exist.lock"))
+	if _, ok := err.(*daemon.LockedError); ok || err == nil {
+		t.Errorf("Expected error opening lock file, got %v", err)
+	}
+}
+
+func TestLockedError(t *testing.T) {
+	var tests = []struct {
+		pid      int
+		expected string
+	}{
+		{0, "daemon: lock is held by another process"},
+		{123, "daemon: lock is held by another process (pid 123)"},
+	}
+
+	for _, test := range tests {
+		err := &daemon.LockedError{PID: test.pid}
+		if err.Error() != test.expected {
+			t.Errorf("Expected %q, got %q", test.expected, err.Error())
+		}
+	}
+}
+
+func TestWaitForLock(t *testing.T) {
+	path, cleanup := tempLockPath(t)
+	defer cleanup()
+
+	holder, err := daemon.Lock(path)
+	if err != nil {
+		t.Fatalf("Expected no error taking lock, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		holder.Close()
+	}()
+
+	file, err := daemon.WaitForLock(path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Expected no error waiting for released lock, got %v", err)
+	}
+
+	file.Close()
+}
+
+func TestWaitForLockTimeout(t *testing.T) {
+	path, cleanup := tempLockPath(t)
+	defer cleanup()
+
+	holder, err := daemon.Lock(path)
+	if err != nil {
+		t.Fatalf("Expected no error taking lock, got %v", err)
+	}
+
+	// The lock is never released, so the wait carries on in the background after timing out.
+	defer holder.Close()
+
+	start := time.Now()
+
+	_, err = daemon.WaitForLock(path, 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected to wait for at least 50ms, waited %v", elapsed)
+	}
+}
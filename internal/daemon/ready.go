@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// ReadyFDEnv is the environment variable that tells i3x3d which file descriptor to signal it's
+// readiness on, when whatever started it (i.e. i3x3ctl) is waiting for it to be ready.
+const ReadyFDEnv = "I3X3_READY_FD"
+
+// ReadyMessage is written to the readiness file descriptor once i3x3d is ready.
+const ReadyMessage = "ready"
+
+// RuntimeDir returns the directory that i3x3's runtime files (e.g. lock files) are kept in,
+// following the XDG base directory specification. It's created if it doesn't exist.
+func RuntimeDir() (string, error) {
+	dir := filepath.Join(os.Getenv("XDG_RUNTIME_DIR"), "i3x3")
+	if os.Getenv("XDG_RUNTIME_DIR") == "" {
+		dir = filepath.Join(os.TempDir(), fmt.Sprintf("i3x3-%d", os.Getuid()))
+	}
+
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return "", fmt.Errorf("daemon: error creating runtime directory: %v", err)
+	}
+
+	return dir, nil
+}
+
+// ReadyFile returns the file that readiness should be signalled on, if one was given in the
+// environment, otherwise nil is returned. The file isn't inherited by any processes started after
+// this is called, so that they can't keep it open.
+func ReadyFile() *os.File {
+	fd, err := strconv.Atoi(os.Getenv(ReadyFDEnv))
+	os.Unsetenv(ReadyFDEnv)
+
+	// File descriptors 0 to 2 are stdin, stdout, and stderr, which shouldn't be closed.
+	if err != nil || fd < 3 {
+		return nil
+	}
+
+	syscall.CloseOnExec(fd)
+
+	return os.NewFile(uintptr(fd), "ready")
+}
+
+// NotifyReady signals readiness on the given file, and then closes it. If the file is nil, nothing
+// happens.
+func NotifyReady(file *os.File) error {
+	if file == nil {
+		return nil
+	}
+
+	defer file.Close()
+
+	_, err := fmt.Fprintln(file, ReadyMessage)
+	if err != nil {
+		return fmt.Errorf("daemon: error signalling readiness: %v", err)
+	}
+
+	return nil
+}
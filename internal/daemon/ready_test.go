@@ -0,0 +1,82 @@
+package daemon_test
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/daemon"
+)
+
+func TestReadyFile(t *testing.T) {
+	var tests = []struct {
+		value string
+	}{
+		{""},
+		{"not a number"},
+		// stdin, stdout, and stderr are never used.
+		{"0"},
+		{"2"},
+	}
+
+	for _, test := range tests {
+		os.Setenv(daemon.ReadyFDEnv, test.value)
+
+		if file := daemon.ReadyFile(); file != nil {
+			t.Errorf("Expected no ready file for %q, got %v", test.value, file.Fd())
+		}
+
+		if _, ok := os.LookupEnv(daemon.ReadyFDEnv); ok {
+			t.Errorf("Expected %s to be unset for %q", daemon.ReadyFDEnv, test.value)
+		}
+	}
+}
+
+func TestNotifyReady(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Expected no error creating pipe, got %v", err)
+	}
+
+	defer r.Close()
+
+	// The ready file takes ownership of it's file descriptor, so it's given a copy.
+	fd, err := syscall.Dup(int(w.Fd()))
+	w.Close()
+
+	if err != nil {
+		t.Fatalf("Expected no error duplicating file descriptor, got %v", err)
+	}
+
+	os.Setenv(daemon.ReadyFDEnv, strconv.Itoa(fd))
+
+	file := daemon.ReadyFile()
+	if file == nil || file.Fd() != uintptr(fd) {
+		t.Fatalf("Expected ready file for fd %v, got %v", fd, file)
+	}
+
+	if _, ok := os.LookupEnv(daemon.ReadyFDEnv); ok {
+		t.Errorf("Expected %s to be unset", daemon.ReadyFDEnv)
+	}
+
+	err = daemon.NotifyReady(file)
+	if err != nil {
+		t.Fatalf("Expected no error notifying readiness, got %v", err)
+	}
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil || line != daemon.ReadyMessage+"\n" {
+		t.Errorf("Expected %q, got %q (%v)", daemon.ReadyMessage+"\n", line, err)
+	}
+
+	// The ready file is closed once readiness is signalled, so that's the end of the pipe.
+	if _, err := r.Read(make([]byte, 1)); err == nil {
+		t.Error("Expected the ready file to be closed")
+	}
+
+	if err := daemon.NotifyReady(nil); err != nil {
+		t.Errorf("Expected no error notifying readiness without a ready file, got %v", err)
+	}
+}
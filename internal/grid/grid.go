@@ -1,7 +1,7 @@
 package grid
 
 import (
-	"math"
+	"sort"
 
 	"github.com/seeruk/i3x3/internal/i3"
 )
@@ -15,8 +15,27 @@ const (
 	Down  Direction = "down"
 	Left  Direction = "left"
 	Right Direction = "right"
+
+	// The diagonal directions are made of one vertical, and one horizontal direction.
+	UpLeft    Direction = "up-left"
+	UpRight   Direction = "up-right"
+	DownLeft  Direction = "down-left"
+	DownRight Direction = "down-right"
+
+	// NextPage and PrevPage move to the same cell on the next or previous page of the grid.
+	NextPage Direction = "next-page"
+	PrevPage Direction = "prev-page"
 )
 
+// Diagonals are the diagonal directions, mapped to the vertical and horizontal directions that
+// they're made of.
+var Diagonals = map[Direction][2]Direction{
+	UpLeft:    {Up, Left},
+	UpRight:   {Up, Right},
+	DownLeft:  {Down, Left},
+	DownRight: {Down, Right},
+}
+
 // Size represents the current size of the i3x3 grid.
 type Size struct {
 	// Integer "real" grid size, used for display.
@@ -26,17 +45,63 @@ type Size struct {
 	// Original "requested" grid size.
 	OriginalX int
 	OriginalY int
+
+	// Integer "real" and original "requested" number of pages. Each page is a grid of the same size,
+	// and workspaces carry on being numbered from one page to the next. If these are 0, there is
+	// only one page.
+	RealPages     int
+	OriginalPages int
+}
+
+// PageCells returns the number of cells on each page of the grid.
+func (s Size) PageCells() int {
+	return s.RealX * s.RealY
+}
+
+// Pages returns the real number of pages in the grid, which is at least 1.
+func (s Size) Pages() int {
+	if s.RealPages < 1 {
+		return 1
+	}
+
+	return s.RealPages
 }
 
 // NewSize initialises a new Size, to keep track of the grid size based on the current environment,
 // and the requested grid size.
 func NewSize(environment Environment, x int, y int) Size {
+	return NewPagedSize(environment, x, y, 1)
+}
+
+// NewPagedSize is like NewSize, but with the requested number of pages. With more than one page,
+// the grid gains pages instead of rows if a workspace is outside of the bounds of the grid.
+func NewPagedSize(environment Environment, x int, y int, pages int) Size {
+	maxGridPos := WorkspaceGridPosition(environment.MaxWorkspace, environment.ActiveOutputs)
+
+	if pages > 1 {
+		maxRealPages := ceilDiv(maxGridPos, x*y)
+
+		rp := pages
+
+		if maxRealPages > pages {
+			rp = maxRealPages
+		}
+
+		return Size{
+			RealX:         x,
+			RealY:         y,
+			OriginalX:     x,
+			OriginalY:     y,
+			RealPages:     rp,
+			OriginalPages: pages,
+		}
+	}
+
 	// This next bit decides the real grid size. The real grid can be larger than the requested grid
 	// if a workspace has ended up on a screen that shouldn't normally be there (e.g. after running
 	// i3x3-fix, or if a user just creates a new workspace themselves that is outside of the bounds
 	// of the grid).
-	maxGridPos := WorkspaceGridPosition(environment.MaxWorkspace, environment.ActiveOutputs)
-	maxRealRows := int(math.Ceil(maxGridPos / float64(x)))
+	maxRealRows := ceilDiv(maxGridPos, x)
 
 	ry := y
 
@@ -45,19 +110,32 @@ func NewSize(environment Environment, x int, y int) Size {
 	}
 
 	return Size{
-		RealX:     x,
-		RealY:     ry,
-		OriginalX: x,
-		OriginalY: y,
+		RealX:         x,
+		RealY:         ry,
+		OriginalX:     x,
+		OriginalY:     y,
+		RealPages:     1,
+		OriginalPages: 1,
 	}
 }
 
-// Environment represents the current state of the grid, and it's environment from i3.
+// ceilDiv divides a by b, rounding up. A b of less than 1 is treated as 1.
+func ceilDiv(a, b int) int {
+	if b < 1 {
+		return a
+	}
+
+	return (a + b - 1) / b
+}
+
+// Environment represents the current state of the grid, and it's environment from i3. If the current
+// workspace isn't numbered (e.g. it only has a name), CurrentWorkspace is below 1, and isn't on the
+// grid, and CurrentOutput is 0 unless it's set from the output that the workspace is on.
 type Environment struct {
-	ActiveOutputs    float64
-	CurrentOutput    float64
-	CurrentWorkspace float64
-	MaxWorkspace     float64
+	ActiveOutputs    int
+	CurrentOutput    int
+	CurrentWorkspace int
+	MaxWorkspace     int
 }
 
 // NewEnvironment initialises a new environment, based on the given outputs and workspaces.
@@ -75,71 +153,191 @@ func NewEnvironment(outputs []i3.Output, workspaces []i3.Workspace) Environment
 	}
 }
 
+// Grid describes where workspaces are on each output's grid. Workspaces are shared out between the
+// outputs in turn, so with 2 outputs, the first output has workspaces 1, 3, 5, and so on, and the
+// second has 2, 4, 6, and so on. On each output, workspaces fill the cells of the grid in reading
+// order, page by page.
+type Grid struct {
+	Outputs int
+	Size    Size
+}
+
+// NewGrid returns the grid for the given environment, and grid size.
+func NewGrid(environment Environment, size Size) Grid {
+	return Grid{
+		Outputs: environment.ActiveOutputs,
+		Size:    size,
+	}
+}
+
+// outputs returns the number of outputs, which is at least 1.
+func (g Grid) outputs() int {
+	if g.Outputs < 1 {
+		return 1
+	}
+
+	return g.Outputs
+}
+
+// Output returns the output that the given workspace belongs to, starting from 1.
+func (g Grid) Output(workspace int) int {
+	return i3.CurrentOutputNum(workspace, g.outputs())
+}
+
+// Position returns the position of the given workspace in it's output's grid, counting the cells on
+// every page in reading order, starting from 1. Workspaces numbered below 1 aren't on the grid, and
+// are at position 0.
+func (g Grid) Position(workspace int) int {
+	return WorkspaceGridPosition(workspace, g.outputs())
+}
+
+// Cell returns the page, column, and row of the given workspace in it's output's grid, starting
+// from 1, from the top left of the first page. If the workspace isn't on the grid, ok will be false.
+func (g Grid) Cell(workspace int) (page, x, y int, ok bool) {
+	pos := g.Position(workspace)
+	cells := g.Size.PageCells()
+
+	if pos < 1 || cells < 1 {
+		return 0, 0, 0, false
+	}
+
+	page = ((pos - 1) / cells) + 1
+	x = ((pos - 1) % g.Size.RealX) + 1
+	y = (((pos - 1) % cells) / g.Size.RealX) + 1
+
+	return page, x, y, page <= g.Size.Pages()
+}
+
+// Workspace returns the workspace at the given page, column, and row of the given output's grid,
+// starting from 1. If the cell, or the output, is outside of the bounds of the grid, ok will be
+// false.
+func (g Grid) Workspace(output, page, x, y int) (ws int, ok bool) {
+	if output < 1 || output > g.outputs() {
+		return 0, false
+	}
+
+	if x < 1 || x > g.Size.RealX || y < 1 || y > g.Size.RealY || page < 1 || page > g.Size.Pages() {
+		return 0, false
+	}
+
+	pos := ((page - 1) * g.Size.PageCells()) + ((y - 1) * g.Size.RealX) + (x - 1)
+
+	return output + (g.outputs() * pos), true
+}
+
 // An EdgeFunc takes the current workspace number, and then based on the other settings (such as
 // current output, number of outputs, so on) it will calculate if we're at the edge of a side of the
 // grid. Each side has it's own function to calculate if the given workspace is on the edge, defined
 // below.
-type EdgeFunc func(tar float64) bool
+type EdgeFunc func(tar int) bool
 
-// BuildEdgeFuncs creates the aforementioned edge detection functions.
+// BuildEdgeFuncs creates the aforementioned edge detection functions. The top and bottom edges are
+// the edges of the page that the workspace is on. A workspace that isn't on the grid is treated as
+// being on every edge, so that there's nowhere to move to from it.
 func BuildEdgeFuncs(environment Environment, size Size) map[Direction]EdgeFunc {
-	x := float64(size.RealX)
-	y := float64(size.RealY)
+	g := NewGrid(environment, size)
 
-	ao := environment.ActiveOutputs
-	co := environment.CurrentOutput
+	// edge returns an EdgeFunc that detects if the given workspace's cell is on an edge.
+	edge := func(onEdge func(page, x, y int) bool) EdgeFunc {
+		return func(cw int) bool {
+			page, x, y, ok := g.Cell(cw)
 
-	return map[Direction]EdgeFunc{
+			return !ok || onEdge(page, x, y)
+		}
+	}
+
+	edgeFuncs := map[Direction]EdgeFunc{
 		// Up detects if we're on the top edge.
-		Up: func(cw float64) bool {
-			return cw-(ao*x) <= 0
-		},
+		Up: edge(func(page, x, y int) bool {
+			return y <= 1
+		}),
 		// Down detects if we're on the bottom edge.
-		Down: func(cw float64) bool {
-			return cw+(ao*x) > (ao*((x*y)-1))+co
-		},
+		Down: edge(func(page, x, y int) bool {
+			return y >= size.RealY
+		}),
 		// Left detects if we're on the left edge.
-		Left: func(cw float64) bool {
-			return math.Mod(cw-co, x*ao) == 0
-		},
+		Left: edge(func(page, x, y int) bool {
+			return x <= 1
+		}),
 		// Right detects if we're on the right edge.
-		Right: func(cw float64) bool {
-			return math.Mod((cw-((x-1)*ao))-co, x*ao) == 0
-		},
+		Right: edge(func(page, x, y int) bool {
+			return x >= size.RealX
+		}),
+		// NextPage detects if we're on the last page.
+		NextPage: edge(func(page, x, y int) bool {
+			return page >= size.Pages()
+		}),
+		// PrevPage detects if we're on the first page.
+		PrevPage: edge(func(page, x, y int) bool {
+			return page <= 1
+		}),
 	}
+
+	// A diagonal stops at an edge if either of the directions it's made of would, e.g. moving up and
+	// to the left from the top row doesn't just move left.
+	for diagonal, parts := range Diagonals {
+		vertical, horizontal := edgeFuncs[parts[0]], edgeFuncs[parts[1]]
+
+		edgeFuncs[diagonal] = func(cw int) bool {
+			return vertical(cw) || horizontal(cw)
+		}
+	}
+
+	return edgeFuncs
 }
 
 // A TargetFunc calculates the next workspace that would be moved to in a given direction, based on
 // other info (such as current workspace, current output, number of outputs, so on). The return
 // value may not be a valid workspace number. The use of the above EdgeFuncs helps to ensure that
 // TargetFuncs only get called when it should be possible to move to the target workspace.
-type TargetFunc func() float64
+type TargetFunc func() int
 
 // BuildTargetFuncs creates the the aforementioned target workspace functions.
 func BuildTargetFuncs(environment Environment, size Size) map[Direction]TargetFunc {
-	x := float64(size.RealX)
+	x := size.RealX
+	cells := size.PageCells()
 
-	ao := environment.ActiveOutputs
+	// Like the edges, the grid is treated as having at least one output.
+	ao := NewGrid(environment, size).outputs()
 	cw := environment.CurrentWorkspace
 
-	return map[Direction]TargetFunc{
+	targetFuncs := map[Direction]TargetFunc{
 		// Up returns the workspace above.
-		Up: func() float64 {
+		Up: func() int {
 			return cw - (ao * x)
 		},
 		// Down returns the workspace below.
-		Down: func() float64 {
+		Down: func() int {
 			return cw + (ao * x)
 		},
 		// Left returns the workspace to the left.
-		Left: func() float64 {
+		Left: func() int {
 			return cw - ao
 		},
 		// Right returns the workspace to the right.
-		Right: func() float64 {
+		Right: func() int {
 			return cw + ao
 		},
+		// NextPage returns the workspace in the same cell on the next page.
+		NextPage: func() int {
+			return cw + (ao * cells)
+		},
+		// PrevPage returns the workspace in the same cell on the previous page.
+		PrevPage: func() int {
+			return cw - (ao * cells)
+		},
+	}
+
+	// A diagonal's target is moved from the current workspace by both of the directions it's made of.
+	for diagonal, parts := range Diagonals {
+		vertical, horizontal := targetFuncs[parts[0]], targetFuncs[parts[1]]
+
+		targetFuncs[diagonal] = func() int {
+			return vertical() + horizontal() - cw
+		}
 	}
+
+	return targetFuncs
 }
 
 // WorkspaceGridPosition calculates the position of a given workspace in a grid where each number
@@ -156,8 +354,14 @@ func BuildTargetFuncs(environment Environment, size Size) map[Direction]TargetFu
 //
 //  fmt.Println(WorkspaceGridPosition(15, 2))
 //  // Output: 8
-func WorkspaceGridPosition(workspace float64, outputs float64) float64 {
-	if outputs == 1 {
+//
+// Workspaces numbered below 1 aren't on the grid, so their position is 0.
+func WorkspaceGridPosition(workspace int, outputs int) int {
+	if workspace < 1 {
+		return 0
+	}
+
+	if outputs <= 1 {
 		return workspace
 	}
 
@@ -165,3 +369,108 @@ func WorkspaceGridPosition(workspace float64, outputs float64) float64 {
 
 	return (workspace + (outputs - output)) / outputs
 }
+
+// WorkspacePage calculates the page of the grid that the given workspace is on, starting from 1.
+// Workspaces that aren't on the grid at all (i.e. numbered below 1) are treated as being on the
+// first page.
+func WorkspacePage(workspace int, outputs int, size Size) int {
+	page, _, _, _ := Grid{Outputs: outputs, Size: size}.Cell(workspace)
+	if page < 1 {
+		return 1
+	}
+
+	return page
+}
+
+// CellWorkspace calculates the workspace number at the given column and row of the current page of
+// the current output's grid. Columns and rows start at 1, from the top left, matching the overlay.
+// If the given cell is outside of the bounds of the grid, ok will be false.
+func CellWorkspace(environment Environment, size Size, x, y int) (ws int, ok bool) {
+	page := WorkspacePage(environment.CurrentWorkspace, environment.ActiveOutputs, size)
+
+	return PageCellWorkspace(environment, size, page, x, y)
+}
+
+// PageCellWorkspace is like CellWorkspace, but finds the cell on the given page, starting from 1.
+func PageCellWorkspace(environment Environment, size Size, page, x, y int) (ws int, ok bool) {
+	return NewGrid(environment, size).Workspace(environment.CurrentOutput, page, x, y)
+}
+
+// PageWorkspace calculates the workspace number in the same cell as the current workspace, on the
+// given page of the current output's grid, starting from 1. If the page is outside of the bounds of
+// the grid, ok will be false.
+func PageWorkspace(environment Environment, size Size, page int) (ws int, ok bool) {
+	g := NewGrid(environment, size)
+
+	_, x, y, ok := g.Cell(environment.CurrentWorkspace)
+	if !ok {
+		return 0, false
+	}
+
+	return g.Workspace(g.Output(environment.CurrentWorkspace), page, x, y)
+}
+
+// Rename represents a change to a workspace's number, made when compacting the grid.
+type Rename struct {
+	From int
+	To   int
+	// Merge is true if the workspace's containers should be moved to an existing workspace, instead
+	// of the workspace being renamed.
+	Merge bool
+}
+
+// Compact works out the renames needed to fill the cells of each output's grid in reading order,
+// page by page, using the given workspace numbers. Workspaces stay on the output they belong to. If
+// shrink is true, any workspaces that don't fit in the requested grid size (including it's pages)
+// are merged into the last cell.
+//
+// The renames are ordered so that they can be applied one after another; a workspace is never
+// renamed to a number that is still in use.
+func Compact(environment Environment, size Size, workspaces []int, shrink bool) []Rename {
+	ao := environment.ActiveOutputs
+	cells := size.OriginalX * size.OriginalY
+
+	if size.OriginalPages > 1 {
+		cells *= size.OriginalPages
+	}
+
+	sorted := make([]int, len(workspaces))
+	copy(sorted, workspaces)
+	sort.Ints(sorted)
+
+	byOutput := make(map[int][]int)
+
+	for _, ws := range sorted {
+		if ws < 1 {
+			continue
+		}
+
+		output := i3.CurrentOutputNum(ws, ao)
+		byOutput[output] = append(byOutput[output], ws)
+	}
+
+	var renames []Rename
+
+	for output := 1; output <= ao; output++ {
+		for i, ws := range byOutput[output] {
+			pos := i
+			merge := false
+
+			if shrink && pos >= cells {
+				pos = cells - 1
+				merge = true
+			}
+
+			target := output + (ao * pos)
+			if target != ws {
+				renames = append(renames, Rename{
+					From:  ws,
+					To:    target,
+					Merge: merge,
+				})
+			}
+		}
+	}
+
+	return renames
+}
@@ -4,13 +4,14 @@ import (
 	"testing"
 
 	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/i3"
 )
 
 func TestWorkspaceGridPosition(t *testing.T) {
 	var tests = []struct {
-		workspace float64
-		outputs   float64
-		expected  float64
+		workspace int
+		outputs   int
+		expected  int
 	}{
 		{1, 1, 1},
 		{1, 2, 1},
@@ -23,6 +24,10 @@ func TestWorkspaceGridPosition(t *testing.T) {
 		{4, 3, 2},
 		{19, 3, 7},
 		{25, 3, 9},
+		{-1, 1, 0},
+		{-1, 2, 0},
+		{0, 3, 0},
+		{3, 0, 3},
 	}
 
 	for _, test := range tests {
@@ -38,3 +43,486 @@ func TestWorkspaceGridPosition(t *testing.T) {
 		}
 	}
 }
+
+func TestCellWorkspace(t *testing.T) {
+	size := grid.Size{RealX: 3, RealY: 3, OriginalX: 3, OriginalY: 3}
+
+	var tests = []struct {
+		outputs  int
+		output   int
+		x        int
+		y        int
+		expected int
+		ok       bool
+	}{
+		{1, 1, 1, 1, 1, true},
+		{1, 1, 3, 3, 9, true},
+		{2, 1, 2, 2, 9, true},
+		{2, 2, 3, 1, 6, true},
+		{3, 3, 1, 3, 21, true},
+		{2, 1, 0, 1, 0, false},
+		{2, 1, 4, 1, 0, false},
+		{2, 1, 1, 4, 0, false},
+	}
+
+	for _, test := range tests {
+		env := grid.Environment{
+			ActiveOutputs: test.outputs,
+			CurrentOutput: test.output,
+		}
+
+		actual, ok := grid.CellWorkspace(env, size, test.x, test.y)
+		if actual != test.expected || ok != test.ok {
+			t.Errorf(
+				"Expected (%v, %v) to equal (%v, %v) for cell %v,%v on output %v of %v",
+				actual,
+				ok,
+				test.expected,
+				test.ok,
+				test.x,
+				test.y,
+				test.output,
+				test.outputs,
+			)
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	size := grid.Size{RealX: 2, RealY: 2, OriginalX: 2, OriginalY: 2}
+
+	var tests = []struct {
+		outputs    int
+		workspaces []int
+		shrink     bool
+		expected   []grid.Rename
+	}{
+		{1, []int{1, 2, 3}, false, nil},
+		{1, []int{1, 3, 7}, false, []grid.Rename{{3, 2, false}, {7, 3, false}}},
+		{1, []int{9, 4, 2}, false, []grid.Rename{{2, 1, false}, {4, 2, false}, {9, 3, false}}},
+		{2, []int{1, 2, 5, 8, 12}, false, []grid.Rename{{5, 3, false}, {8, 4, false}, {12, 6, false}}},
+		{1, []int{1, 2, 3, 4, 6, 9}, false, []grid.Rename{{6, 5, false}, {9, 6, false}}},
+		{1, []int{1, 2, 3, 4, 6, 9}, true, []grid.Rename{{6, 4, true}, {9, 4, true}}},
+		{1, []int{-1, 2}, false, []grid.Rename{{2, 1, false}}},
+		{0, []int{1, 3, 7}, false, nil},
+	}
+
+	for _, test := range tests {
+		env := grid.Environment{ActiveOutputs: test.outputs}
+
+		actual := grid.Compact(env, size, test.workspaces, test.shrink)
+		if len(actual) != len(test.expected) {
+			t.Errorf("Expected %v to equal %v for workspaces %v", actual, test.expected, test.workspaces)
+			continue
+		}
+
+		for i := range actual {
+			if actual[i] != test.expected[i] {
+				t.Errorf("Expected %v to equal %v for workspaces %v", actual, test.expected, test.workspaces)
+				break
+			}
+		}
+	}
+}
+
+func TestPages(t *testing.T) {
+	// Two outputs, with 2x2 pages. The first output's first page is 1, 3, 5, 7, and it's second page
+	// is 9, 11, 13, 15.
+	env := grid.Environment{
+		ActiveOutputs:    2,
+		CurrentOutput:    1,
+		CurrentWorkspace: 11,
+		MaxWorkspace:     11,
+	}
+
+	size := grid.NewPagedSize(env, 2, 2, 2)
+	if size.RealY != 2 || size.Pages() != 2 {
+		t.Fatalf("Expected 2 rows and 2 pages, got %v rows and %v pages", size.RealY, size.Pages())
+	}
+
+	grown := grid.NewPagedSize(grid.Environment{ActiveOutputs: 2, MaxWorkspace: 18}, 2, 2, 2)
+	if grown.RealY != 2 || grown.Pages() != 3 {
+		t.Errorf("Expected 2 rows and 3 pages, got %v rows and %v pages", grown.RealY, grown.Pages())
+	}
+
+	var pageTests = []struct {
+		workspace int
+		expected  int
+	}{
+		{1, 1},
+		{8, 1},
+		{9, 2},
+		{16, 2},
+		{17, 3},
+		{-1, 1},
+	}
+
+	for _, test := range pageTests {
+		actual := grid.WorkspacePage(test.workspace, env.ActiveOutputs, size)
+		if actual != test.expected {
+			t.Errorf("Expected workspace %v to be on page %v, got %v", test.workspace, test.expected, actual)
+		}
+	}
+
+	edgeFuncs := grid.BuildEdgeFuncs(env, size)
+	targetFuncs := grid.BuildTargetFuncs(env, size)
+
+	var edgeTests = []struct {
+		direction grid.Direction
+		edge      bool
+		target    int
+	}{
+		{grid.Up, true, 0},
+		{grid.Down, false, 15},
+		{grid.Left, false, 9},
+		{grid.Right, true, 0},
+		{grid.NextPage, true, 0},
+		{grid.PrevPage, false, 3},
+	}
+
+	for _, test := range edgeTests {
+		edge := edgeFuncs[test.direction](env.CurrentWorkspace)
+		if edge != test.edge {
+			t.Errorf("Expected edge %v to be %v for workspace %v", test.direction, test.edge, env.CurrentWorkspace)
+		}
+
+		if !edge && targetFuncs[test.direction]() != test.target {
+			t.Errorf("Expected target %v to be %v, got %v", test.direction, test.target, targetFuncs[test.direction]())
+		}
+	}
+
+	if ws, ok := grid.PageWorkspace(env, size, 1); ws != 3 || !ok {
+		t.Errorf("Expected page 1 to be workspace 3, got (%v, %v)", ws, ok)
+	}
+
+	if _, ok := grid.PageWorkspace(env, size, 3); ok {
+		t.Errorf("Expected page 3 to be outside of the grid")
+	}
+
+	if ws, ok := grid.CellWorkspace(env, size, 1, 2); ws != 13 || !ok {
+		t.Errorf("Expected cell 1,2 on the current page to be workspace 13, got (%v, %v)", ws, ok)
+	}
+}
+
+func TestDiagonals(t *testing.T) {
+	// Two outputs, with a 3x3 grid. The first output's grid is 1, 3, 5, then 7, 9, 11, then 13, 15, 17.
+	size := grid.Size{RealX: 3, RealY: 3, OriginalX: 3, OriginalY: 3}
+
+	var tests = []struct {
+		workspace int
+		direction grid.Direction
+		edge      bool
+		target    int
+	}{
+		{9, grid.UpLeft, false, 1},
+		{9, grid.UpRight, false, 5},
+		{9, grid.DownLeft, false, 13},
+		{9, grid.DownRight, false, 17},
+		{1, grid.UpLeft, true, 0},
+		{1, grid.UpRight, true, 0},
+		{1, grid.DownLeft, true, 0},
+		{1, grid.DownRight, false, 9},
+		{5, grid.DownLeft, false, 9},
+		{5, grid.DownRight, true, 0},
+		{15, grid.UpLeft, false, 7},
+		{15, grid.DownLeft, true, 0},
+	}
+
+	for _, test := range tests {
+		env := grid.Environment{
+			ActiveOutputs:    2,
+			CurrentOutput:    1,
+			CurrentWorkspace: test.workspace,
+			MaxWorkspace:     test.workspace,
+		}
+
+		edge := grid.BuildEdgeFuncs(env, size)[test.direction](test.workspace)
+		if edge != test.edge {
+			t.Errorf("Expected edge %v to be %v for workspace %v", test.direction, test.edge, test.workspace)
+		}
+
+		target := grid.BuildTargetFuncs(env, size)[test.direction]()
+		if !edge && target != test.target {
+			t.Errorf("Expected target %v to be %v for workspace %v, got %v", test.direction, test.target, test.workspace, target)
+		}
+	}
+}
+
+// moves are how far each direction moves through the grid, as pages, columns, and rows.
+var moves = map[grid.Direction][3]int{
+	grid.Up:        {0, 0, -1},
+	grid.Down:      {0, 0, 1},
+	grid.Left:      {0, -1, 0},
+	grid.Right:     {0, 1, 0},
+	grid.UpLeft:    {0, -1, -1},
+	grid.UpRight:   {0, 1, -1},
+	grid.DownLeft:  {0, -1, 1},
+	grid.DownRight: {0, 1, 1},
+	grid.NextPage:  {1, 0, 0},
+	grid.PrevPage:  {-1, 0, 0},
+}
+
+// opposites are the directions that undo each direction.
+var opposites = map[grid.Direction]grid.Direction{
+	grid.Up:        grid.Down,
+	grid.Down:      grid.Up,
+	grid.Left:      grid.Right,
+	grid.Right:     grid.Left,
+	grid.UpLeft:    grid.DownRight,
+	grid.UpRight:   grid.DownLeft,
+	grid.DownLeft:  grid.UpRight,
+	grid.DownRight: grid.UpLeft,
+	grid.NextPage:  grid.PrevPage,
+	grid.PrevPage:  grid.NextPage,
+}
+
+// forEachGrid calls the given function with every grid of up to 4 outputs, 5 columns, 5 rows, and
+// 3 pages.
+func forEachGrid(fn func(g grid.Grid)) {
+	for outputs := 1; outputs <= 4; outputs++ {
+		for x := 1; x <= 5; x++ {
+			for y := 1; y <= 5; y++ {
+				for pages := 1; pages <= 3; pages++ {
+					fn(grid.Grid{
+						Outputs: outputs,
+						Size: grid.Size{
+							RealX:         x,
+							RealY:         y,
+							OriginalX:     x,
+							OriginalY:     y,
+							RealPages:     pages,
+							OriginalPages: pages,
+						},
+					})
+				}
+			}
+		}
+	}
+}
+
+// workspaces returns the number of workspaces in the given grid, across every output.
+func workspaces(g grid.Grid) int {
+	return g.Outputs * g.Size.Pages() * g.Size.PageCells()
+}
+
+func TestGridCells(t *testing.T) {
+	forEachGrid(func(g grid.Grid) {
+		// Every workspace is in exactly one cell of it's output's grid, and that cell is where the
+		// workspace is.
+		seen := make(map[int]bool)
+
+		for output := 1; output <= g.Outputs; output++ {
+			for page := 1; page <= g.Size.Pages(); page++ {
+				for y := 1; y <= g.Size.RealY; y++ {
+					for x := 1; x <= g.Size.RealX; x++ {
+						ws, ok := g.Workspace(output, page, x, y)
+						if !ok || ws < 1 || ws > workspaces(g) || seen[ws] {
+							t.Fatalf("Expected a new workspace for %v,%v on page %v of output %v in %+v, got (%v, %v)", x, y, page, output, g, ws, ok)
+						}
+
+						seen[ws] = true
+
+						if g.Output(ws) != output {
+							t.Errorf("Expected workspace %v to be on output %v in %+v, got %v", ws, output, g, g.Output(ws))
+						}
+
+						p, cx, cy, ok := g.Cell(ws)
+						if p != page || cx != x || cy != y || !ok {
+							t.Errorf("Expected workspace %v to be at %v,%v on page %v in %+v, got %v,%v on page %v (%v)", ws, x, y, page, g, cx, cy, p, ok)
+						}
+
+						if grid.WorkspaceGridPosition(ws, g.Outputs) != g.Position(ws) {
+							t.Errorf("Expected the grid position of workspace %v to be %v in %+v", ws, g.Position(ws), g)
+						}
+					}
+				}
+			}
+		}
+
+		if _, _, _, ok := g.Cell(workspaces(g) + 1); ok {
+			t.Errorf("Expected workspace %v to be outside of %+v", workspaces(g)+1, g)
+		}
+
+		for _, ws := range []int{0, -1} {
+			if _, _, _, ok := g.Cell(ws); ok {
+				t.Errorf("Expected workspace %v to be outside of %+v", ws, g)
+			}
+		}
+
+		var outside = [][4]int{
+			{0, 1, 1, 1},
+			{g.Outputs + 1, 1, 1, 1},
+			{1, 0, 1, 1},
+			{1, g.Size.Pages() + 1, 1, 1},
+			{1, 1, 0, 1},
+			{1, 1, g.Size.RealX + 1, 1},
+			{1, 1, 1, 0},
+			{1, 1, 1, g.Size.RealY + 1},
+		}
+
+		for _, cell := range outside {
+			if _, ok := g.Workspace(cell[0], cell[1], cell[2], cell[3]); ok {
+				t.Errorf("Expected %v to be outside of %+v", cell, g)
+			}
+		}
+	})
+}
+
+func TestGridMoves(t *testing.T) {
+	forEachGrid(func(g grid.Grid) {
+		for ws := 1; ws <= workspaces(g); ws++ {
+			env := grid.Environment{
+				ActiveOutputs:    g.Outputs,
+				CurrentOutput:    g.Output(ws),
+				CurrentWorkspace: ws,
+				MaxWorkspace:     workspaces(g),
+			}
+
+			edgeFuncs := grid.BuildEdgeFuncs(env, g.Size)
+			targetFuncs := grid.BuildTargetFuncs(env, g.Size)
+
+			page, x, y, _ := g.Cell(ws)
+
+			for direction, move := range moves {
+				// The edge functions agree with the bounds of the grid.
+				expected, inside := g.Workspace(env.CurrentOutput, page+move[0], x+move[1], y+move[2])
+
+				edge := edgeFuncs[direction](ws)
+				if edge == inside {
+					t.Errorf("Expected edge %v to be %v for workspace %v in %+v", direction, !inside, ws, g)
+					continue
+				}
+
+				if edge {
+					continue
+				}
+
+				// Targets are the next cell in the direction, on the same output.
+				target := targetFuncs[direction]()
+				if target != expected || g.Output(target) != env.CurrentOutput {
+					t.Errorf("Expected target %v to be %v for workspace %v in %+v, got %v", direction, expected, ws, g, target)
+					continue
+				}
+
+				// Moving back the opposite way returns to the start.
+				back := env
+				back.CurrentWorkspace = target
+
+				opposite := opposites[direction]
+				if grid.BuildEdgeFuncs(back, g.Size)[opposite](target) {
+					t.Errorf("Expected workspace %v not to be on edge %v in %+v", target, opposite, g)
+					continue
+				}
+
+				if actual := grid.BuildTargetFuncs(back, g.Size)[opposite](); actual != ws {
+					t.Errorf("Expected %v then %v from workspace %v to return to it in %+v, got %v", direction, opposite, ws, g, actual)
+				}
+			}
+		}
+	})
+}
+
+func TestEdgesOutsideGrid(t *testing.T) {
+	env := grid.Environment{ActiveOutputs: 2, CurrentOutput: 1, MaxWorkspace: 17}
+	size := grid.NewSize(env, 3, 3)
+
+	for _, ws := range []int{0, -1} {
+		for direction, edgeFunc := range grid.BuildEdgeFuncs(env, size) {
+			if !edgeFunc(ws) {
+				t.Errorf("Expected workspace %v to be on edge %v", ws, direction)
+			}
+		}
+	}
+}
+
+func TestNewEnvironmentUnnumbered(t *testing.T) {
+	outputs := []i3.Output{
+		{Name: "DP-1", Active: true},
+		{Name: "DP-2", Active: true},
+	}
+
+	workspaces := []i3.Workspace{
+		{Num: 1, Output: "DP-1"},
+		{Num: -1, Name: "chat", Output: "DP-2", Focused: true},
+		{Num: 6, Output: "DP-2"},
+	}
+
+	env := grid.NewEnvironment(outputs, workspaces)
+	if env.CurrentWorkspace != -1 || env.CurrentOutput != 0 || env.MaxWorkspace != 6 {
+		t.Fatalf("Expected the current workspace to be off the grid, with a max workspace of 6, got %+v", env)
+	}
+
+	size := grid.NewSize(env, 3, 3)
+	if size.RealY != 3 {
+		t.Errorf("Expected 3 rows, got %v", size.RealY)
+	}
+
+	if _, ok := grid.PageWorkspace(env, size, 1); ok {
+		t.Error("Expected there to be no cell to keep on another page")
+	}
+
+	if _, ok := grid.CellWorkspace(env, size, 1, 1); ok {
+		t.Error("Expected there to be no cell without a current output")
+	}
+
+	// Once the output that the workspace is on is known, cells can be found on it's grid.
+	env.CurrentOutput = 2
+	if ws, ok := grid.CellWorkspace(env, size, 1, 1); ws != 2 || !ok {
+		t.Errorf("Expected cell 1,1 to be workspace 2, got (%v, %v)", ws, ok)
+	}
+}
+
+func TestNoOutputs(t *testing.T) {
+	// i3 can briefly report no active outputs, e.g. whilst outputs are being reconfigured.
+	outputs := []i3.Output{
+		{Name: "DP-1", Active: false},
+	}
+
+	workspaces := []i3.Workspace{
+		{Num: 3, Output: "DP-1", Focused: true},
+		{Num: 5, Output: "DP-1"},
+	}
+
+	env := grid.NewEnvironment(outputs, workspaces)
+	if env.ActiveOutputs != 0 || env.CurrentOutput != 0 || env.CurrentWorkspace != 3 || env.MaxWorkspace != 5 {
+		t.Fatalf("Expected no active outputs, and no current output, got %+v", env)
+	}
+
+	size := grid.NewSize(env, 3, 3)
+	if size.RealY != 3 {
+		t.Errorf("Expected 3 rows, got %v", size.RealY)
+	}
+
+	// The grid is treated as having one output.
+	g := grid.NewGrid(env, size)
+	if output := g.Output(5); output != 1 {
+		t.Errorf("Expected workspace 5 to be on output 1, got %v", output)
+	}
+
+	if page, x, y, ok := g.Cell(5); page != 1 || x != 2 || y != 2 || !ok {
+		t.Errorf("Expected workspace 5 to be at 2,2 on page 1, got %v,%v on page %v (%v)", x, y, page, ok)
+	}
+
+	edgeFuncs := grid.BuildEdgeFuncs(env, size)
+	if !edgeFuncs[grid.Up](3) || !edgeFuncs[grid.Right](3) || edgeFuncs[grid.Down](3) || edgeFuncs[grid.Left](3) {
+		t.Error("Expected workspace 3 to be on the top and right edges, and not the bottom or left edges")
+	}
+
+	// Every direction that isn't an edge should move somewhere.
+	targetFuncs := grid.BuildTargetFuncs(env, size)
+
+	var targets = []struct {
+		direction grid.Direction
+		expected  int
+	}{
+		{grid.Down, 6},
+		{grid.Left, 2},
+		{grid.DownLeft, 5},
+	}
+
+	for _, target := range targets {
+		if actual := targetFuncs[target.direction](); actual != target.expected {
+			t.Errorf("Expected %v from workspace 3 to be workspace %v, got %v", target.direction, target.expected, actual)
+		}
+	}
+}
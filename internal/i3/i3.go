@@ -1,10 +1,13 @@
 package i3
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
-	"math"
 	"os/exec"
+	"strconv"
+	"strings"
 )
 
 // FindOutputs fetches an array of outputs from i3 via i3-msg. This will return all outputs, not
@@ -43,36 +46,209 @@ func FindWorkspaces() ([]Workspace, error) {
 	return workspaces, nil
 }
 
+// FindTree fetches i3's layout tree via i3-msg, returning the root node.
+func FindTree() (Node, error) {
+	var tree Node
+
+	out, err := exec.Command("i3-msg", "-t", "get_tree").Output()
+	if err != nil {
+		return Node{}, err
+	}
+
+	err = json.Unmarshal(out, &tree)
+	if err != nil {
+		return Node{}, err
+	}
+
+	return tree, nil
+}
+
+// Subscribe subscribes to the given i3 events via i3-msg, calling the given function each time one
+// of the events occurs. This blocks until the given context is cancelled, or i3-msg exits (e.g. if
+// i3 restarts), returning any error.
+func Subscribe(ctx context.Context, events []string, fn func()) error {
+	payload, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "i3-msg", "-t", "subscribe", "-m", string(payload))
+
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	// Each event is written as a line of JSON. We don't care what's in it, just that it happened,
+	// but events about windows with long titles can be large.
+	scanner := bufio.NewScanner(out)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		fn()
+	}
+
+	if err := scanner.Err(); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+
+	return cmd.Wait()
+}
+
 // MoveToWorkspace tells i3 to move the current container to the given workspace. It does not also
 // switch to the workspace. Any error running the i3-msg command will be returned.
-func MoveToWorkspace(workspace float64) error {
-	ws := fmt.Sprintf("%v", workspace)
+func MoveToWorkspace(workspace int) error {
+	ws := strconv.Itoa(workspace)
+
+	return exec.Command("i3-msg", "move", "container", "to", "workspace", "number", ws).Run()
+}
+
+// MoveAllToWorkspace tells i3 to move every container on the focused workspace to the given
+// workspace. It does not also switch to the workspace. Any error running the i3-msg command will be
+// returned.
+func MoveAllToWorkspace(workspace int) error {
+	ws := strconv.Itoa(workspace)
 
-	return exec.Command("i3-msg", "move", "container", "to", "workspace", ws).Run()
+	return exec.Command("i3-msg", `[workspace="__focused__"]`, "move", "container", "to", "workspace", "number", ws).Run()
 }
 
-// SwitchToWorkspace tells i3 to switch to the given workspace. Any error running the i3-msg command
-// will be returned.
-func SwitchToWorkspace(workspace float64) error {
-	ws := fmt.Sprintf("%v", workspace)
+// MoveContainersToWorkspace tells i3 to move every container on the workspace given by from to the
+// workspace given by to. Any error running the i3-msg command will be returned.
+func MoveContainersToWorkspace(from int, to int) error {
+	cmd := fmt.Sprintf(`[workspace="^%d(:.*)?$"] move container to workspace number %d`, from, to)
 
-	return exec.Command("i3-msg", "workspace", ws).Run()
+	return exec.Command("i3-msg", cmd).Run()
+}
+
+// SwitchToWorkspace tells i3 to switch to the given workspace. The workspace is found by it's
+// number, so this works whether or not it has a label. Any error running the i3-msg command will be
+// returned.
+func SwitchToWorkspace(workspace int) error {
+	ws := strconv.Itoa(workspace)
+
+	return exec.Command("i3-msg", "workspace", "number", ws).Run()
+}
+
+// FocusWorkspace tells i3 to switch to the workspace with the given name. Unlike SwitchToWorkspace,
+// this works for workspaces that aren't numbered. Any error running the i3-msg command will be
+// returned.
+func FocusWorkspace(name string) error {
+	return exec.Command("i3-msg", "workspace "+quote(name)).Run()
+}
+
+// FocusOutput tells i3 to focus the output with the given name, switching to whichever workspace is
+// visible on it. Any error running the i3-msg command will be returned.
+func FocusOutput(outputName string) error {
+	return exec.Command("i3-msg", "focus", "output", outputName).Run()
+}
+
+// RenameWorkspace tells i3 to rename the workspace with the given name. The workspace will stay on
+// the output that it's currently on. Any error running the i3-msg command will be returned.
+func RenameWorkspace(from string, to string) error {
+	cmd := fmt.Sprintf(`rename workspace %s to %s`, quote(from), quote(to))
+
+	return exec.Command("i3-msg", cmd).Run()
+}
+
+// SwapWorkspaces tells i3 to rename the workspace named a to aTo, and the workspace named b to bTo,
+// where aTo and bTo are usually each other's numbers. Both workspaces will stay on the output that
+// they're currently on. The renames are sent to i3 as a single command, using a temporary name, so
+// that the swap happens all at once. Any error running the i3-msg command will be returned.
+func SwapWorkspaces(a, aTo, b, bTo string) error {
+	tmp := quote("i3x3-swap")
+	cmd := fmt.Sprintf(
+		`rename workspace %s to %s; rename workspace %s to %s; rename workspace %s to %s`,
+		quote(a), tmp, quote(b), quote(bTo), tmp, quote(aTo),
+	)
+
+	return exec.Command("i3-msg", cmd).Run()
 }
 
 // MoveWorkspaceToOutput takes a given workspace, and moves it to the given output (used for
 // re-arranging workspaces on differing numbers of outputs).
-func MoveWorkspaceToOutput(workspaceNum float64, outputName string) error {
+func MoveWorkspaceToOutput(workspaceNum int, outputName string) error {
 	// Firstly, switch to the workspace that will be moved.
-	SwitchToWorkspace(float64(workspaceNum))
+	SwitchToWorkspace(workspaceNum)
 
 	// Then move the workspace (you can't move a workspace unless you're on it).
 	return exec.Command("i3-msg", "move", "workspace", "to", "output", outputName).Run()
 }
 
+// WorkspaceWindows walks the given layout tree, collecting the properties of every window on each
+// workspace, including floating windows. The result is keyed by workspace number, and each
+// workspace's windows are in the order they appear in the tree.
+func WorkspaceWindows(tree Node) map[int][]WindowProperties {
+	windows := make(map[int][]WindowProperties)
+
+	var walk func(node Node, workspace int)
+	walk = func(node Node, workspace int) {
+		if node.Type == "workspace" {
+			workspace = node.Num
+		}
+
+		if node.Window != 0 {
+			windows[workspace] = append(windows[workspace], node.WindowProperties)
+		}
+
+		for _, child := range node.Nodes {
+			walk(child, workspace)
+		}
+
+		for _, child := range node.FloatingNodes {
+			walk(child, workspace)
+		}
+	}
+
+	walk(tree, 0)
+
+	return windows
+}
+
+// WorkspaceName builds the name for a workspace with the given number, and label. Labelled
+// workspaces are named like "5:mail", which i3 still recognises the number of.
+func WorkspaceName(workspace int, label string) string {
+	if label == "" {
+		return strconv.Itoa(workspace)
+	}
+
+	return fmt.Sprintf("%d:%s", workspace, label)
+}
+
+// WorkspaceLabel returns the label from the given workspace name, if it has one. Only names that
+// begin with a number followed by a colon (e.g. "5:mail") are considered to be labelled.
+func WorkspaceLabel(name string) string {
+	idx := strings.Index(name, ":")
+	if idx < 1 {
+		return ""
+	}
+
+	if _, err := strconv.Atoi(name[:idx]); err != nil {
+		return ""
+	}
+
+	return name[idx+1:]
+}
+
+// quote wraps the given string in double quotes, escaping it so that it is safe to use as an
+// argument in an i3 command.
+func quote(str string) string {
+	str = strings.Replace(str, `\`, `\\`, -1)
+	str = strings.Replace(str, `"`, `\"`, -1)
+
+	return `"` + str + `"`
+}
+
 // ActiveOutputsNum counts the number of active outputs in the given slice of Outputs. This could
 // be, but is unlikely to be 0.
-func ActiveOutputsNum(outputs []Output) float64 {
-	return float64(len(ActiveOutputs(outputs)))
+func ActiveOutputsNum(outputs []Output) int {
+	return len(ActiveOutputs(outputs))
 }
 
 // ActiveOutputs returns the active outputs in the given slice of Outputs. This could be, but is
@@ -93,8 +269,15 @@ func ActiveOutputs(outputs []Output) []Output {
 // the workspace number, and the number of outputs. We avoid trying to figure out the physical
 // layout of displays because that will be both complicated, and error prone. This method works best
 // if your only method of navigating workspaces is by using i3x3.
-func CurrentOutputNum(workspaceNum float64, outputsNum float64) float64 {
-	mod := math.Mod(workspaceNum, outputsNum)
+//
+// Workspaces that aren't numbered don't belong to any output, so 0 is returned for them, and if
+// there are no outputs.
+func CurrentOutputNum(workspaceNum int, outputsNum int) int {
+	if workspaceNum < 1 || outputsNum < 1 {
+		return 0
+	}
+
+	mod := workspaceNum % outputsNum
 
 	if mod == 0 {
 		return outputsNum
@@ -103,23 +286,39 @@ func CurrentOutputNum(workspaceNum float64, outputsNum float64) float64 {
 	return mod
 }
 
-// CurrentWorkspaceNum gets the currently focused workspace number from the given workspaces.
-func CurrentWorkspaceNum(workspaces []Workspace) float64 {
+// CurrentWorkspaceNum gets the currently focused workspace number from the given workspaces. If the
+// focused workspace isn't numbered, it's number will be -1, and if no workspace is focused, 0 is
+// returned. Either way, the current workspace isn't on the grid.
+func CurrentWorkspaceNum(workspaces []Workspace) int {
+	workspace, ok := FocusedWorkspace(workspaces)
+	if !ok {
+		return 0
+	}
+
+	return workspace.Num
+}
+
+// FocusedWorkspace finds the focused workspace in the given workspaces, if there is one.
+func FocusedWorkspace(workspaces []Workspace) (Workspace, bool) {
 	for _, workspace := range workspaces {
 		if workspace.Focused {
-			return float64(workspace.Num)
+			return workspace, true
 		}
 	}
 
-	return 1.0
+	return Workspace{}, false
 }
 
 // MaxWorkspaceNum finds the workspace with the highest number in the given slice of workspaces.
-func MaxWorkspaceNum(workspaces []Workspace) float64 {
-	max := 0.0
+// Workspaces that aren't numbered are ignored, and 0 is returned if there are no numbered
+// workspaces.
+func MaxWorkspaceNum(workspaces []Workspace) int {
+	max := 0
 
 	for _, workspace := range workspaces {
-		max = math.Max(max, float64(workspace.Num))
+		if workspace.Num > max {
+			max = workspace.Num
+		}
 	}
 
 	return max
@@ -0,0 +1,160 @@
+package i3_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/i3"
+)
+
+func TestWorkspaceName(t *testing.T) {
+	var tests = []struct {
+		workspace int
+		label     string
+		expected  string
+	}{
+		{1, "", "1"},
+		{5, "mail", "5:mail"},
+		{12, "web: dev", "12:web: dev"},
+	}
+
+	for _, test := range tests {
+		actual := i3.WorkspaceName(test.workspace, test.label)
+		if actual != test.expected {
+			t.Errorf(
+				"Expected %q to equal %q for workspace %v, with label %q",
+				actual,
+				test.expected,
+				test.workspace,
+				test.label,
+			)
+		}
+	}
+}
+
+func TestWorkspaceLabel(t *testing.T) {
+	var tests = []struct {
+		name     string
+		expected string
+	}{
+		{"1", ""},
+		{"5:mail", "mail"},
+		{"12:web: dev", "web: dev"},
+		{"5:", ""},
+		{":mail", ""},
+		{"chat", ""},
+		{"chat:mail", ""},
+	}
+
+	for _, test := range tests {
+		actual := i3.WorkspaceLabel(test.name)
+		if actual != test.expected {
+			t.Errorf("Expected %q to equal %q for name %q", actual, test.expected, test.name)
+		}
+	}
+}
+
+func TestWorkspaceWindows(t *testing.T) {
+	tree := i3.Node{
+		Type: "root",
+		Nodes: []i3.Node{
+			{
+				Type: "output",
+				Nodes: []i3.Node{
+					{
+						Type: "workspace",
+						Num:  1,
+						Nodes: []i3.Node{
+							{Type: "con", Window: 1, WindowProperties: i3.WindowProperties{Class: "Firefox"}},
+							{Type: "con", Nodes: []i3.Node{
+								{Type: "con", Window: 2, WindowProperties: i3.WindowProperties{Class: "URxvt"}},
+							}},
+						},
+						FloatingNodes: []i3.Node{
+							{Type: "floating_con", Nodes: []i3.Node{
+								{Type: "con", Window: 3, WindowProperties: i3.WindowProperties{Class: "Pavucontrol"}},
+							}},
+						},
+					},
+					{
+						Type: "workspace",
+						Num:  3,
+					},
+				},
+			},
+		},
+	}
+
+	windows := i3.WorkspaceWindows(tree)
+
+	var classes []string
+	for _, window := range windows[1] {
+		classes = append(classes, window.Class)
+	}
+
+	expected := []string{"Firefox", "URxvt", "Pavucontrol"}
+	if fmt.Sprint(classes) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v to equal %v for workspace 1", classes, expected)
+	}
+
+	if len(windows[3]) != 0 {
+		t.Errorf("Expected no windows for workspace 3, got %v", windows[3])
+	}
+}
+
+func TestCurrentOutputNum(t *testing.T) {
+	var tests = []struct {
+		workspace int
+		outputs   int
+		expected  int
+	}{
+		{1, 1, 1},
+		{4, 1, 1},
+		{1, 2, 1},
+		{4, 2, 2},
+		{7, 3, 1},
+		{9, 3, 3},
+		{-1, 2, 0},
+		{0, 2, 0},
+		{3, 0, 0},
+	}
+
+	for _, test := range tests {
+		actual := i3.CurrentOutputNum(test.workspace, test.outputs)
+		if actual != test.expected {
+			t.Errorf("Expected %v to equal %v for workspace %v, with %v outputs", actual, test.expected, test.workspace, test.outputs)
+		}
+	}
+}
+
+func TestUnnumberedWorkspaces(t *testing.T) {
+	// i3 gives workspaces that only have a name a number of -1.
+	workspaces := []i3.Workspace{
+		{Num: 2, Name: "2"},
+		{Num: -1, Name: "chat", Focused: true},
+		{Num: 5, Name: "5:mail"},
+		{Num: -1, Name: "music"},
+	}
+
+	if ws := i3.CurrentWorkspaceNum(workspaces); ws != -1 {
+		t.Errorf("Expected the current workspace to be -1, got %v", ws)
+	}
+
+	if focused, ok := i3.FocusedWorkspace(workspaces); focused.Name != "chat" || !ok || focused.Numbered() {
+		t.Errorf("Expected the focused workspace to be chat, and not numbered, got %+v (%v)", focused, ok)
+	}
+
+	if ws := i3.MaxWorkspaceNum(workspaces); ws != 5 {
+		t.Errorf("Expected the max workspace to be 5, got %v", ws)
+	}
+
+	unfocused := []i3.Workspace{{Num: -1, Name: "chat"}}
+
+	if ws := i3.CurrentWorkspaceNum(unfocused); ws != 0 {
+		t.Errorf("Expected the current workspace to be 0 without a focused workspace, got %v", ws)
+	}
+
+	if ws := i3.MaxWorkspaceNum(unfocused); ws != 0 {
+		t.Errorf("Expected the max workspace to be 0 without numbered workspaces, got %v", ws)
+	}
+}
@@ -27,3 +27,30 @@ type Workspace struct {
 	Output  string `json:"output"`
 	Urgent  bool   `json:"urgent"`
 }
+
+// Numbered returns true if the workspace has a number. i3 gives workspaces that only have a name
+// (e.g. "chat") a number of -1. Those workspaces aren't on i3x3's grid, so i3x3 leaves them alone.
+func (w Workspace) Numbered() bool {
+	return w.Num >= 1
+}
+
+// Node represents a node in i3's layout tree, e.g. an output, a workspace, or a container.
+type Node struct {
+	ID               int64            `json:"id"`
+	Name             string           `json:"name"`
+	Type             string           `json:"type"`
+	Num              int              `json:"num"`
+	Window           int64            `json:"window"`
+	WindowProperties WindowProperties `json:"window_properties"`
+	Focused          bool             `json:"focused"`
+	Urgent           bool             `json:"urgent"`
+	Nodes            []Node           `json:"nodes"`
+	FloatingNodes    []Node           `json:"floating_nodes"`
+}
+
+// WindowProperties represents the X11 properties of a window in i3's layout tree.
+type WindowProperties struct {
+	Class    string `json:"class"`
+	Instance string `json:"instance"`
+	Title    string `json:"title"`
+}
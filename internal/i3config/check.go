@@ -0,0 +1,226 @@
+package i3config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// modifiers are the modifier names that i3 understands, in lower case, mapped to the name they're
+// normalised to.
+var modifiers = map[string]string{
+	"shift":   "shift",
+	"control": "control",
+	"ctrl":    "control",
+	"lock":    "lock",
+	"mod1":    "mod1",
+	"mod2":    "mod2",
+	"mod3":    "mod3",
+	"mod4":    "mod4",
+	"mod5":    "mod5",
+	"group1":  "group1",
+	"group2":  "group2",
+	"group3":  "group3",
+	"group4":  "group4",
+}
+
+// Config is the parts of an i3 (or Sway) config that are needed to check it for conflicts.
+type Config struct {
+	// Bindings are the config's bindsym bindings. Bindings that run on release are left out, because
+	// they don't stop a binding for the same keys from running on press.
+	Bindings []Binding
+	// Variables are the variables set in the config, keyed by name, including the "$".
+	Variables map[string]string
+}
+
+// Conflict is a generated binding that uses the same keys as a binding in an existing config, but
+// runs a different command.
+type Conflict struct {
+	Existing  Binding
+	Generated Binding
+}
+
+// Parse reads the bindings and variables from the given config. Includes aren't followed.
+func Parse(r io.Reader) (Config, error) {
+	config := Config{
+		Variables: make(map[string]string),
+	}
+
+	var mode string
+	var line string
+	var lineNum int
+	var startNum int
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNum++
+
+		text := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			startNum = lineNum
+		}
+
+		// Lines ending with a backslash are continued on the next line.
+		if strings.HasSuffix(text, `\`) {
+			line += strings.TrimSuffix(text, `\`) + " "
+			continue
+		}
+
+		line += text
+		fields := strings.Fields(line)
+		line = ""
+
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch {
+		case fields[0] == "set" && len(fields) >= 3:
+			config.Variables[fields[1]] = strings.Join(fields[2:], " ")
+		case fields[0] == "set_from_resource" && len(fields) >= 4:
+			config.Variables[fields[1]] = strings.Join(fields[3:], " ")
+		case fields[0] == "mode" && fields[len(fields)-1] == "{":
+			mode = strings.Trim(strings.Join(fields[1:len(fields)-1], " "), `"`)
+		case fields[0] == "}":
+			mode = ""
+		case fields[0] == "bindsym":
+			binding, ok := parseBindsym(fields[1:])
+			if ok {
+				binding.Line = startNum
+				binding.Mode = mode
+				config.Bindings = append(config.Bindings, binding)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return config, fmt.Errorf("i3config: error reading config: %v", err)
+	}
+
+	return config, nil
+}
+
+// parseBindsym parses the arguments of a bindsym line. It's not ok if the binding runs on release,
+// or the line is incomplete.
+func parseBindsym(args []string) (Binding, bool) {
+	var binding Binding
+
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		if args[0] == "--release" {
+			return binding, false
+		}
+
+		args = args[1:]
+	}
+
+	if len(args) < 2 {
+		return binding, false
+	}
+
+	binding.Keys = args[0]
+	binding.Command = strings.Join(args[1:], " ")
+
+	return binding, true
+}
+
+// Conflicts returns the generated bindings that use the same keys as a binding in the default mode
+// of the given config, but run a different command. Variables in the generated bindings are
+// resolved using the config's variables.
+func Conflicts(config Config, generated []Binding) []Conflict {
+	existing := make(map[string]Binding)
+	for _, binding := range config.Bindings {
+		if binding.Mode != "" {
+			continue
+		}
+
+		existing[normaliseKeys(binding.Keys, config.Variables)] = binding
+	}
+
+	var conflicts []Conflict
+
+	for _, binding := range generated {
+		other, ok := existing[normaliseKeys(binding.Keys, config.Variables)]
+		if !ok {
+			continue
+		}
+
+		if normaliseCommand(other.Command, config.Variables) == normaliseCommand(binding.Command, config.Variables) {
+			continue
+		}
+
+		conflicts = append(conflicts, Conflict{
+			Existing:  other,
+			Generated: binding,
+		})
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].Existing.Line < conflicts[j].Existing.Line
+	})
+
+	return conflicts
+}
+
+// normaliseKeys resolves the variables in the given key combination, and then puts it in a form
+// that can be compared with other key combinations, regardless of the order of it's modifiers, or
+// how they're spelled (e.g. "Ctrl+Mod4+a" and "mod4+control+A" are the same).
+func normaliseKeys(keys string, variables map[string]string) string {
+	var mods []string
+	var rest []string
+
+	for _, part := range strings.Split(resolve(keys, variables), "+") {
+		part = strings.ToLower(part)
+
+		if mod, ok := modifiers[part]; ok {
+			mods = append(mods, mod)
+		} else {
+			rest = append(rest, part)
+		}
+	}
+
+	sort.Strings(mods)
+
+	return strings.Join(append(mods, rest...), "+")
+}
+
+// normaliseCommand resolves the variables in the given command, and removes the differences that
+// don't change what it does.
+func normaliseCommand(command string, variables map[string]string) string {
+	fields := strings.Fields(resolve(command, variables))
+
+	var normalised []string
+	for _, field := range fields {
+		if field == "--no-startup-id" {
+			continue
+		}
+
+		normalised = append(normalised, field)
+	}
+
+	return strings.Join(normalised, " ")
+}
+
+// resolve replaces the given variables in the given string. Longer variable names are replaced
+// first, so that "$mod" doesn't replace the start of "$mod_alt".
+func resolve(s string, variables map[string]string) string {
+	var names []string
+	for name := range variables {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		s = strings.Replace(s, name, variables[name], -1)
+	}
+
+	return s
+}
@@ -0,0 +1,256 @@
+package i3config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WM is a window manager that a config can be generated for.
+type WM string
+
+// Possible WM values.
+const (
+	WMi3   WM = "i3"
+	WMSway WM = "sway"
+)
+
+// Keys is a set of keys used for directions.
+type Keys string
+
+// Possible Keys values.
+const (
+	// KeysArrows uses the arrow keys.
+	KeysArrows Keys = "arrows"
+	// KeysVim uses h, j, k, and l, like vim.
+	KeysVim Keys = "hjkl"
+	// KeysBoth uses both the arrow keys, and h, j, k, and l.
+	KeysBoth Keys = "both"
+)
+
+// directionKeys are the keys for each direction, in each set of keys.
+var directionKeys = map[Keys][][2]string{
+	KeysArrows: {{"left", "Left"}, {"down", "Down"}, {"up", "Up"}, {"right", "Right"}},
+	KeysVim:    {{"left", "h"}, {"down", "j"}, {"up", "k"}, {"right", "l"}},
+}
+
+// cellKeys are the keys used to jump to cells, in the order of the cells they jump to.
+var cellKeys = []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "0"}
+
+// Options are the settings used to generate a config.
+type Options struct {
+	// WM is the window manager the config is for.
+	WM WM
+	// Keys are the keys used for directions.
+	Keys Keys
+	// Mod are the modifiers used for going to workspaces, e.g. "$mod+Control".
+	Mod string
+	// MoveMod are the modifiers used for moving containers to workspaces.
+	MoveMod string
+	// Columns and Rows are the size of the grid.
+	Columns int
+	Rows    int
+	// Pages is the number of pages of the grid.
+	Pages int
+}
+
+// DefaultOptions returns the options that match the example config in the README.
+func DefaultOptions() Options {
+	return Options{
+		WM:      WMi3,
+		Keys:    KeysArrows,
+		Mod:     "$mod+Control",
+		MoveMod: "$mod+Control+Mod1",
+		Columns: 3,
+		Rows:    3,
+		Pages:   1,
+	}
+}
+
+// Validate returns an error if any of the options are invalid.
+func (o Options) Validate() error {
+	switch o.WM {
+	case WMi3, WMSway:
+	default:
+		return fmt.Errorf("i3config: invalid window manager: %q", o.WM)
+	}
+
+	switch o.Keys {
+	case KeysArrows, KeysVim, KeysBoth:
+	default:
+		return fmt.Errorf("i3config: invalid keys: %q", o.Keys)
+	}
+
+	if o.Mod == "" || o.MoveMod == "" {
+		return fmt.Errorf("i3config: modifiers must not be empty")
+	}
+
+	if normaliseKeys(o.Mod, nil) == normaliseKeys(o.MoveMod, nil) {
+		return fmt.Errorf("i3config: the move modifiers must be different to the modifiers")
+	}
+
+	if o.Columns < 1 || o.Rows < 1 {
+		return fmt.Errorf("i3config: invalid grid size: %dx%d", o.Columns, o.Rows)
+	}
+
+	if o.Pages < 1 {
+		return fmt.Errorf("i3config: invalid number of pages: %d", o.Pages)
+	}
+
+	return nil
+}
+
+// Binding is a key binding in a config.
+type Binding struct {
+	// Line is the line that the binding is on in it's config file, starting from 1. It's 0 for
+	// generated bindings.
+	Line int
+	// Mode is the binding mode that the binding is in, which is empty for the default mode.
+	Mode string
+	// Keys is the key combination, as it's written, e.g. "$mod+Control+Left".
+	Keys string
+	// Command is the command that is run when the keys are pressed.
+	Command string
+}
+
+// section is a group of generated bindings, with a comment describing them.
+type section struct {
+	comment  string
+	bindings []Binding
+}
+
+// Bindings returns the bindings that would be generated with the given options.
+func Bindings(opts Options) []Binding {
+	var bindings []Binding
+
+	for _, section := range sections(opts) {
+		bindings = append(bindings, section.bindings...)
+	}
+
+	return bindings
+}
+
+// Generate writes a config fragment with the bindings for the given options to the given writer.
+func Generate(w io.Writer, opts Options) error {
+	err := opts.Validate()
+	if err != nil {
+		return err
+	}
+
+	var buf strings.Builder
+
+	fmt.Fprintf(&buf, "# i3x3 config for %s, generated by \"i3x3ctl gen-config\".\n", opts.WM)
+	fmt.Fprintln(&buf)
+	pages := ""
+	pagesEnv := ""
+
+	if opts.Pages > 1 {
+		pages = fmt.Sprintf(" and %d pages", opts.Pages)
+		pagesEnv = fmt.Sprintf(" I3X3_PAGES=%d", opts.Pages)
+	}
+
+	fmt.Fprintf(&buf, "# Start the daemon, with a %dx%d grid%s, replacing the one that's running when reloading.\n", opts.Columns, opts.Rows, pages)
+	fmt.Fprintf(&buf, "exec_always %senv I3X3_X_SIZE=%d I3X3_Y_SIZE=%d%s i3x3d --replace\n", noStartupID(opts), opts.Columns, opts.Rows, pagesEnv)
+
+	for _, section := range sections(opts) {
+		fmt.Fprintln(&buf)
+		fmt.Fprintf(&buf, "# %s\n", section.comment)
+
+		for _, binding := range section.bindings {
+			fmt.Fprintf(&buf, "bindsym %s %s\n", binding.Keys, binding.Command)
+		}
+	}
+
+	_, err = io.WriteString(w, buf.String())
+	return err
+}
+
+// sections returns the generated bindings for the given options, grouped by what they do.
+func sections(opts Options) []section {
+	exec := "exec " + noStartupID(opts) + "i3x3ctl "
+
+	var keys [][2]string
+	switch opts.Keys {
+	case KeysBoth:
+		keys = append(keys, directionKeys[KeysArrows]...)
+		keys = append(keys, directionKeys[KeysVim]...)
+	default:
+		keys = directionKeys[opts.Keys]
+	}
+
+	var goSection, moveSection, jumpSection section
+
+	goSection.comment = "Switch to the adjacent workspace."
+	moveSection.comment = "Move the focused container to the adjacent workspace, and follow it."
+
+	for _, key := range keys {
+		goSection.bindings = append(goSection.bindings, Binding{
+			Keys:    opts.Mod + "+" + key[1],
+			Command: exec + "go " + key[0],
+		})
+
+		moveSection.bindings = append(moveSection.bindings, Binding{
+			Keys:    opts.MoveMod + "+" + key[1],
+			Command: exec + "move " + key[0],
+		})
+	}
+
+	jumpSection.comment = "Jump to a cell in the current output's grid, numbered row by row from the top left."
+
+	cells := opts.Columns * opts.Rows
+	if cells > len(cellKeys) {
+		cells = len(cellKeys)
+		jumpSection.comment += fmt.Sprintf(" Only the first %d cells have keys.", cells)
+	}
+
+	for i := 0; i < cells; i++ {
+		jumpSection.bindings = append(jumpSection.bindings, Binding{
+			Keys:    opts.Mod + "+" + cellKeys[i],
+			Command: fmt.Sprintf("%sjump -x %d -y %d", exec, (i%opts.Columns)+1, (i/opts.Columns)+1),
+		})
+	}
+
+	sections := []section{
+		goSection,
+		moveSection,
+		jumpSection,
+	}
+
+	if opts.Pages > 1 {
+		sections = append(sections, section{
+			comment: "Switch to the same cell on the previous or next page, or move the focused container there.",
+			bindings: []Binding{
+				{Keys: opts.Mod + "+Prior", Command: exec + "page prev"},
+				{Keys: opts.Mod + "+Next", Command: exec + "page next"},
+				{Keys: opts.MoveMod + "+Prior", Command: exec + "page prev -move"},
+				{Keys: opts.MoveMod + "+Next", Command: exec + "page next -move"},
+			},
+		})
+	}
+
+	return append(sections, []section{
+		{
+			comment: "Go back and forward through the workspaces switched to with i3x3.",
+			bindings: []Binding{
+				{Keys: opts.Mod + "+bracketleft", Command: exec + "back"},
+				{Keys: opts.Mod + "+bracketright", Command: exec + "forward"},
+			},
+		},
+		{
+			comment: "Pick a workspace with the keyboard or mouse.",
+			bindings: []Binding{
+				{Keys: opts.Mod + "+g", Command: exec + "pick"},
+			},
+		},
+	}...)
+}
+
+// noStartupID returns the option that stops i3 from showing startup notifications for commands. Sway
+// doesn't have startup notifications, so it's left out.
+func noStartupID(opts Options) string {
+	if opts.WM == WMSway {
+		return ""
+	}
+
+	return "--no-startup-id "
+}
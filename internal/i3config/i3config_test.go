@@ -0,0 +1,193 @@
+package i3config_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/i3config"
+)
+
+func TestGenerate(t *testing.T) {
+	opts := i3config.DefaultOptions()
+	opts.Keys = i3config.KeysVim
+	opts.Columns = 2
+	opts.Rows = 2
+
+	var buf strings.Builder
+
+	err := i3config.Generate(&buf, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "" +
+		"# i3x3 config for i3, generated by \"i3x3ctl gen-config\".\n" +
+		"\n" +
+		"# Start the daemon, with a 2x2 grid, replacing the one that's running when reloading.\n" +
+		"exec_always --no-startup-id env I3X3_X_SIZE=2 I3X3_Y_SIZE=2 i3x3d --replace\n" +
+		"\n" +
+		"# Switch to the adjacent workspace.\n" +
+		"bindsym $mod+Control+h exec --no-startup-id i3x3ctl go left\n" +
+		"bindsym $mod+Control+j exec --no-startup-id i3x3ctl go down\n" +
+		"bindsym $mod+Control+k exec --no-startup-id i3x3ctl go up\n" +
+		"bindsym $mod+Control+l exec --no-startup-id i3x3ctl go right\n" +
+		"\n" +
+		"# Move the focused container to the adjacent workspace, and follow it.\n" +
+		"bindsym $mod+Control+Mod1+h exec --no-startup-id i3x3ctl move left\n" +
+		"bindsym $mod+Control+Mod1+j exec --no-startup-id i3x3ctl move down\n" +
+		"bindsym $mod+Control+Mod1+k exec --no-startup-id i3x3ctl move up\n" +
+		"bindsym $mod+Control+Mod1+l exec --no-startup-id i3x3ctl move right\n" +
+		"\n" +
+		"# Jump to a cell in the current output's grid, numbered row by row from the top left.\n" +
+		"bindsym $mod+Control+1 exec --no-startup-id i3x3ctl jump -x 1 -y 1\n" +
+		"bindsym $mod+Control+2 exec --no-startup-id i3x3ctl jump -x 2 -y 1\n" +
+		"bindsym $mod+Control+3 exec --no-startup-id i3x3ctl jump -x 1 -y 2\n" +
+		"bindsym $mod+Control+4 exec --no-startup-id i3x3ctl jump -x 2 -y 2\n" +
+		"\n" +
+		"# Go back and forward through the workspaces switched to with i3x3.\n" +
+		"bindsym $mod+Control+bracketleft exec --no-startup-id i3x3ctl back\n" +
+		"bindsym $mod+Control+bracketright exec --no-startup-id i3x3ctl forward\n" +
+		"\n" +
+		"# Pick a workspace with the keyboard or mouse.\n" +
+		"bindsym $mod+Control+g exec --no-startup-id i3x3ctl pick\n"
+
+	if buf.String() != expected {
+		t.Errorf("Expected config to be:\n%v\ngot:\n%v", expected, buf.String())
+	}
+}
+
+func TestGenerateSway(t *testing.T) {
+	opts := i3config.DefaultOptions()
+	opts.WM = i3config.WMSway
+	opts.Keys = i3config.KeysBoth
+	opts.Columns = 4
+	opts.Rows = 4
+
+	var buf strings.Builder
+
+	err := i3config.Generate(&buf, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config := buf.String()
+
+	if strings.Contains(config, "--no-startup-id") {
+		t.Errorf("Expected sway config not to use --no-startup-id")
+	}
+
+	var tests = []struct {
+		line     string
+		expected bool
+	}{
+		{"bindsym $mod+Control+Left exec i3x3ctl go left", true},
+		{"bindsym $mod+Control+h exec i3x3ctl go left", true},
+		{"bindsym $mod+Control+0 exec i3x3ctl jump -x 2 -y 3", true},
+		{"bindsym $mod+Control+1 exec i3x3ctl jump -x 1 -y 1", true},
+		{"jump -x 3 -y 3", false},
+		{"page next", false},
+	}
+
+	for _, test := range tests {
+		if strings.Contains(config, test.line) != test.expected {
+			t.Errorf("Expected config containing %q to be %v, config:\n%v", test.line, test.expected, config)
+		}
+	}
+}
+
+func TestGeneratePages(t *testing.T) {
+	opts := i3config.DefaultOptions()
+	opts.Pages = 3
+
+	var buf strings.Builder
+
+	err := i3config.Generate(&buf, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config := buf.String()
+
+	var tests = []string{
+		"exec_always --no-startup-id env I3X3_X_SIZE=3 I3X3_Y_SIZE=3 I3X3_PAGES=3 i3x3d --replace\n",
+		"bindsym $mod+Control+Next exec --no-startup-id i3x3ctl page next\n",
+		"bindsym $mod+Control+Mod1+Prior exec --no-startup-id i3x3ctl page prev -move\n",
+	}
+
+	for _, line := range tests {
+		if !strings.Contains(config, line) {
+			t.Errorf("Expected config to contain %q, config:\n%v", line, config)
+		}
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	var tests = []struct {
+		modify func(opts *i3config.Options)
+		valid  bool
+	}{
+		{func(opts *i3config.Options) {}, true},
+		{func(opts *i3config.Options) { opts.WM = "dwm" }, false},
+		{func(opts *i3config.Options) { opts.Keys = "wasd" }, false},
+		{func(opts *i3config.Options) { opts.Columns = 0 }, false},
+		{func(opts *i3config.Options) { opts.Pages = 0 }, false},
+		{func(opts *i3config.Options) { opts.MoveMod = "" }, false},
+		{func(opts *i3config.Options) { opts.MoveMod = "Control+$mod" }, false},
+	}
+
+	for i, test := range tests {
+		opts := i3config.DefaultOptions()
+		test.modify(&opts)
+
+		err := opts.Validate()
+		if (err == nil) != test.valid {
+			t.Errorf("Expected options %d to be valid: %v, got error: %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	existing := `
+set $mod Mod4
+set $alt Mod1
+
+# Default mode.
+bindsym $mod+Ctrl+Left focus left
+bindsym Control+$alt+$mod+Right exec i3x3ctl go right
+bindsym --release $mod+Control+Up exec screenshot
+bindsym $mod+Control+Down \
+    exec --no-startup-id i3x3ctl go down
+
+mode "resize" {
+    bindsym $mod+Control+Left resize shrink width 10 px
+}
+
+bindsym $mod+Control+g exec firefox
+`
+
+	config, err := i3config.Parse(strings.NewReader(existing))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	conflicts := i3config.Conflicts(config, i3config.Bindings(i3config.DefaultOptions()))
+
+	var actual []string
+	for _, conflict := range conflicts {
+		actual = append(actual, conflict.Existing.Keys+" -> "+conflict.Generated.Command)
+	}
+
+	expected := []string{
+		"$mod+Ctrl+Left -> exec --no-startup-id i3x3ctl go left",
+		"Control+$alt+$mod+Right -> exec --no-startup-id i3x3ctl move right",
+		"$mod+Control+g -> exec --no-startup-id i3x3ctl pick",
+	}
+
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected conflicts to be:\n%v\ngot:\n%v", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+
+	if len(conflicts) > 0 && conflicts[0].Existing.Line != 6 {
+		t.Errorf("Expected first conflict to be on line 6, got %d", conflicts[0].Existing.Line)
+	}
+}
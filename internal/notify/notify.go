@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+// The freedesktop.org notification service's D-Bus details.
+const (
+	ServiceName   = "org.freedesktop.Notifications"
+	ServicePath   = "/org/freedesktop/Notifications"
+	ServiceNotify = ServiceName + ".Notify"
+)
+
+// Notifier shows desktop notifications through the freedesktop.org notification service on the
+// session bus. Each notification replaces the last one it showed, so a burst of notifications
+// doesn't pile up on the screen. The connection to D-Bus is made when the first notification is
+// shown, and is made again if it's lost.
+type Notifier struct {
+	sync.Mutex
+
+	appName string
+	conn    *dbus.Conn
+	// id is the ID of the last notification shown, which is replaced by the next one.
+	id uint32
+}
+
+// NewNotifier creates a new Notifier, showing notifications from the application with the given
+// name.
+func NewNotifier(appName string) *Notifier {
+	return &Notifier{
+		appName: appName,
+	}
+}
+
+// Notify shows a notification with the given summary and body, that expires after the given
+// duration.
+func (n *Notifier) Notify(summary string, body string, timeout time.Duration) error {
+	n.Lock()
+	defer n.Unlock()
+
+	if n.conn == nil {
+		conn, err := sessionBus()
+		if err != nil {
+			return fmt.Errorf("notify: error connecting to session bus: %v", err)
+		}
+
+		n.conn = conn
+	}
+
+	hints := map[string]dbus.Variant{
+		// Don't keep the notification around in the notification history.
+		"transient": dbus.MakeVariant(true),
+		// Ask notification servers that support it to update the notification in place.
+		"x-canonical-private-synchronous": dbus.MakeVariant(n.appName),
+	}
+
+	var id uint32
+
+	err := n.conn.Object(ServiceName, ServicePath).Call(
+		ServiceNotify,
+		0,
+		n.appName,
+		n.id,
+		"",
+		summary,
+		body,
+		[]string{},
+		hints,
+		int32(timeout/time.Millisecond),
+	).Store(&id)
+
+	if err != nil {
+		// The connection may have been lost, so start again next time.
+		n.conn.Close()
+		n.conn = nil
+
+		return fmt.Errorf("notify: error showing notification: %v", err)
+	}
+
+	n.id = id
+
+	return nil
+}
+
+// Close closes the Notifier's connection to D-Bus, if it has one.
+func (n *Notifier) Close() {
+	n.Lock()
+	defer n.Unlock()
+
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn = nil
+	}
+}
+
+// sessionBus opens a new connection to the session bus. A private connection is used so that
+// closing it doesn't affect anything else in the process using the session bus.
+func sessionBus() (*dbus.Conn, error) {
+	conn, err := dbus.SessionBusPrivate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.Auth(nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	err = conn.Hello()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,153 @@
+package notify_test
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus"
+	"github.com/seeruk/i3x3/internal/notify"
+)
+
+// notification is a notification received by the fake notification service.
+type notification struct {
+	appName   string
+	replaceID uint32
+	summary   string
+	body      string
+	timeout   int32
+}
+
+// notificationService is a fake freedesktop.org notification service.
+type notificationService struct {
+	received chan notification
+	nextID   uint32
+}
+
+// Notify implements the org.freedesktop.Notifications.Notify D-Bus method.
+func (s *notificationService) Notify(appName string, replaceID uint32, icon string, summary string, body string, actions []string, hints map[string]dbus.Variant, timeout int32) (uint32, *dbus.Error) {
+	s.received <- notification{
+		appName:   appName,
+		replaceID: replaceID,
+		summary:   summary,
+		body:      body,
+		timeout:   timeout,
+	}
+
+	if replaceID != 0 {
+		return replaceID, nil
+	}
+
+	s.nextID++
+
+	return s.nextID, nil
+}
+
+// TestNotifier needs dbus-daemon, which is used to start a private session bus. It's skipped if
+// dbus-daemon can't be found.
+func TestNotifier(t *testing.T) {
+	address, stop := startSessionBus(t)
+	defer stop()
+
+	// The Notifier connects to whatever the session bus is.
+	defer os.Setenv("DBUS_SESSION_BUS_ADDRESS", os.Getenv("DBUS_SESSION_BUS_ADDRESS"))
+	os.Setenv("DBUS_SESSION_BUS_ADDRESS", address)
+
+	conn, err := dbus.SessionBusPrivate()
+	if err != nil {
+		t.Fatalf("error connecting to session bus: %v", err)
+	}
+
+	defer conn.Close()
+
+	if err := conn.Auth(nil); err != nil {
+		t.Fatalf("error authenticating with session bus: %v", err)
+	}
+
+	if err := conn.Hello(); err != nil {
+		t.Fatalf("error greeting session bus: %v", err)
+	}
+
+	service := &notificationService{
+		received: make(chan notification, 2),
+	}
+
+	err = conn.Export(service, notify.ServicePath, notify.ServiceName)
+	if err != nil {
+		t.Fatalf("error exporting notification service: %v", err)
+	}
+
+	reply, err := conn.RequestName(notify.ServiceName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("error requesting notification service name: %v (%v)", err, reply)
+	}
+
+	notifier := notify.NewNotifier("i3x3")
+	defer notifier.Close()
+
+	err = notifier.Notify("Workspace 1", "1 2 3", 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first := <-service.received
+
+	expected := notification{appName: "i3x3", summary: "Workspace 1", body: "1 2 3", timeout: 500}
+	if first != expected {
+		t.Errorf("expected notification to be %+v, got %+v", expected, first)
+	}
+
+	err = notifier.Notify("Workspace 2", "1 2 3", 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The second notification replaces the first.
+	second := <-service.received
+	if second.replaceID != 1 {
+		t.Errorf("expected second notification to replace notification 1, got %d", second.replaceID)
+	}
+
+	// Without a notification service, an error is returned.
+	conn.ReleaseName(notify.ServiceName)
+
+	err = notifier.Notify("Workspace 3", "1 2 3", 500*time.Millisecond)
+	if err == nil {
+		t.Error("expected error without a notification service")
+	}
+}
+
+// startSessionBus starts a private session bus, returning it's address, and a function to stop it.
+func startSessionBus(t *testing.T) (string, func()) {
+	path, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not found, skipping test that needs a session bus")
+	}
+
+	cmd := exec.Command(path, "--session", "--nofork", "--print-address")
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("error starting dbus-daemon: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("error starting dbus-daemon: %v", err)
+	}
+
+	stop := func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		stop()
+		t.Fatalf("error reading dbus-daemon address: %v", err)
+	}
+
+	return strings.TrimSpace(address), stop
+}
@@ -0,0 +1,108 @@
+package overlay
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"text/template"
+)
+
+// cssTemplate is the overlay's built-in GTK theme, filled in from a Theme.
+var cssTemplate = template.Must(template.New("css").Funcs(template.FuncMap{"hex": hex}).Parse(`
+	.i3x3-window {
+		background: {{ hex .Background }};
+		color: {{ hex .TextOccupied }};
+	}
+
+	.i3x3-outputs {
+		background: {{ hex .Background }};
+	}
+
+	.i3x3-output {
+		padding: {{ .OutputPadding }}px;
+	}
+
+	.i3x3-output__name {
+		color: {{ hex .Text }};
+		font-size: 8px;
+	}
+
+	.i3x3-output--current {
+		background: {{ hex .OutputCurrent }};
+	}
+
+	.i3x3-output--current .i3x3-output__name {
+		color: {{ hex .TextHighlighted }};
+	}
+
+	.i3x3-grid {
+		background: {{ hex .Grid }};
+		padding: {{ .GridPadding }}px;
+	}
+
+	.i3x3-grid__box {
+		background: {{ hex .Box }};
+		color: {{ hex .Text }};
+	}
+
+	.i3x3-grid__box--overflow {
+		font-style: italic;
+	}
+
+	.i3x3-grid__page {
+		color: {{ hex .Text }};
+		font-size: 8px;
+	}
+
+	.i3x3-grid__box--occupied {
+		color: {{ hex .TextOccupied }};
+	}
+
+	.i3x3-grid__box--visible {
+		background: {{ hex .BoxVisible }};
+	}
+
+	.i3x3-grid__box--urgent {
+		background: {{ hex .BoxUrgent }};
+		color: {{ hex .TextHighlighted }};
+	}
+
+	.i3x3-grid__box--active {
+		background: {{ hex .BoxActive }};
+		color: {{ hex .TextHighlighted }};
+		font-weight: bold;
+	}
+
+	.i3x3-grid__highlight {
+		border: {{ .HighlightWidth }}px solid {{ hex .Highlight }};
+	}
+
+	.i3x3-grid__thumbnail {
+		opacity: 0.4;
+	}
+
+	.i3x3-grid__windows {
+		font-size: 8px;
+		font-weight: normal;
+		font-style: normal;
+	}
+`))
+
+// CSS returns the GTK CSS for the given theme. The GTK overlay's built-in theme is the CSS for
+// DefaultTheme, so the offscreen renderer and the GTK overlay always use the same colours and
+// padding.
+func CSS(theme Theme) string {
+	var buf bytes.Buffer
+
+	// The template is fixed, and a Theme always has every field it uses.
+	if err := cssTemplate.Execute(&buf, theme); err != nil {
+		panic(err)
+	}
+
+	return buf.String()
+}
+
+// hex returns the given colour in CSS hex notation.
+func hex(colour color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", colour.R, colour.G, colour.B)
+}
@@ -0,0 +1,28 @@
+package overlay_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/overlay"
+)
+
+func TestCSS(t *testing.T) {
+	theme := overlay.DefaultTheme()
+	theme.BoxUrgent.R = 0xAB
+	theme.GridPadding = 7
+
+	css := overlay.CSS(theme)
+
+	var expected = []string{
+		".i3x3-grid__box--urgent {\n\t\tbackground: #AB1A1A;",
+		".i3x3-grid {\n\t\tbackground: #2A2A2A;\n\t\tpadding: 7px;",
+		".i3x3-grid__highlight {\n\t\tborder: 2px solid #FFFFFF;",
+	}
+
+	for _, rule := range expected {
+		if !strings.Contains(css, rule) {
+			t.Errorf("Expected CSS to contain %q, got:\n%v", rule, css)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package overlay
+
+import (
+	"sort"
+
+	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/i3"
+)
+
+// State is everything the overlay needs to know about i3 to lay itself out.
+type State struct {
+	Environment grid.Environment
+	Size        grid.Size
+	Workspaces  []i3.Workspace
+	// Outputs are the active outputs, sorted in the same order as they're numbered.
+	Outputs []i3.Output
+	// Target is the workspace being switched to.
+	Target  int
+	Labels  map[int]string
+	Windows map[int][]i3.WindowProperties
+}
+
+// Cell is a single workspace in an output's grid.
+type Cell struct {
+	// Workspace is the workspace number.
+	Workspace int
+	// Column and Row are the position of the cell in the grid, starting from 0.
+	Column int
+	Row    int
+	// Label is the workspace's label, if it has one.
+	Label string
+	// Windows are the windows on the workspace.
+	Windows []i3.WindowProperties
+	// Overflow is set if the cell is only there because the grid has grown beyond it's requested
+	// size.
+	Overflow bool
+	// Occupied is set if the workspace has windows on it. An empty workspace can still exist in i3
+	// while it's focused or visible, but it isn't occupied.
+	Occupied bool
+	// Visible is set if the workspace is visible on another output.
+	Visible bool
+	// Urgent is set if a window on the workspace is urgent.
+	Urgent bool
+	// Current is set if the workspace is the one being switched from.
+	Current bool
+	// Active is set if the workspace is the one being switched to.
+	Active bool
+}
+
+// Grid is the grid of workspaces on a single output.
+type Grid struct {
+	// Output is the output number, starting from 1.
+	Output int
+	// Name is the output's name, if it's known.
+	Name string
+	// Current is set if this is the output being switched on.
+	Current bool
+	// Column and Row are the position of the grid amongst the other outputs' grids, following the
+	// outputs' physical arrangement.
+	Column int
+	Row    int
+	// Columns and Rows are the size of the grid.
+	Columns int
+	Rows    int
+	// Page is the page of the grid that's shown, out of Pages, starting from 1.
+	Page  int
+	Pages int
+	// Cells are the grid's cells, row by row.
+	Cells []Cell
+}
+
+// Cell returns the cell in the grid that shows the given workspace, if there is one.
+func (g Grid) Cell(ws int) (Cell, bool) {
+	for _, cell := range g.Cells {
+		if cell.Workspace == ws {
+			return cell, true
+		}
+	}
+
+	return Cell{}, false
+}
+
+// Layout is the overlay's content, made up of one or more grids.
+type Layout struct {
+	Grids []Grid
+}
+
+// NewLayout lays out the overlay for the given state. Only the current output's grid is included
+// unless allOutputs is set, and there is more than one output.
+func NewLayout(state State, allOutputs bool) Layout {
+	if !allOutputs || len(state.Outputs) < 2 {
+		return Layout{
+			Grids: []Grid{NewGrid(state, state.Environment.CurrentOutput)},
+		}
+	}
+
+	cols, rows := OutputPositions(state.Outputs)
+	grids := make([]Grid, 0, len(state.Outputs))
+
+	for i := range state.Outputs {
+		g := NewGrid(state, i+1)
+		g.Column = cols[i]
+		g.Row = rows[i]
+
+		grids = append(grids, g)
+	}
+
+	return Layout{Grids: grids}
+}
+
+// NewGrid lays out the grid of workspaces for the output with the given number. The current
+// output's grid shows the page that the target is on, and other outputs' grids show the page that
+// their visible workspace is on.
+func NewGrid(state State, output int) Grid {
+	size := state.Size
+	workspaceGrid := grid.NewGrid(state.Environment, size)
+
+	// Workspaces that aren't numbered aren't on the grid, so they aren't shown.
+	workspaces := make(map[int]i3.Workspace, len(state.Workspaces))
+	for _, workspace := range state.Workspaces {
+		if workspace.Numbered() {
+			workspaces[workspace.Num] = workspace
+		}
+	}
+
+	g := Grid{
+		Output:  output,
+		Current: output == state.Environment.CurrentOutput,
+		Columns: size.RealX,
+		Rows:    size.RealY,
+		Page:    1,
+		Pages:   size.Pages(),
+		Cells:   make([]Cell, 0, size.RealX*size.RealY),
+	}
+
+	if output >= 1 && output <= len(state.Outputs) {
+		g.Name = state.Outputs[output-1].Name
+	}
+
+	if g.Current {
+		g.Page = grid.WorkspacePage(state.Target, state.Environment.ActiveOutputs, size)
+	} else {
+		for _, workspace := range state.Workspaces {
+			if workspace.Visible && workspace.Numbered() && workspace.Output == g.Name {
+				g.Page = grid.WorkspacePage(workspace.Num, state.Environment.ActiveOutputs, size)
+			}
+		}
+	}
+
+	for i := 0; i < size.RealX*size.RealY; i++ {
+		row := i / size.RealX
+		col := i - (row * size.RealX)
+
+		ws, _ := workspaceGrid.Workspace(output, g.Page, col+1, row+1)
+
+		cell := Cell{
+			Workspace: ws,
+			Column:    col,
+			Row:       row,
+			Label:     state.Labels[ws],
+			Windows:   state.Windows[ws],
+			Occupied:  len(state.Windows[ws]) > 0,
+			Overflow:  row >= size.OriginalY || (size.OriginalPages > 0 && g.Page > size.OriginalPages),
+			Current:   state.Environment.CurrentWorkspace == ws,
+			Active:    state.Target == ws,
+		}
+
+		if workspace, ok := workspaces[ws]; ok {
+			// Only one workspace can be focused, so any other visible workspace must be shown on
+			// another output.
+			cell.Visible = workspace.Visible && !workspace.Focused
+			cell.Urgent = workspace.Urgent
+		}
+
+		g.Cells = append(g.Cells, cell)
+	}
+
+	return g
+}
+
+// OutputPositions returns the column and row of each of the given outputs, when they're laid out in
+// a grid that follows their physical arrangement. Outputs that share an edge coordinate share a
+// column or row, and gaps between outputs are ignored.
+func OutputPositions(outputs []i3.Output) (cols []int, rows []int) {
+	xs := make([]int, 0, len(outputs))
+	ys := make([]int, 0, len(outputs))
+
+	for _, output := range outputs {
+		xs = append(xs, output.Rect.X)
+		ys = append(ys, output.Rect.Y)
+	}
+
+	cols = ranks(xs)
+	rows = ranks(ys)
+
+	return cols, rows
+}
+
+// ranks returns the position of each of the given values amongst the distinct given values, in
+// ascending order.
+func ranks(values []int) []int {
+	distinct := make([]int, 0, len(values))
+	seen := make(map[int]bool, len(values))
+
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			distinct = append(distinct, value)
+		}
+	}
+
+	sort.Ints(distinct)
+
+	result := make([]int, len(values))
+	for i, value := range values {
+		result[i] = sort.SearchInts(distinct, value)
+	}
+
+	return result
+}
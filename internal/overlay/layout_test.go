@@ -0,0 +1,250 @@
+package overlay_test
+
+import (
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/overlay"
+)
+
+// testOutputs returns the given number of outputs, side by side.
+func testOutputs(count int) []i3.Output {
+	outputs := make([]i3.Output, 0, count)
+	names := []string{"DP-1", "DP-2", "DP-3"}
+
+	for i := 0; i < count; i++ {
+		outputs = append(outputs, i3.Output{
+			Name:   names[i],
+			Active: true,
+			Rect:   i3.Rect{X: i * 1920, Width: 1920, Height: 1080},
+		})
+	}
+
+	return outputs
+}
+
+// testState returns a state with a 3x3 grid across the given number of outputs.
+func testState(outputs int) overlay.State {
+	return overlay.State{
+		Environment: grid.Environment{
+			ActiveOutputs:    outputs,
+			CurrentOutput:    1,
+			CurrentWorkspace: 1,
+			MaxWorkspace:     outputs * 9,
+		},
+		Size:    grid.Size{RealX: 3, RealY: 3, OriginalX: 3, OriginalY: 3},
+		Outputs: testOutputs(outputs),
+		Target:  1,
+	}
+}
+
+func TestNewGrid(t *testing.T) {
+	var tests = []struct {
+		outputs  int
+		output   int
+		expected []int
+	}{
+		{1, 1, []int{1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{2, 1, []int{1, 3, 5, 7, 9, 11, 13, 15, 17}},
+		{2, 2, []int{2, 4, 6, 8, 10, 12, 14, 16, 18}},
+		{3, 3, []int{3, 6, 9, 12, 15, 18, 21, 24, 27}},
+	}
+
+	for _, test := range tests {
+		g := overlay.NewGrid(testState(test.outputs), test.output)
+
+		if len(g.Cells) != len(test.expected) {
+			t.Fatalf("Expected %v cells, got %v", len(test.expected), len(g.Cells))
+		}
+
+		for i, cell := range g.Cells {
+			if cell.Workspace != test.expected[i] {
+				t.Errorf(
+					"Expected cell %v to be workspace %v, got %v, for output %v of %v",
+					i,
+					test.expected[i],
+					cell.Workspace,
+					test.output,
+					test.outputs,
+				)
+			}
+
+			if cell.Column != i%3 || cell.Row != i/3 {
+				t.Errorf("Expected cell %v to be at %v,%v, got %v,%v", i, i%3, i/3, cell.Column, cell.Row)
+			}
+		}
+	}
+}
+
+func TestNewGridCells(t *testing.T) {
+	state := testState(2)
+	state.Size = grid.Size{RealX: 3, RealY: 4, OriginalX: 3, OriginalY: 3}
+	state.Environment.CurrentWorkspace = 3
+	state.Target = 5
+	state.Labels = map[int]string{5: "mail"}
+	state.Windows = map[int][]i3.WindowProperties{
+		5: {{Class: "Thunderbird"}},
+		7: {{Class: "Firefox"}},
+	}
+	state.Workspaces = []i3.Workspace{
+		{Num: 3, Visible: true, Focused: true},
+		{Num: 4, Visible: true},
+		{Num: 5, Urgent: true},
+		{Num: 7},
+	}
+
+	g := overlay.NewGrid(state, 1)
+
+	if g.Name != "DP-1" || !g.Current {
+		t.Errorf("Expected grid to be the current output DP-1, got %q (current: %v)", g.Name, g.Current)
+	}
+
+	// The current workspace exists in i3, but it's empty.
+	current, _ := g.Cell(3)
+	if !current.Current || current.Occupied || current.Visible {
+		t.Errorf("Expected workspace 3 to be current, empty, and not visible elsewhere: %+v", current)
+	}
+
+	active, _ := g.Cell(5)
+	if !active.Active || !active.Urgent || !active.Occupied || active.Label != "mail" || len(active.Windows) != 1 {
+		t.Errorf("Expected workspace 5 to be active, urgent, occupied, labelled, and have a window: %+v", active)
+	}
+
+	occupied, _ := g.Cell(7)
+	if !occupied.Occupied || occupied.Current || occupied.Active {
+		t.Errorf("Expected workspace 7 to be occupied, and not current or active: %+v", occupied)
+	}
+
+	if _, ok := g.Cell(4); ok {
+		t.Error("Expected workspace 4 to be on another output's grid")
+	}
+
+	other := overlay.NewGrid(state, 2)
+
+	visible, _ := other.Cell(4)
+	if !visible.Visible || other.Current {
+		t.Errorf("Expected workspace 4 to be visible on a non-current output: %+v", visible)
+	}
+
+	// The fourth row is only there because the grid has grown.
+	for _, cell := range g.Cells {
+		if cell.Overflow != (cell.Row == 3) {
+			t.Errorf("Expected overflow of cell %v to be %v", cell.Workspace, cell.Row == 3)
+		}
+	}
+}
+
+func TestNewGridPages(t *testing.T) {
+	state := testState(2)
+	state.Size = grid.Size{RealX: 3, RealY: 3, OriginalX: 3, OriginalY: 3, RealPages: 3, OriginalPages: 2}
+	state.Target = 19
+	state.Workspaces = []i3.Workspace{
+		{Num: 1, Output: "DP-1", Visible: true, Focused: true},
+		{Num: 38, Output: "DP-2", Visible: true},
+	}
+
+	current := overlay.NewGrid(state, 1)
+	if current.Page != 2 || current.Pages != 3 {
+		t.Errorf("Expected the current output's grid to show page 2 of 3, got %v of %v", current.Page, current.Pages)
+	}
+
+	if current.Cells[0].Workspace != 19 || current.Cells[8].Workspace != 35 {
+		t.Errorf("Expected page 2 to be workspaces 19 to 35, got %v to %v", current.Cells[0].Workspace, current.Cells[8].Workspace)
+	}
+
+	other := overlay.NewGrid(state, 2)
+	if other.Page != 3 || other.Cells[0].Workspace != 38 {
+		t.Errorf("Expected the other output's grid to show page 3, from workspace 38, got page %v, from %v", other.Page, other.Cells[0].Workspace)
+	}
+
+	// The third page is only there because the grid has grown.
+	if current.Cells[0].Overflow || !other.Cells[0].Overflow {
+		t.Errorf("Expected only the third page to overflow")
+	}
+}
+
+func TestNewGridUnnumbered(t *testing.T) {
+	state := testState(2)
+	state.Size = grid.Size{RealX: 3, RealY: 3, OriginalX: 3, OriginalY: 3, RealPages: 2, OriginalPages: 2}
+	state.Environment.CurrentWorkspace = -1
+	state.Target = -1
+	state.Workspaces = []i3.Workspace{
+		{Num: -1, Name: "chat", Output: "DP-1", Visible: true, Focused: true},
+		{Num: -1, Name: "music", Output: "DP-2", Visible: true},
+		{Num: 20, Output: "DP-2"},
+	}
+
+	for _, output := range []int{1, 2} {
+		g := overlay.NewGrid(state, output)
+		if g.Page != 1 {
+			t.Errorf("Expected output %v's grid to show page 1, got %v", output, g.Page)
+		}
+
+		for _, cell := range g.Cells {
+			if cell.Current || cell.Active || cell.Occupied || cell.Visible {
+				t.Errorf("Expected workspaces that aren't numbered not to be shown, got %+v", cell)
+			}
+		}
+	}
+}
+
+func TestNewLayout(t *testing.T) {
+	state := testState(3)
+
+	layout := overlay.NewLayout(state, false)
+	if len(layout.Grids) != 1 || layout.Grids[0].Output != 1 {
+		t.Errorf("Expected only the current output's grid, got %+v", layout.Grids)
+	}
+
+	// The middle output is above the others.
+	state.Outputs[1].Rect.Y = -1080
+
+	layout = overlay.NewLayout(state, true)
+	if len(layout.Grids) != 3 {
+		t.Fatalf("Expected 3 grids, got %v", len(layout.Grids))
+	}
+
+	var positions = []struct {
+		col int
+		row int
+	}{
+		{0, 1},
+		{1, 0},
+		{2, 1},
+	}
+
+	for i, position := range positions {
+		g := layout.Grids[i]
+		if g.Column != position.col || g.Row != position.row {
+			t.Errorf("Expected grid %v to be at %v,%v, got %v,%v", i, position.col, position.row, g.Column, g.Row)
+		}
+	}
+}
+
+func TestOutputPositions(t *testing.T) {
+	outputs := []i3.Output{
+		{Rect: i3.Rect{X: 0, Y: 0}},
+		{Rect: i3.Rect{X: 1920, Y: 0}},
+		{Rect: i3.Rect{X: 0, Y: 1080}},
+		{Rect: i3.Rect{X: 5000, Y: 1080}},
+	}
+
+	cols, rows := overlay.OutputPositions(outputs)
+
+	expectedCols := []int{0, 1, 0, 2}
+	expectedRows := []int{0, 0, 1, 1}
+
+	for i := range outputs {
+		if cols[i] != expectedCols[i] || rows[i] != expectedRows[i] {
+			t.Errorf(
+				"Expected output %v to be at %v,%v, got %v,%v",
+				i,
+				expectedCols[i],
+				expectedRows[i],
+				cols[i],
+				rows[i],
+			)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+//go:build !nogtk
+// +build !nogtk
+
+package overlay
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/gotk3/gotk3/cairo"
+)
+
+// RenderPNG renders the given scene offscreen with cairo, and writes it to a PNG file at the given
+// path. No display is needed, so this can be used to test the overlay's layout.
+func RenderPNG(scene Scene, path string) error {
+	if scene.Width <= 0 || scene.Height <= 0 {
+		return fmt.Errorf("overlay: invalid scene size: %dx%d", scene.Width, scene.Height)
+	}
+
+	surface := cairo.CreateImageSurface(cairo.FORMAT_ARGB32, scene.Width, scene.Height)
+
+	// Every rectangle is pixel aligned, so there's nothing to antialias, and turning it off makes
+	// sure edges are never blended.
+	cr := cairo.Create(surface)
+	cr.SetAntialias(cairo.ANTIALIAS_NONE)
+
+	setSourceColour(cr, scene.Background)
+	cr.Paint()
+
+	for _, rect := range scene.Rects {
+		setSourceColour(cr, rect.Colour)
+		cr.Rectangle(float64(rect.X), float64(rect.Y), float64(rect.Width), float64(rect.Height))
+		cr.Fill()
+	}
+
+	surface.Flush()
+
+	err := surface.WriteToPNG(path)
+	if err != nil {
+		return fmt.Errorf("overlay: error writing png: %v", err)
+	}
+
+	return nil
+}
+
+// setSourceColour sets the colour that cairo will paint with.
+func setSourceColour(cr *cairo.Context, colour color.RGBA) {
+	cr.SetSourceRGB(float64(colour.R)/0xff, float64(colour.G)/0xff, float64(colour.B)/0xff)
+}
@@ -0,0 +1,173 @@
+//go:build !nogtk
+// +build !nogtk
+
+package overlay_test
+
+import (
+	"flag"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/overlay"
+)
+
+// The golden images in testdata are drawn from the overlay's layout, in the colours of the built-in
+// theme, but they aren't drawn by GTK, so they don't show fonts, icons, or user CSS. They can be
+// regenerated after an intentional change to the overlay's appearance with:
+//
+//	go test ./internal/overlay/ -update
+var update = flag.Bool("update", false, "update golden images")
+
+func TestRenderPNG(t *testing.T) {
+	enlarged := testState(1)
+	enlarged.Size = grid.Size{RealX: 3, RealY: 4, OriginalX: 3, OriginalY: 3}
+	enlarged.Environment.MaxWorkspace = 11
+	enlarged.Target = 11
+	enlarged.Workspaces = []i3.Workspace{
+		{Num: 1, Visible: true, Focused: true},
+		{Num: 11},
+	}
+
+	busy := testState(1)
+	busy.Size = grid.Size{RealX: 4, RealY: 2, OriginalX: 4, OriginalY: 2}
+	busy.Target = 6
+	busy.Workspaces = []i3.Workspace{
+		{Num: 1, Visible: true, Focused: true},
+		{Num: 3, Urgent: true},
+		{Num: 6},
+	}
+	busy.Windows = map[int][]i3.WindowProperties{
+		1: {{Class: "URxvt"}},
+		6: {{Class: "URxvt"}, {Class: "Firefox"}, {Class: "Gimp"}, {Class: "Slack"}},
+	}
+
+	twoOutputs := testState(2)
+	twoOutputs.Target = 3
+	twoOutputs.Workspaces = []i3.Workspace{
+		{Num: 1, Visible: true, Focused: true},
+		{Num: 2, Visible: true},
+	}
+
+	threeOutputs := testState(3)
+	threeOutputs.Environment.CurrentOutput = 2
+	threeOutputs.Environment.CurrentWorkspace = 2
+	threeOutputs.Target = 14
+	threeOutputs.Outputs[1].Rect.Y = -1080
+
+	var tests = []struct {
+		name       string
+		state      overlay.State
+		allOutputs bool
+	}{
+		{"single", testState(1), false},
+		{"enlarged", enlarged, false},
+		{"busy", busy, false},
+		{"two-outputs", twoOutputs, true},
+		{"three-outputs", threeOutputs, true},
+		{"three-outputs-current", threeOutputs, false},
+	}
+
+	dir, err := ioutil.TempDir("", "i3x3-overlay")
+	if err != nil {
+		t.Fatalf("error creating temporary directory: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	for _, test := range tests {
+		scene := overlay.NewScene(overlay.NewLayout(test.state, test.allOutputs), overlay.DefaultTheme())
+
+		actualPath := filepath.Join(dir, test.name+".png")
+		goldenPath := filepath.Join("testdata", test.name+".png")
+
+		err := overlay.RenderPNG(scene, actualPath)
+		if err != nil {
+			t.Fatalf("Unexpected error rendering %v: %v", test.name, err)
+		}
+
+		if *update {
+			bs, err := ioutil.ReadFile(actualPath)
+			if err != nil {
+				t.Fatalf("Unexpected error reading %v: %v", actualPath, err)
+			}
+
+			err = ioutil.WriteFile(goldenPath, bs, 0644)
+			if err != nil {
+				t.Fatalf("Unexpected error updating %v: %v", goldenPath, err)
+			}
+
+			continue
+		}
+
+		// The files themselves may differ depending on the version of cairo, so only the pixels
+		// are compared.
+		actual := decodePNG(t, actualPath)
+		expected := decodePNG(t, goldenPath)
+
+		if actual.Bounds() != expected.Bounds() {
+			t.Errorf("Expected %v to be %v, got %v", test.name, expected.Bounds(), actual.Bounds())
+			continue
+		}
+
+		if x, y, found := firstDifference(actual, expected); found {
+			t.Errorf(
+				"Expected %v to match %v, first difference at %v,%v: %v != %v",
+				test.name,
+				goldenPath,
+				x,
+				y,
+				actual.At(x, y),
+				expected.At(x, y),
+			)
+		}
+	}
+}
+
+func TestRenderPNGEmptyScene(t *testing.T) {
+	err := overlay.RenderPNG(overlay.Scene{}, filepath.Join(os.TempDir(), "i3x3-empty.png"))
+	if err == nil {
+		t.Error("Expected error rendering an empty scene")
+	}
+}
+
+// decodePNG reads the PNG image at the given path.
+func decodePNG(t *testing.T, path string) image.Image {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening %v: %v", path, err)
+	}
+
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding %v: %v", path, err)
+	}
+
+	return img
+}
+
+// firstDifference compares two images of the same size, returning the position of the first pixel
+// that differs, if there is one.
+func firstDifference(a, b image.Image) (int, int, bool) {
+	bounds := a.Bounds()
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			ar, ag, ab, aa := a.At(x, y).RGBA()
+			br, bg, bb, ba := b.At(x, y).RGBA()
+
+			if ar != br || ag != bg || ab != bb || aa != ba {
+				return x, y, true
+			}
+		}
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,332 @@
+package overlay
+
+import (
+	"image/color"
+	"strconv"
+)
+
+// Theme is the appearance of the overlay when it's rendered offscreen. The GTK overlay's built-in
+// theme is made from DefaultTheme (see CSS), so the two share their colours and padding.
+type Theme struct {
+	CellWidth      int
+	CellHeight     int
+	Spacing        int
+	GridPadding    int
+	OutputPadding  int
+	OutputSpacing  int
+	HighlightWidth int
+	// MaxWindows is the maximum number of windows marked in each cell.
+	MaxWindows int
+
+	Background      color.RGBA
+	Output          color.RGBA
+	OutputCurrent   color.RGBA
+	Grid            color.RGBA
+	Box             color.RGBA
+	BoxVisible      color.RGBA
+	BoxUrgent       color.RGBA
+	BoxActive       color.RGBA
+	Text            color.RGBA
+	TextOccupied    color.RGBA
+	TextHighlighted color.RGBA
+	Highlight       color.RGBA
+}
+
+// DefaultTheme returns the theme matching the overlay's default configuration.
+func DefaultTheme() Theme {
+	return Theme{
+		CellWidth:      50,
+		CellHeight:     50,
+		GridPadding:    3,
+		OutputPadding:  3,
+		OutputSpacing:  6,
+		HighlightWidth: 2,
+		MaxWindows:     3,
+
+		Background:      rgb(0x000000),
+		Output:          rgb(0x000000),
+		OutputCurrent:   rgb(0x3A3A3A),
+		Grid:            rgb(0x2A2A2A),
+		Box:             rgb(0x1A1A1A),
+		BoxVisible:      rgb(0x1E2A36),
+		BoxUrgent:       rgb(0x6B1A1A),
+		BoxActive:       rgb(0x2A2A2A),
+		Text:            rgb(0x5A5A5A),
+		TextOccupied:    rgb(0xD3D3D3),
+		TextHighlighted: rgb(0xFFFFFF),
+		Highlight:       rgb(0xFFFFFF),
+	}
+}
+
+// Rect is a filled rectangle in a Scene.
+type Rect struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+	Colour color.RGBA
+}
+
+// Scene is a layout turned into filled rectangles, painted in order over the background. Everything
+// is aligned to whole pixels, and workspace numbers are drawn as seven-segment digits rather than
+// with a font, so that a scene always renders to exactly the same pixels.
+type Scene struct {
+	Width      int
+	Height     int
+	Background color.RGBA
+	Rects      []Rect
+}
+
+// pageMarker is the size of the markers drawn under a grid with more than one page, one for each
+// page, in pixels.
+const pageMarker = 6
+
+// Seven-segment digit dimensions, in pixels.
+const (
+	digitWidth     = 8
+	digitHeight    = 14
+	digitThickness = 2
+	digitSpacing   = 2
+)
+
+// digitSegments are the segments lit for each digit, in the order: top, top right, bottom right,
+// bottom, bottom left, top left, middle.
+var digitSegments = [10][7]bool{
+	{true, true, true, true, true, true, false},
+	{false, true, true, false, false, false, false},
+	{true, true, false, true, true, false, true},
+	{true, true, true, true, false, false, true},
+	{false, true, true, false, false, true, true},
+	{true, false, true, true, false, true, true},
+	{true, false, true, true, true, true, true},
+	{true, true, true, false, false, false, false},
+	{true, true, true, true, true, true, true},
+	{true, true, true, true, false, true, true},
+}
+
+// NewScene draws the given layout with the given theme. Grids are arranged in the same way as the
+// overlay window arranges them, with each output's grid placed by it's column and row when there is
+// more than one.
+func NewScene(layout Layout, theme Theme) Scene {
+	scene := Scene{Background: theme.Background}
+
+	if len(layout.Grids) == 1 {
+		scene.Width, scene.Height = theme.gridSize(layout.Grids[0])
+		scene.grid(layout.Grids[0], theme, 0, 0)
+
+		return scene
+	}
+
+	// Like a GTK grid, each column is as wide as it's widest output, and each row is as tall as
+	// it's tallest output.
+	var widths, heights []int
+
+	for _, g := range layout.Grids {
+		for len(widths) <= g.Column {
+			widths = append(widths, 0)
+		}
+
+		for len(heights) <= g.Row {
+			heights = append(heights, 0)
+		}
+
+		width, height := theme.gridSize(g)
+
+		if width+(2*theme.OutputPadding) > widths[g.Column] {
+			widths[g.Column] = width + (2 * theme.OutputPadding)
+		}
+
+		if height+(2*theme.OutputPadding) > heights[g.Row] {
+			heights[g.Row] = height + (2 * theme.OutputPadding)
+		}
+	}
+
+	scene.Width = sum(widths) + theme.OutputSpacing*(len(widths)-1)
+	scene.Height = sum(heights) + theme.OutputSpacing*(len(heights)-1)
+
+	for _, g := range layout.Grids {
+		x := sum(widths[:g.Column]) + theme.OutputSpacing*g.Column
+		y := sum(heights[:g.Row]) + theme.OutputSpacing*g.Row
+
+		colour := theme.Output
+		if g.Current {
+			colour = theme.OutputCurrent
+		}
+
+		scene.rect(x, y, widths[g.Column], heights[g.Row], colour)
+		scene.grid(g, theme, x+theme.OutputPadding, y+theme.OutputPadding)
+	}
+
+	return scene
+}
+
+// gridSize returns the width and height of the given grid, including it's padding, and it's page
+// markers, if it has more than one page.
+func (t Theme) gridSize(g Grid) (int, int) {
+	width := (2 * t.GridPadding) + (g.Columns * t.CellWidth) + ((g.Columns - 1) * t.Spacing)
+	height := (2 * t.GridPadding) + (g.Rows * t.CellHeight) + ((g.Rows - 1) * t.Spacing)
+
+	if g.Pages > 1 {
+		height += pageMarker + t.GridPadding
+	}
+
+	return width, height
+}
+
+// grid draws the given grid with it's top left corner at the given position.
+func (s *Scene) grid(g Grid, theme Theme, x, y int) {
+	width, height := theme.gridSize(g)
+	s.rect(x, y, width, height, theme.Grid)
+
+	for _, cell := range g.Cells {
+		cx := x + theme.GridPadding + cell.Column*(theme.CellWidth+theme.Spacing)
+		cy := y + theme.GridPadding + cell.Row*(theme.CellHeight+theme.Spacing)
+
+		s.cell(cell, theme, cx, cy)
+	}
+
+	if g.Pages < 2 {
+		return
+	}
+
+	// Each page is marked with a square, centred along the bottom of the grid, with the page that's
+	// shown highlighted.
+	markersWidth := g.Pages*(pageMarker*2) - pageMarker
+	mx := x + (width-markersWidth)/2
+	my := y + height - theme.GridPadding - pageMarker
+
+	for page := 1; page <= g.Pages; page++ {
+		colour := theme.Text
+		if page == g.Page {
+			colour = theme.TextHighlighted
+		}
+
+		s.rect(mx+(page-1)*(pageMarker*2), my, pageMarker, pageMarker, colour)
+	}
+}
+
+// cell draws the given cell with it's top left corner at the given position. The cell's background
+// and text colour follow the same precedence as the built-in GTK theme.
+func (s *Scene) cell(cell Cell, theme Theme, x, y int) {
+	background := theme.Box
+	text := theme.Text
+
+	if cell.Occupied {
+		text = theme.TextOccupied
+	}
+
+	if cell.Visible {
+		background = theme.BoxVisible
+	}
+
+	if cell.Urgent {
+		background = theme.BoxUrgent
+		text = theme.TextHighlighted
+	}
+
+	if cell.Active {
+		background = theme.BoxActive
+		text = theme.TextHighlighted
+	}
+
+	s.rect(x, y, theme.CellWidth, theme.CellHeight, background)
+
+	digits := strconv.Itoa(cell.Workspace)
+	numberWidth := len(digits)*(digitWidth+digitSpacing) - digitSpacing
+
+	nx := x + (theme.CellWidth-numberWidth)/2
+	ny := y + (theme.CellHeight-digitHeight)/2
+
+	for i, digit := range digits {
+		if digit < '0' || digit > '9' {
+			continue
+		}
+
+		s.digit(int(digit-'0'), nx+i*(digitWidth+digitSpacing), ny, text)
+	}
+
+	// Each window is marked with a small square, centred along the bottom of the cell.
+	windows := len(cell.Windows)
+	if windows > theme.MaxWindows {
+		windows = theme.MaxWindows
+	}
+
+	if windows > 0 {
+		const marker = 4
+
+		markersWidth := windows*(marker*2) - marker
+		mx := x + (theme.CellWidth-markersWidth)/2
+		my := y + theme.CellHeight - (marker * 2)
+
+		for i := 0; i < windows; i++ {
+			s.rect(mx+i*(marker*2), my, marker, marker, text)
+		}
+	}
+
+	if cell.Active {
+		w := theme.HighlightWidth
+
+		s.rect(x, y, theme.CellWidth, w, theme.Highlight)
+		s.rect(x, y+theme.CellHeight-w, theme.CellWidth, w, theme.Highlight)
+		s.rect(x, y, w, theme.CellHeight, theme.Highlight)
+		s.rect(x+theme.CellWidth-w, y, w, theme.CellHeight, theme.Highlight)
+	}
+}
+
+// digit draws a seven-segment digit with it's top left corner at the given position.
+func (s *Scene) digit(digit, x, y int, colour color.RGBA) {
+	const (
+		w   = digitWidth
+		h   = digitHeight
+		t   = digitThickness
+		mid = (digitHeight - digitThickness) / 2
+	)
+
+	segments := [7]Rect{
+		{X: 0, Y: 0, Width: w, Height: t},
+		{X: w - t, Y: 0, Width: t, Height: mid + t},
+		{X: w - t, Y: mid, Width: t, Height: h - mid},
+		{X: 0, Y: h - t, Width: w, Height: t},
+		{X: 0, Y: mid, Width: t, Height: h - mid},
+		{X: 0, Y: 0, Width: t, Height: mid + t},
+		{X: 0, Y: mid, Width: w, Height: t},
+	}
+
+	for i, lit := range digitSegments[digit] {
+		if lit {
+			segment := segments[i]
+			s.rect(x+segment.X, y+segment.Y, segment.Width, segment.Height, colour)
+		}
+	}
+}
+
+// rect adds a filled rectangle to the scene.
+func (s *Scene) rect(x, y, width, height int, colour color.RGBA) {
+	s.Rects = append(s.Rects, Rect{
+		X:      x,
+		Y:      y,
+		Width:  width,
+		Height: height,
+		Colour: colour,
+	})
+}
+
+// rgb returns the opaque colour with the given hex value.
+func rgb(hex uint32) color.RGBA {
+	return color.RGBA{
+		R: uint8(hex >> 16),
+		G: uint8(hex >> 8),
+		B: uint8(hex),
+		A: 0xff,
+	}
+}
+
+// sum returns the sum of the given values.
+func sum(values []int) int {
+	var total int
+	for _, value := range values {
+		total += value
+	}
+
+	return total
+}
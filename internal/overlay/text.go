@@ -0,0 +1,62 @@
+package overlay
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// TerminalClear is the ANSI escape sequence that clears the terminal and moves the cursor to the
+// top left corner, for redrawing text in place.
+const TerminalClear = "\x1b[H\x1b[2J"
+
+// RenderText draws the given layout as plain text, for places that can't show graphics, like a
+// terminal or a desktop notification. Each grid is drawn as rows of workspace numbers, with the
+// active workspace in square brackets, followed by the page it's showing if the grid has more than
+// one. When there's more than one grid, each is headed by it's output's name, and the current output
+// is marked with an asterisk.
+func RenderText(layout Layout) string {
+	var buf bytes.Buffer
+
+	for i, g := range layout.Grids {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+
+		if len(layout.Grids) > 1 {
+			name := g.Name
+			if g.Current {
+				name += " *"
+			}
+
+			buf.WriteString(name + "\n")
+		}
+
+		// Every cell is as wide as the widest workspace number, so the columns line up.
+		width := 0
+		for _, cell := range g.Cells {
+			if n := len(strconv.Itoa(cell.Workspace)); n > width {
+				width = n
+			}
+		}
+
+		for _, cell := range g.Cells {
+			left, right := " ", " "
+			if cell.Active {
+				left, right = "[", "]"
+			}
+
+			fmt.Fprintf(&buf, "%s%*d%s", left, width, cell.Workspace, right)
+
+			if cell.Column == g.Columns-1 {
+				buf.WriteString("\n")
+			}
+		}
+
+		if g.Pages > 1 {
+			fmt.Fprintf(&buf, "page %d/%d\n", g.Page, g.Pages)
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,63 @@
+package overlay_test
+
+import (
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/overlay"
+)
+
+func TestRenderText(t *testing.T) {
+	state := testState(2)
+	state.Target = 11
+
+	var tests = []struct {
+		allOutputs bool
+		expected   string
+	}{
+		{
+			allOutputs: false,
+			expected: "" +
+				"  1   3   5 \n" +
+				"  7   9 [11]\n" +
+				" 13  15  17 \n",
+		},
+		{
+			allOutputs: true,
+			expected: "" +
+				"DP-1 *\n" +
+				"  1   3   5 \n" +
+				"  7   9 [11]\n" +
+				" 13  15  17 \n" +
+				"\n" +
+				"DP-2\n" +
+				"  2   4   6 \n" +
+				"  8  10  12 \n" +
+				" 14  16  18 \n",
+		},
+	}
+
+	for _, test := range tests {
+		actual := overlay.RenderText(overlay.NewLayout(state, test.allOutputs))
+		if actual != test.expected {
+			t.Errorf("Expected text to be:\n%v\ngot:\n%v", test.expected, actual)
+		}
+	}
+}
+
+func TestRenderTextPages(t *testing.T) {
+	state := testState(1)
+	state.Size = grid.Size{RealX: 3, RealY: 3, OriginalX: 3, OriginalY: 3, RealPages: 2, OriginalPages: 2}
+	state.Target = 14
+
+	expected := "" +
+		" 10  11  12 \n" +
+		" 13 [14] 15 \n" +
+		" 16  17  18 \n" +
+		"page 2/2\n"
+
+	actual := overlay.RenderText(overlay.NewLayout(state, false))
+	if actual != expected {
+		t.Errorf("Expected text to be:\n%v\ngot:\n%v", expected, actual)
+	}
+}
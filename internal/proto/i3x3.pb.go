@@ -9,6 +9,26 @@ It is generated from these files:
 
 It has these top-level messages:
 	DaemonCommand
+	SwapCommand
+	MoveWorkspaceCommand
+	LabelCommand
+	CompactCommand
+	CompactResponse
+	Rename
+	PickCommand
+	JumpCommand
+	HistoryCommand
+	BatchCommand
+	BatchStep
+	RedistributeCommand
+	ReloadCommand
+	StateRequest
+	WatchRequest
+	StateResponse
+	OutputState
+	WorkspaceState
+	VersionRequest
+	VersionResponse
 	DaemonCommandResponse
 */
 package proto
@@ -38,6 +58,8 @@ type DaemonCommand struct {
 	Direction string `protobuf:"bytes,1,opt,name=direction" json:"direction,omitempty"`
 	Move      bool   `protobuf:"varint,2,opt,name=move" json:"move,omitempty"`
 	Overlay   bool   `protobuf:"varint,3,opt,name=overlay" json:"overlay,omitempty"`
+	// move_mode selects how containers are moved (follow, stay, or all). Setting it implies move.
+	MoveMode string `protobuf:"bytes,4,opt,name=move_mode,json=moveMode" json:"move_mode,omitempty"`
 }
 
 func (m *DaemonCommand) Reset()                    { *m = DaemonCommand{} }
@@ -66,53 +88,997 @@ func (m *DaemonCommand) GetOverlay() bool {
 	return false
 }
 
+func (m *DaemonCommand) GetMoveMode() string {
+	if m != nil {
+		return m.MoveMode
+	}
+	return ""
+}
+
+// SwapCommand represents a request to swap the contents of the current workspace with another. The
+// other workspace is given either by a direction, or by it's column and row (starting from 1).
+type SwapCommand struct {
+	Direction string `protobuf:"bytes,1,opt,name=direction" json:"direction,omitempty"`
+	X         int32  `protobuf:"varint,2,opt,name=x" json:"x,omitempty"`
+	Y         int32  `protobuf:"varint,3,opt,name=y" json:"y,omitempty"`
+	Overlay   bool   `protobuf:"varint,4,opt,name=overlay" json:"overlay,omitempty"`
+}
+
+func (m *SwapCommand) Reset()                    { *m = SwapCommand{} }
+func (m *SwapCommand) String() string            { return proto1.CompactTextString(m) }
+func (*SwapCommand) ProtoMessage()               {}
+func (*SwapCommand) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *SwapCommand) GetDirection() string {
+	if m != nil {
+		return m.Direction
+	}
+	return ""
+}
+
+func (m *SwapCommand) GetX() int32 {
+	if m != nil {
+		return m.X
+	}
+	return 0
+}
+
+func (m *SwapCommand) GetY() int32 {
+	if m != nil {
+		return m.Y
+	}
+	return 0
+}
+
+func (m *SwapCommand) GetOverlay() bool {
+	if m != nil {
+		return m.Overlay
+	}
+	return false
+}
+
+// MoveWorkspaceCommand represents a request to move all containers on the current workspace to the
+// same cell on another output's grid. The output is given either by a direction, based on the
+// physical layout of the outputs, or by it's name. If follow is set, focus will move too.
+type MoveWorkspaceCommand struct {
+	Direction string `protobuf:"bytes,1,opt,name=direction" json:"direction,omitempty"`
+	Output    string `protobuf:"bytes,2,opt,name=output" json:"output,omitempty"`
+	Follow    bool   `protobuf:"varint,3,opt,name=follow" json:"follow,omitempty"`
+	Overlay   bool   `protobuf:"varint,4,opt,name=overlay" json:"overlay,omitempty"`
+}
+
+func (m *MoveWorkspaceCommand) Reset()                    { *m = MoveWorkspaceCommand{} }
+func (m *MoveWorkspaceCommand) String() string            { return proto1.CompactTextString(m) }
+func (*MoveWorkspaceCommand) ProtoMessage()               {}
+func (*MoveWorkspaceCommand) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *MoveWorkspaceCommand) GetDirection() string {
+	if m != nil {
+		return m.Direction
+	}
+	return ""
+}
+
+func (m *MoveWorkspaceCommand) GetOutput() string {
+	if m != nil {
+		return m.Output
+	}
+	return ""
+}
+
+func (m *MoveWorkspaceCommand) GetFollow() bool {
+	if m != nil {
+		return m.Follow
+	}
+	return false
+}
+
+func (m *MoveWorkspaceCommand) GetOverlay() bool {
+	if m != nil {
+		return m.Overlay
+	}
+	return false
+}
+
+// LabelCommand represents a request to set the label of the current workspace. An empty label
+// removes the current workspace's label.
+type LabelCommand struct {
+	Label string `protobuf:"bytes,1,opt,name=label" json:"label,omitempty"`
+}
+
+func (m *LabelCommand) Reset()                    { *m = LabelCommand{} }
+func (m *LabelCommand) String() string            { return proto1.CompactTextString(m) }
+func (*LabelCommand) ProtoMessage()               {}
+func (*LabelCommand) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *LabelCommand) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+// CompactCommand represents a request to renumber workspaces so that they fill each output's grid
+// in reading order. If shrink is set, workspaces that don't fit in the configured grid size have
+// their containers merged into the last cell. If dry_run is set, nothing is changed.
+type CompactCommand struct {
+	Shrink bool `protobuf:"varint,1,opt,name=shrink" json:"shrink,omitempty"`
+	DryRun bool `protobuf:"varint,2,opt,name=dry_run,json=dryRun" json:"dry_run,omitempty"`
+}
+
+func (m *CompactCommand) Reset()                    { *m = CompactCommand{} }
+func (m *CompactCommand) String() string            { return proto1.CompactTextString(m) }
+func (*CompactCommand) ProtoMessage()               {}
+func (*CompactCommand) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *CompactCommand) GetShrink() bool {
+	if m != nil {
+		return m.Shrink
+	}
+	return false
+}
+
+func (m *CompactCommand) GetDryRun() bool {
+	if m != nil {
+		return m.DryRun
+	}
+	return false
+}
+
+// CompactResponse lists the renames made by a CompactCommand.
+type CompactResponse struct {
+	Renames []*Rename `protobuf:"bytes,1,rep,name=renames" json:"renames,omitempty"`
+}
+
+func (m *CompactResponse) Reset()                    { *m = CompactResponse{} }
+func (m *CompactResponse) String() string            { return proto1.CompactTextString(m) }
+func (*CompactResponse) ProtoMessage()               {}
+func (*CompactResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *CompactResponse) GetRenames() []*Rename {
+	if m != nil {
+		return m.Renames
+	}
+	return nil
+}
+
+// Rename represents a workspace being renumbered. If merge is set, the workspace's containers are
+// moved to the existing target workspace instead.
+type Rename struct {
+	From  int32 `protobuf:"varint,1,opt,name=from" json:"from,omitempty"`
+	To    int32 `protobuf:"varint,2,opt,name=to" json:"to,omitempty"`
+	Merge bool  `protobuf:"varint,3,opt,name=merge" json:"merge,omitempty"`
+}
+
+func (m *Rename) Reset()                    { *m = Rename{} }
+func (m *Rename) String() string            { return proto1.CompactTextString(m) }
+func (*Rename) ProtoMessage()               {}
+func (*Rename) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *Rename) GetFrom() int32 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *Rename) GetTo() int32 {
+	if m != nil {
+		return m.To
+	}
+	return 0
+}
+
+func (m *Rename) GetMerge() bool {
+	if m != nil {
+		return m.Merge
+	}
+	return false
+}
+
+// PickCommand represents a request to interactively pick a workspace from the overlay. If the
+// pick is made with the move modifier held, containers are moved using move_mode (which defaults to
+// follow).
+type PickCommand struct {
+	MoveMode string `protobuf:"bytes,1,opt,name=move_mode,json=moveMode" json:"move_mode,omitempty"`
+}
+
+func (m *PickCommand) Reset()                    { *m = PickCommand{} }
+func (m *PickCommand) String() string            { return proto1.CompactTextString(m) }
+func (*PickCommand) ProtoMessage()               {}
+func (*PickCommand) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *PickCommand) GetMoveMode() string {
+	if m != nil {
+		return m.MoveMode
+	}
+	return ""
+}
+
+// JumpCommand represents a request to switch straight to a workspace, given either by it's number,
+// or by it's column and row on the current output's grid (starting from 1). If a page is given, the
+// cell is on that page, or if no cell is given, it's the current workspace's cell. Containers are
+// moved in the same way as a DaemonCommand.
+type JumpCommand struct {
+	Workspace int32  `protobuf:"varint,1,opt,name=workspace" json:"workspace,omitempty"`
+	X         int32  `protobuf:"varint,2,opt,name=x" json:"x,omitempty"`
+	Y         int32  `protobuf:"varint,3,opt,name=y" json:"y,omitempty"`
+	Move      bool   `protobuf:"varint,4,opt,name=move" json:"move,omitempty"`
+	MoveMode  string `protobuf:"bytes,5,opt,name=move_mode,json=moveMode" json:"move_mode,omitempty"`
+	Overlay   bool   `protobuf:"varint,6,opt,name=overlay" json:"overlay,omitempty"`
+	Page      int32  `protobuf:"varint,7,opt,name=page" json:"page,omitempty"`
+}
+
+func (m *JumpCommand) Reset()                    { *m = JumpCommand{} }
+func (m *JumpCommand) String() string            { return proto1.CompactTextString(m) }
+func (*JumpCommand) ProtoMessage()               {}
+func (*JumpCommand) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *JumpCommand) GetWorkspace() int32 {
+	if m != nil {
+		return m.Workspace
+	}
+	return 0
+}
+
+func (m *JumpCommand) GetX() int32 {
+	if m != nil {
+		return m.X
+	}
+	return 0
+}
+
+func (m *JumpCommand) GetY() int32 {
+	if m != nil {
+		return m.Y
+	}
+	return 0
+}
+
+func (m *JumpCommand) GetMove() bool {
+	if m != nil {
+		return m.Move
+	}
+	return false
+}
+
+func (m *JumpCommand) GetMoveMode() string {
+	if m != nil {
+		return m.MoveMode
+	}
+	return ""
+}
+
+func (m *JumpCommand) GetOverlay() bool {
+	if m != nil {
+		return m.Overlay
+	}
+	return false
+}
+
+func (m *JumpCommand) GetPage() int32 {
+	if m != nil {
+		return m.Page
+	}
+	return 0
+}
+
+// HistoryCommand represents a request to go back, or forward, through the workspaces that have been
+// switched to.
+type HistoryCommand struct {
+	Forward bool `protobuf:"varint,1,opt,name=forward" json:"forward,omitempty"`
+	Overlay bool `protobuf:"varint,2,opt,name=overlay" json:"overlay,omitempty"`
+}
+
+func (m *HistoryCommand) Reset()                    { *m = HistoryCommand{} }
+func (m *HistoryCommand) String() string            { return proto1.CompactTextString(m) }
+func (*HistoryCommand) ProtoMessage()               {}
+func (*HistoryCommand) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *HistoryCommand) GetForward() bool {
+	if m != nil {
+		return m.Forward
+	}
+	return false
+}
+
+func (m *HistoryCommand) GetOverlay() bool {
+	if m != nil {
+		return m.Overlay
+	}
+	return false
+}
+
+// BatchCommand represents a request to run several commands in order, without any other commands
+// running in between them. The batch stops at the first command that fails. The overlay is shown
+// once, after the last command, if it's enabled for the batch; it's ignored on each command.
+type BatchCommand struct {
+	Steps   []*BatchStep `protobuf:"bytes,1,rep,name=steps" json:"steps,omitempty"`
+	Overlay bool         `protobuf:"varint,2,opt,name=overlay" json:"overlay,omitempty"`
+}
+
+func (m *BatchCommand) Reset()                    { *m = BatchCommand{} }
+func (m *BatchCommand) String() string            { return proto1.CompactTextString(m) }
+func (*BatchCommand) ProtoMessage()               {}
+func (*BatchCommand) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *BatchCommand) GetSteps() []*BatchStep {
+	if m != nil {
+		return m.Steps
+	}
+	return nil
+}
+
+func (m *BatchCommand) GetOverlay() bool {
+	if m != nil {
+		return m.Overlay
+	}
+	return false
+}
+
+// BatchStep is a single command in a batch.
+type BatchStep struct {
+	// Types that are valid to be assigned to Command:
+	//	*BatchStep_DaemonCommand
+	//	*BatchStep_Jump
+	//	*BatchStep_History
+	Command isBatchStep_Command `protobuf_oneof:"command"`
+}
+
+func (m *BatchStep) Reset()                    { *m = BatchStep{} }
+func (m *BatchStep) String() string            { return proto1.CompactTextString(m) }
+func (*BatchStep) ProtoMessage()               {}
+func (*BatchStep) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+type isBatchStep_Command interface{ isBatchStep_Command() }
+
+type BatchStep_DaemonCommand struct {
+	DaemonCommand *DaemonCommand `protobuf:"bytes,1,opt,name=daemon_command,json=daemonCommand,oneof"`
+}
+type BatchStep_Jump struct {
+	Jump *JumpCommand `protobuf:"bytes,2,opt,name=jump,oneof"`
+}
+type BatchStep_History struct {
+	History *HistoryCommand `protobuf:"bytes,3,opt,name=history,oneof"`
+}
+
+func (*BatchStep_DaemonCommand) isBatchStep_Command() {}
+func (*BatchStep_Jump) isBatchStep_Command()          {}
+func (*BatchStep_History) isBatchStep_Command()       {}
+
+func (m *BatchStep) GetCommand() isBatchStep_Command {
+	if m != nil {
+		return m.Command
+	}
+	return nil
+}
+
+func (m *BatchStep) GetDaemonCommand() *DaemonCommand {
+	if x, ok := m.GetCommand().(*BatchStep_DaemonCommand); ok {
+		return x.DaemonCommand
+	}
+	return nil
+}
+
+func (m *BatchStep) GetJump() *JumpCommand {
+	if x, ok := m.GetCommand().(*BatchStep_Jump); ok {
+		return x.Jump
+	}
+	return nil
+}
+
+func (m *BatchStep) GetHistory() *HistoryCommand {
+	if x, ok := m.GetCommand().(*BatchStep_History); ok {
+		return x.History
+	}
+	return nil
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*BatchStep) XXX_OneofFuncs() (func(msg proto1.Message, b *proto1.Buffer) error, func(msg proto1.Message, tag, wire int, b *proto1.Buffer) (bool, error), func(msg proto1.Message) (n int), []interface{}) {
+	return _BatchStep_OneofMarshaler, _BatchStep_OneofUnmarshaler, _BatchStep_OneofSizer, []interface{}{
+		(*BatchStep_DaemonCommand)(nil),
+		(*BatchStep_Jump)(nil),
+		(*BatchStep_History)(nil),
+	}
+}
+
+func _BatchStep_OneofMarshaler(msg proto1.Message, b *proto1.Buffer) error {
+	m := msg.(*BatchStep)
+	// command
+	switch x := m.Command.(type) {
+	case *BatchStep_DaemonCommand:
+		b.EncodeVarint(1<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.DaemonCommand); err != nil {
+			return err
+		}
+	case *BatchStep_Jump:
+		b.EncodeVarint(2<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.Jump); err != nil {
+			return err
+		}
+	case *BatchStep_History:
+		b.EncodeVarint(3<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.History); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		return fmt.Errorf("BatchStep.Command has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _BatchStep_OneofUnmarshaler(msg proto1.Message, tag, wire int, b *proto1.Buffer) (bool, error) {
+	m := msg.(*BatchStep)
+	switch tag {
+	case 1: // command.daemon_command
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(DaemonCommand)
+		err := b.DecodeMessage(msg)
+		m.Command = &BatchStep_DaemonCommand{msg}
+		return true, err
+	case 2: // command.jump
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(JumpCommand)
+		err := b.DecodeMessage(msg)
+		m.Command = &BatchStep_Jump{msg}
+		return true, err
+	case 3: // command.history
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(HistoryCommand)
+		err := b.DecodeMessage(msg)
+		m.Command = &BatchStep_History{msg}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _BatchStep_OneofSizer(msg proto1.Message) (n int) {
+	m := msg.(*BatchStep)
+	// command
+	switch x := m.Command.(type) {
+	case *BatchStep_DaemonCommand:
+		s := proto1.Size(x.DaemonCommand)
+		n += proto1.SizeVarint(1<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *BatchStep_Jump:
+		s := proto1.Size(x.Jump)
+		n += proto1.SizeVarint(2<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *BatchStep_History:
+		s := proto1.Size(x.History)
+		n += proto1.SizeVarint(3<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+// RedistributeCommand represents a request to move every workspace to the output it belongs on
+// straight away, instead of waiting for the next automatic redistribution.
+type RedistributeCommand struct {
+}
+
+func (m *RedistributeCommand) Reset()                    { *m = RedistributeCommand{} }
+func (m *RedistributeCommand) String() string            { return proto1.CompactTextString(m) }
+func (*RedistributeCommand) ProtoMessage()               {}
+func (*RedistributeCommand) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+// ReloadCommand represents a request to reload the workspace labels from disk, and apply them.
+type ReloadCommand struct {
+}
+
+func (m *ReloadCommand) Reset()                    { *m = ReloadCommand{} }
+func (m *ReloadCommand) String() string            { return proto1.CompactTextString(m) }
+func (*ReloadCommand) ProtoMessage()               {}
+func (*ReloadCommand) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+// StateRequest represents a request for the current state of the grid.
+type StateRequest struct {
+}
+
+func (m *StateRequest) Reset()                    { *m = StateRequest{} }
+func (m *StateRequest) String() string            { return proto1.CompactTextString(m) }
+func (*StateRequest) ProtoMessage()               {}
+func (*StateRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+// WatchRequest represents a request to be sent the state of the grid each time it changes.
+type WatchRequest struct {
+}
+
+func (m *WatchRequest) Reset()                    { *m = WatchRequest{} }
+func (m *WatchRequest) String() string            { return proto1.CompactTextString(m) }
+func (*WatchRequest) ProtoMessage()               {}
+func (*WatchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+// StateResponse describes the grid, and the workspaces on it.
+type StateResponse struct {
+	Columns          int32             `protobuf:"varint,1,opt,name=columns" json:"columns,omitempty"`
+	Rows             int32             `protobuf:"varint,2,opt,name=rows" json:"rows,omitempty"`
+	CurrentOutput    int32             `protobuf:"varint,3,opt,name=current_output,json=currentOutput" json:"current_output,omitempty"`
+	CurrentWorkspace int32             `protobuf:"varint,4,opt,name=current_workspace,json=currentWorkspace" json:"current_workspace,omitempty"`
+	Outputs          []*OutputState    `protobuf:"bytes,5,rep,name=outputs" json:"outputs,omitempty"`
+	Workspaces       []*WorkspaceState `protobuf:"bytes,6,rep,name=workspaces" json:"workspaces,omitempty"`
+	// grid is the current page of the current output's grid, drawn as text.
+	Grid        string `protobuf:"bytes,7,opt,name=grid" json:"grid,omitempty"`
+	Pages       int32  `protobuf:"varint,8,opt,name=pages" json:"pages,omitempty"`
+	CurrentPage int32  `protobuf:"varint,9,opt,name=current_page,json=currentPage" json:"current_page,omitempty"`
+}
+
+func (m *StateResponse) Reset()                    { *m = StateResponse{} }
+func (m *StateResponse) String() string            { return proto1.CompactTextString(m) }
+func (*StateResponse) ProtoMessage()               {}
+func (*StateResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *StateResponse) GetColumns() int32 {
+	if m != nil {
+		return m.Columns
+	}
+	return 0
+}
+
+func (m *StateResponse) GetRows() int32 {
+	if m != nil {
+		return m.Rows
+	}
+	return 0
+}
+
+func (m *StateResponse) GetCurrentOutput() int32 {
+	if m != nil {
+		return m.CurrentOutput
+	}
+	return 0
+}
+
+func (m *StateResponse) GetCurrentWorkspace() int32 {
+	if m != nil {
+		return m.CurrentWorkspace
+	}
+	return 0
+}
+
+func (m *StateResponse) GetOutputs() []*OutputState {
+	if m != nil {
+		return m.Outputs
+	}
+	return nil
+}
+
+func (m *StateResponse) GetWorkspaces() []*WorkspaceState {
+	if m != nil {
+		return m.Workspaces
+	}
+	return nil
+}
+
+func (m *StateResponse) GetGrid() string {
+	if m != nil {
+		return m.Grid
+	}
+	return ""
+}
+
+func (m *StateResponse) GetPages() int32 {
+	if m != nil {
+		return m.Pages
+	}
+	return 0
+}
+
+func (m *StateResponse) GetCurrentPage() int32 {
+	if m != nil {
+		return m.CurrentPage
+	}
+	return 0
+}
+
+// OutputState describes an active output. Outputs are numbered from 1, in the order they're used
+// on the grid.
+type OutputState struct {
+	Number  int32  `protobuf:"varint,1,opt,name=number" json:"number,omitempty"`
+	Name    string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	Primary bool   `protobuf:"varint,3,opt,name=primary" json:"primary,omitempty"`
+}
+
+func (m *OutputState) Reset()                    { *m = OutputState{} }
+func (m *OutputState) String() string            { return proto1.CompactTextString(m) }
+func (*OutputState) ProtoMessage()               {}
+func (*OutputState) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *OutputState) GetNumber() int32 {
+	if m != nil {
+		return m.Number
+	}
+	return 0
+}
+
+func (m *OutputState) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *OutputState) GetPrimary() bool {
+	if m != nil {
+		return m.Primary
+	}
+	return false
+}
+
+// WorkspaceState describes a workspace that exists in i3, and where it is on it's output's grid.
+type WorkspaceState struct {
+	Num     int32  `protobuf:"varint,1,opt,name=num" json:"num,omitempty"`
+	Name    string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	Label   string `protobuf:"bytes,3,opt,name=label" json:"label,omitempty"`
+	Output  string `protobuf:"bytes,4,opt,name=output" json:"output,omitempty"`
+	X       int32  `protobuf:"varint,5,opt,name=x" json:"x,omitempty"`
+	Y       int32  `protobuf:"varint,6,opt,name=y" json:"y,omitempty"`
+	Focused bool   `protobuf:"varint,7,opt,name=focused" json:"focused,omitempty"`
+	Visible bool   `protobuf:"varint,8,opt,name=visible" json:"visible,omitempty"`
+	Urgent  bool   `protobuf:"varint,9,opt,name=urgent" json:"urgent,omitempty"`
+	Page    int32  `protobuf:"varint,10,opt,name=page" json:"page,omitempty"`
+}
+
+func (m *WorkspaceState) Reset()                    { *m = WorkspaceState{} }
+func (m *WorkspaceState) String() string            { return proto1.CompactTextString(m) }
+func (*WorkspaceState) ProtoMessage()               {}
+func (*WorkspaceState) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *WorkspaceState) GetNum() int32 {
+	if m != nil {
+		return m.Num
+	}
+	return 0
+}
+
+func (m *WorkspaceState) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *WorkspaceState) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+func (m *WorkspaceState) GetOutput() string {
+	if m != nil {
+		return m.Output
+	}
+	return ""
+}
+
+func (m *WorkspaceState) GetX() int32 {
+	if m != nil {
+		return m.X
+	}
+	return 0
+}
+
+func (m *WorkspaceState) GetY() int32 {
+	if m != nil {
+		return m.Y
+	}
+	return 0
+}
+
+func (m *WorkspaceState) GetFocused() bool {
+	if m != nil {
+		return m.Focused
+	}
+	return false
+}
+
+func (m *WorkspaceState) GetVisible() bool {
+	if m != nil {
+		return m.Visible
+	}
+	return false
+}
+
+func (m *WorkspaceState) GetUrgent() bool {
+	if m != nil {
+		return m.Urgent
+	}
+	return false
+}
+
+func (m *WorkspaceState) GetPage() int32 {
+	if m != nil {
+		return m.Page
+	}
+	return 0
+}
+
+// VersionRequest represents a request for the daemon's version, and the features it supports.
+type VersionRequest struct {
+}
+
+func (m *VersionRequest) Reset()                    { *m = VersionRequest{} }
+func (m *VersionRequest) String() string            { return proto1.CompactTextString(m) }
+func (*VersionRequest) ProtoMessage()               {}
+func (*VersionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+// VersionResponse describes the daemon's build, and the features it supports.
+type VersionResponse struct {
+	Version  string   `protobuf:"bytes,1,opt,name=version" json:"version,omitempty"`
+	Commit   string   `protobuf:"bytes,2,opt,name=commit" json:"commit,omitempty"`
+	Date     string   `protobuf:"bytes,3,opt,name=date" json:"date,omitempty"`
+	Features []string `protobuf:"bytes,4,rep,name=features" json:"features,omitempty"`
+}
+
+func (m *VersionResponse) Reset()                    { *m = VersionResponse{} }
+func (m *VersionResponse) String() string            { return proto1.CompactTextString(m) }
+func (*VersionResponse) ProtoMessage()               {}
+func (*VersionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *VersionResponse) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *VersionResponse) GetCommit() string {
+	if m != nil {
+		return m.Commit
+	}
+	return ""
+}
+
+func (m *VersionResponse) GetDate() string {
+	if m != nil {
+		return m.Date
+	}
+	return ""
+}
+
+func (m *VersionResponse) GetFeatures() []string {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
 // DaemonCommandResponse represents the result of a command for i3x3overlayd.
 type DaemonCommandResponse struct {
 	Message string `protobuf:"bytes,1,opt,name=message" json:"message,omitempty"`
 }
 
-func (m *DaemonCommandResponse) Reset()                    { *m = DaemonCommandResponse{} }
-func (m *DaemonCommandResponse) String() string            { return proto1.CompactTextString(m) }
-func (*DaemonCommandResponse) ProtoMessage()               {}
-func (*DaemonCommandResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (m *DaemonCommandResponse) Reset()                    { *m = DaemonCommandResponse{} }
+func (m *DaemonCommandResponse) String() string            { return proto1.CompactTextString(m) }
+func (*DaemonCommandResponse) ProtoMessage()               {}
+func (*DaemonCommandResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *DaemonCommandResponse) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func init() {
+	proto1.RegisterType((*DaemonCommand)(nil), "proto.DaemonCommand")
+	proto1.RegisterType((*SwapCommand)(nil), "proto.SwapCommand")
+	proto1.RegisterType((*MoveWorkspaceCommand)(nil), "proto.MoveWorkspaceCommand")
+	proto1.RegisterType((*LabelCommand)(nil), "proto.LabelCommand")
+	proto1.RegisterType((*CompactCommand)(nil), "proto.CompactCommand")
+	proto1.RegisterType((*CompactResponse)(nil), "proto.CompactResponse")
+	proto1.RegisterType((*Rename)(nil), "proto.Rename")
+	proto1.RegisterType((*PickCommand)(nil), "proto.PickCommand")
+	proto1.RegisterType((*JumpCommand)(nil), "proto.JumpCommand")
+	proto1.RegisterType((*HistoryCommand)(nil), "proto.HistoryCommand")
+	proto1.RegisterType((*BatchCommand)(nil), "proto.BatchCommand")
+	proto1.RegisterType((*BatchStep)(nil), "proto.BatchStep")
+	proto1.RegisterType((*RedistributeCommand)(nil), "proto.RedistributeCommand")
+	proto1.RegisterType((*ReloadCommand)(nil), "proto.ReloadCommand")
+	proto1.RegisterType((*StateRequest)(nil), "proto.StateRequest")
+	proto1.RegisterType((*WatchRequest)(nil), "proto.WatchRequest")
+	proto1.RegisterType((*StateResponse)(nil), "proto.StateResponse")
+	proto1.RegisterType((*OutputState)(nil), "proto.OutputState")
+	proto1.RegisterType((*WorkspaceState)(nil), "proto.WorkspaceState")
+	proto1.RegisterType((*VersionRequest)(nil), "proto.VersionRequest")
+	proto1.RegisterType((*VersionResponse)(nil), "proto.VersionResponse")
+	proto1.RegisterType((*DaemonCommandResponse)(nil), "proto.DaemonCommandResponse")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for DaemonService service
+
+type DaemonServiceClient interface {
+	HandleCommand(ctx context.Context, in *DaemonCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error)
+	Swap(ctx context.Context, in *SwapCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error)
+	Compact(ctx context.Context, in *CompactCommand, opts ...grpc.CallOption) (*CompactResponse, error)
+	MoveWorkspace(ctx context.Context, in *MoveWorkspaceCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error)
+	Label(ctx context.Context, in *LabelCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error)
+	Pick(ctx context.Context, in *PickCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error)
+	Jump(ctx context.Context, in *JumpCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error)
+	History(ctx context.Context, in *HistoryCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error)
+	Batch(ctx context.Context, in *BatchCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error)
+	Redistribute(ctx context.Context, in *RedistributeCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error)
+	Reload(ctx context.Context, in *ReloadCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error)
+	State(ctx context.Context, in *StateRequest, opts ...grpc.CallOption) (*StateResponse, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (DaemonService_WatchClient, error)
+	Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error)
+}
+
+type daemonServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewDaemonServiceClient(cc *grpc.ClientConn) DaemonServiceClient {
+	return &daemonServiceClient{cc}
+}
+
+func (c *daemonServiceClient) HandleCommand(ctx context.Context, in *DaemonCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error) {
+	out := new(DaemonCommandResponse)
+	err := grpc.Invoke(ctx, "/proto.DaemonService/HandleCommand", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) Swap(ctx context.Context, in *SwapCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error) {
+	out := new(DaemonCommandResponse)
+	err := grpc.Invoke(ctx, "/proto.DaemonService/Swap", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) Compact(ctx context.Context, in *CompactCommand, opts ...grpc.CallOption) (*CompactResponse, error) {
+	out := new(CompactResponse)
+	err := grpc.Invoke(ctx, "/proto.DaemonService/Compact", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) MoveWorkspace(ctx context.Context, in *MoveWorkspaceCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error) {
+	out := new(DaemonCommandResponse)
+	err := grpc.Invoke(ctx, "/proto.DaemonService/MoveWorkspace", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) Label(ctx context.Context, in *LabelCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error) {
+	out := new(DaemonCommandResponse)
+	err := grpc.Invoke(ctx, "/proto.DaemonService/Label", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) Pick(ctx context.Context, in *PickCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error) {
+	out := new(DaemonCommandResponse)
+	err := grpc.Invoke(ctx, "/proto.DaemonService/Pick", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) Jump(ctx context.Context, in *JumpCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error) {
+	out := new(DaemonCommandResponse)
+	err := grpc.Invoke(ctx, "/proto.DaemonService/Jump", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) History(ctx context.Context, in *HistoryCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error) {
+	out := new(DaemonCommandResponse)
+	err := grpc.Invoke(ctx, "/proto.DaemonService/History", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-func (m *DaemonCommandResponse) GetMessage() string {
-	if m != nil {
-		return m.Message
+func (c *daemonServiceClient) Batch(ctx context.Context, in *BatchCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error) {
+	out := new(DaemonCommandResponse)
+	err := grpc.Invoke(ctx, "/proto.DaemonService/Batch", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return ""
+	return out, nil
 }
 
-func init() {
-	proto1.RegisterType((*DaemonCommand)(nil), "proto.DaemonCommand")
-	proto1.RegisterType((*DaemonCommandResponse)(nil), "proto.DaemonCommandResponse")
+func (c *daemonServiceClient) Redistribute(ctx context.Context, in *RedistributeCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error) {
+	out := new(DaemonCommandResponse)
+	err := grpc.Invoke(ctx, "/proto.DaemonService/Redistribute", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+func (c *daemonServiceClient) Reload(ctx context.Context, in *ReloadCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error) {
+	out := new(DaemonCommandResponse)
+	err := grpc.Invoke(ctx, "/proto.DaemonService/Reload", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (c *daemonServiceClient) State(ctx context.Context, in *StateRequest, opts ...grpc.CallOption) (*StateResponse, error) {
+	out := new(StateResponse)
+	err := grpc.Invoke(ctx, "/proto.DaemonService/State", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-// Client API for DaemonService service
+func (c *daemonServiceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (DaemonService_WatchClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_DaemonService_serviceDesc.Streams[0], c.cc, "/proto.DaemonService/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daemonServiceWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
 
-type DaemonServiceClient interface {
-	HandleCommand(ctx context.Context, in *DaemonCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error)
+type DaemonService_WatchClient interface {
+	Recv() (*StateResponse, error)
+	grpc.ClientStream
 }
 
-type daemonServiceClient struct {
-	cc *grpc.ClientConn
+type daemonServiceWatchClient struct {
+	grpc.ClientStream
 }
 
-func NewDaemonServiceClient(cc *grpc.ClientConn) DaemonServiceClient {
-	return &daemonServiceClient{cc}
+func (x *daemonServiceWatchClient) Recv() (*StateResponse, error) {
+	m := new(StateResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
-func (c *daemonServiceClient) HandleCommand(ctx context.Context, in *DaemonCommand, opts ...grpc.CallOption) (*DaemonCommandResponse, error) {
-	out := new(DaemonCommandResponse)
-	err := grpc.Invoke(ctx, "/proto.DaemonService/HandleCommand", in, out, c.cc, opts...)
+func (c *daemonServiceClient) Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error) {
+	out := new(VersionResponse)
+	err := grpc.Invoke(ctx, "/proto.DaemonService/Version", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -123,6 +1089,19 @@ func (c *daemonServiceClient) HandleCommand(ctx context.Context, in *DaemonComma
 
 type DaemonServiceServer interface {
 	HandleCommand(context.Context, *DaemonCommand) (*DaemonCommandResponse, error)
+	Swap(context.Context, *SwapCommand) (*DaemonCommandResponse, error)
+	Compact(context.Context, *CompactCommand) (*CompactResponse, error)
+	MoveWorkspace(context.Context, *MoveWorkspaceCommand) (*DaemonCommandResponse, error)
+	Label(context.Context, *LabelCommand) (*DaemonCommandResponse, error)
+	Pick(context.Context, *PickCommand) (*DaemonCommandResponse, error)
+	Jump(context.Context, *JumpCommand) (*DaemonCommandResponse, error)
+	History(context.Context, *HistoryCommand) (*DaemonCommandResponse, error)
+	Batch(context.Context, *BatchCommand) (*DaemonCommandResponse, error)
+	Redistribute(context.Context, *RedistributeCommand) (*DaemonCommandResponse, error)
+	Reload(context.Context, *ReloadCommand) (*DaemonCommandResponse, error)
+	State(context.Context, *StateRequest) (*StateResponse, error)
+	Watch(*WatchRequest, DaemonService_WatchServer) error
+	Version(context.Context, *VersionRequest) (*VersionResponse, error)
 }
 
 func RegisterDaemonServiceServer(s *grpc.Server, srv DaemonServiceServer) {
@@ -147,6 +1126,243 @@ func _DaemonService_HandleCommand_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DaemonService_Swap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SwapCommand)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Swap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DaemonService/Swap",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Swap(ctx, req.(*SwapCommand))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Compact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompactCommand)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Compact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DaemonService/Compact",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Compact(ctx, req.(*CompactCommand))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_MoveWorkspace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveWorkspaceCommand)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).MoveWorkspace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DaemonService/MoveWorkspace",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).MoveWorkspace(ctx, req.(*MoveWorkspaceCommand))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Label_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LabelCommand)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Label(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DaemonService/Label",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Label(ctx, req.(*LabelCommand))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Pick_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PickCommand)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Pick(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DaemonService/Pick",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Pick(ctx, req.(*PickCommand))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Jump_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JumpCommand)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Jump(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DaemonService/Jump",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Jump(ctx, req.(*JumpCommand))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_History_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryCommand)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).History(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DaemonService/History",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).History(ctx, req.(*HistoryCommand))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Batch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCommand)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Batch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DaemonService/Batch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Batch(ctx, req.(*BatchCommand))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Redistribute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RedistributeCommand)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Redistribute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DaemonService/Redistribute",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Redistribute(ctx, req.(*RedistributeCommand))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Reload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadCommand)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Reload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DaemonService/Reload",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Reload(ctx, req.(*ReloadCommand))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_State_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).State(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DaemonService/State",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).State(ctx, req.(*StateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServiceServer).Watch(m, &daemonServiceWatchServer{stream})
+}
+
+type DaemonService_WatchServer interface {
+	Send(*StateResponse) error
+	grpc.ServerStream
+}
+
+type daemonServiceWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *daemonServiceWatchServer) Send(m *StateResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DaemonService_Version_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Version(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DaemonService/Version",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Version(ctx, req.(*VersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _DaemonService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "proto.DaemonService",
 	HandlerType: (*DaemonServiceServer)(nil),
@@ -155,25 +1371,135 @@ var _DaemonService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "HandleCommand",
 			Handler:    _DaemonService_HandleCommand_Handler,
 		},
+		{
+			MethodName: "Swap",
+			Handler:    _DaemonService_Swap_Handler,
+		},
+		{
+			MethodName: "Compact",
+			Handler:    _DaemonService_Compact_Handler,
+		},
+		{
+			MethodName: "MoveWorkspace",
+			Handler:    _DaemonService_MoveWorkspace_Handler,
+		},
+		{
+			MethodName: "Label",
+			Handler:    _DaemonService_Label_Handler,
+		},
+		{
+			MethodName: "Pick",
+			Handler:    _DaemonService_Pick_Handler,
+		},
+		{
+			MethodName: "Jump",
+			Handler:    _DaemonService_Jump_Handler,
+		},
+		{
+			MethodName: "History",
+			Handler:    _DaemonService_History_Handler,
+		},
+		{
+			MethodName: "Batch",
+			Handler:    _DaemonService_Batch_Handler,
+		},
+		{
+			MethodName: "Redistribute",
+			Handler:    _DaemonService_Redistribute_Handler,
+		},
+		{
+			MethodName: "Reload",
+			Handler:    _DaemonService_Reload_Handler,
+		},
+		{
+			MethodName: "State",
+			Handler:    _DaemonService_State_Handler,
+		},
+		{
+			MethodName: "Version",
+			Handler:    _DaemonService_Version_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _DaemonService_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "i3x3.proto",
 }
 
 func init() { proto1.RegisterFile("i3x3.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 179 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0xca, 0x34, 0xae, 0x30,
-	0xd6, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x05, 0x53, 0x4a, 0xd1, 0x5c, 0xbc, 0x2e, 0x89,
-	0xa9, 0xb9, 0xf9, 0x79, 0xce, 0xf9, 0xb9, 0xb9, 0x89, 0x79, 0x29, 0x42, 0x32, 0x5c, 0x9c, 0x29,
-	0x99, 0x45, 0xa9, 0xc9, 0x25, 0x99, 0xf9, 0x79, 0x12, 0x8c, 0x0a, 0x8c, 0x1a, 0x9c, 0x41, 0x08,
-	0x01, 0x21, 0x21, 0x2e, 0x96, 0xdc, 0xfc, 0xb2, 0x54, 0x09, 0x26, 0x05, 0x46, 0x0d, 0x8e, 0x20,
-	0x30, 0x5b, 0x48, 0x82, 0x8b, 0x3d, 0xbf, 0x2c, 0xb5, 0x28, 0x27, 0xb1, 0x52, 0x82, 0x19, 0x2c,
-	0x0c, 0xe3, 0x2a, 0x19, 0x72, 0x89, 0xa2, 0x18, 0x1e, 0x94, 0x5a, 0x5c, 0x90, 0x9f, 0x57, 0x0c,
-	0xd6, 0x92, 0x9b, 0x5a, 0x5c, 0x9c, 0x98, 0x9e, 0x0a, 0xb5, 0x02, 0xc6, 0x35, 0x0a, 0x81, 0xb9,
-	0x27, 0x38, 0xb5, 0xa8, 0x2c, 0x33, 0x39, 0x55, 0xc8, 0x99, 0x8b, 0xd7, 0x23, 0x31, 0x2f, 0x25,
-	0x27, 0x15, 0xe6, 0x40, 0x11, 0x88, 0x07, 0xf4, 0x50, 0x4c, 0x96, 0x92, 0xc1, 0x26, 0x0a, 0xb3,
-	0x2f, 0x89, 0x0d, 0x2c, 0x69, 0x0c, 0x08, 0x00, 0x00, 0xff, 0xff, 0x79, 0xee, 0x12, 0xd6, 0x01,
-	0x01, 0x00, 0x00,
+	// 1077 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x56, 0xdb, 0x6e, 0x23, 0x45,
+	0x13, 0xce, 0xc4, 0x73, 0xb0, 0xcb, 0x87, 0xe4, 0xef, 0x4d, 0xf2, 0x8f, 0xbc, 0x7b, 0x61, 0x46,
+	0x1c, 0x22, 0x40, 0x2b, 0xd6, 0x01, 0x09, 0xad, 0x00, 0x89, 0x64, 0x2f, 0xac, 0x15, 0x2b, 0xa2,
+	0x8e, 0x44, 0x2e, 0xa3, 0x89, 0xa7, 0x93, 0x0c, 0xf1, 0x4c, 0x0f, 0xdd, 0x3d, 0x76, 0x7c, 0xc3,
+	0xe3, 0xf0, 0x00, 0xbc, 0x02, 0x2f, 0xc2, 0x03, 0xf0, 0x10, 0xa8, 0x4f, 0x93, 0x9e, 0xc5, 0x8a,
+	0xf7, 0xca, 0x5d, 0x35, 0x55, 0xf5, 0x75, 0x1d, 0xfa, 0x2b, 0x03, 0xe4, 0x27, 0x0f, 0x27, 0x2f,
+	0x2b, 0x46, 0x05, 0x45, 0x81, 0xfa, 0x49, 0x1e, 0x60, 0xf8, 0x26, 0x25, 0x05, 0x2d, 0xcf, 0x68,
+	0x51, 0xa4, 0x65, 0x86, 0x5e, 0x40, 0x2f, 0xcb, 0x19, 0x99, 0x8b, 0x9c, 0x96, 0xb1, 0x37, 0xf1,
+	0x8e, 0x7b, 0xf8, 0x51, 0x81, 0x10, 0xf8, 0x05, 0x5d, 0x92, 0x78, 0x77, 0xe2, 0x1d, 0x77, 0xb1,
+	0x3a, 0xa3, 0x18, 0x22, 0xba, 0x24, 0x6c, 0x91, 0xae, 0xe3, 0x8e, 0x52, 0x5b, 0x11, 0x3d, 0x87,
+	0x9e, 0xb4, 0xb8, 0x2a, 0x68, 0x46, 0x62, 0x5f, 0xc5, 0xea, 0x4a, 0xc5, 0x3b, 0x9a, 0x91, 0x24,
+	0x85, 0xfe, 0xc5, 0x2a, 0xad, 0x3e, 0x0c, 0x77, 0x00, 0xde, 0x83, 0x02, 0x0d, 0xb0, 0xf7, 0x20,
+	0x25, 0x8d, 0x15, 0x60, 0x6f, 0xed, 0xe2, 0xfb, 0x2d, 0xfc, 0xe4, 0x77, 0x38, 0x78, 0x47, 0x97,
+	0xe4, 0x92, 0xb2, 0x7b, 0x5e, 0xa5, 0x73, 0xf2, 0x61, 0x58, 0x47, 0x10, 0xd2, 0x5a, 0x54, 0xb5,
+	0x50, 0x80, 0x3d, 0x6c, 0x24, 0xa9, 0xbf, 0xa1, 0x8b, 0x05, 0x5d, 0x99, 0x34, 0x8d, 0xf4, 0x04,
+	0xfe, 0xc7, 0x30, 0xf8, 0x29, 0xbd, 0x26, 0x0b, 0x8b, 0x7b, 0x00, 0xc1, 0x42, 0xca, 0x06, 0x53,
+	0x0b, 0xc9, 0x8f, 0x30, 0x3a, 0xa3, 0x45, 0x95, 0xce, 0x85, 0xb5, 0x3b, 0x82, 0x90, 0xdf, 0xb1,
+	0xbc, 0xbc, 0x57, 0x86, 0x5d, 0x6c, 0x24, 0xf4, 0x7f, 0x88, 0x32, 0xb6, 0xbe, 0x62, 0x75, 0x69,
+	0x1a, 0x10, 0x66, 0x6c, 0x8d, 0xeb, 0x32, 0x79, 0x0d, 0x7b, 0x26, 0x04, 0x26, 0xbc, 0xa2, 0x25,
+	0x27, 0xe8, 0x33, 0x88, 0x18, 0x29, 0xd3, 0x82, 0xf0, 0xd8, 0x9b, 0x74, 0x8e, 0xfb, 0xd3, 0xa1,
+	0x6e, 0xfc, 0x4b, 0xac, 0xb4, 0xd8, 0x7e, 0x4d, 0x4e, 0x21, 0xd4, 0x2a, 0xd9, 0xdc, 0x1b, 0x46,
+	0x0b, 0x05, 0x1a, 0x60, 0x75, 0x46, 0x23, 0xd8, 0x15, 0xd4, 0x54, 0x7e, 0x57, 0x50, 0x99, 0x42,
+	0x41, 0xd8, 0x2d, 0x31, 0x35, 0xd0, 0x42, 0xf2, 0x39, 0xf4, 0xcf, 0xf3, 0xf9, 0xbd, 0xbd, 0x7f,
+	0xab, 0xef, 0xde, 0x7b, 0x7d, 0xff, 0xc3, 0x83, 0xfe, 0xdb, 0xba, 0x70, 0x1b, 0xbf, 0xb2, 0x0d,
+	0x32, 0xd0, 0x8f, 0x8a, 0x27, 0x1b, 0x6f, 0x87, 0xd1, 0x77, 0x86, 0xb1, 0x05, 0x1d, 0xb4, 0xa1,
+	0xdd, 0x4e, 0x85, 0xed, 0x49, 0x45, 0xe0, 0x57, 0xe9, 0x2d, 0x89, 0x23, 0x9d, 0xba, 0x3c, 0x27,
+	0x6f, 0x60, 0x34, 0xcb, 0xb9, 0xa0, 0x6c, 0x6d, 0xaf, 0x1a, 0x43, 0x74, 0x43, 0xd9, 0x2a, 0x65,
+	0x99, 0x69, 0x8c, 0x15, 0xdd, 0xc8, 0xbb, 0xed, 0x19, 0x38, 0x87, 0xc1, 0x69, 0x2a, 0xe6, 0x77,
+	0x36, 0xc6, 0xa7, 0x10, 0x70, 0x41, 0x2a, 0xdb, 0x95, 0x7d, 0xd3, 0x15, 0x65, 0x73, 0x21, 0x48,
+	0x85, 0xf5, 0xe7, 0x27, 0x22, 0xfe, 0xe9, 0x41, 0xaf, 0x31, 0x47, 0xdf, 0xc3, 0x28, 0x53, 0x0f,
+	0xf8, 0x6a, 0xae, 0x11, 0xd4, 0xd5, 0xfa, 0xd3, 0x03, 0x13, 0xb8, 0xf5, 0xba, 0x67, 0x3b, 0x78,
+	0x98, 0xb9, 0x0a, 0x74, 0x0c, 0xfe, 0xaf, 0x75, 0x51, 0x29, 0x8c, 0xfe, 0x14, 0x19, 0x27, 0xa7,
+	0x3f, 0xb3, 0x1d, 0xac, 0x2c, 0xd0, 0x2b, 0x88, 0xee, 0x74, 0x39, 0x54, 0x07, 0xfa, 0xd3, 0x43,
+	0x63, 0xdc, 0x2e, 0xd2, 0x6c, 0x07, 0x5b, 0xbb, 0xd3, 0x1e, 0x44, 0xe6, 0x52, 0xc9, 0x21, 0x3c,
+	0xc3, 0x24, 0xcb, 0xb9, 0x60, 0xf9, 0x75, 0x2d, 0xec, 0x4b, 0x4c, 0xf6, 0x60, 0x88, 0xc9, 0x82,
+	0xa6, 0x99, 0x55, 0x8c, 0x60, 0x70, 0x21, 0x52, 0x41, 0x30, 0xf9, 0xad, 0x26, 0x5c, 0x48, 0xf9,
+	0x52, 0xe6, 0x6a, 0xe5, 0xbf, 0x76, 0x61, 0x68, 0x0c, 0xcc, 0xa0, 0xc7, 0x12, 0x64, 0x51, 0x17,
+	0x25, 0x37, 0xd3, 0x63, 0x45, 0xd9, 0x54, 0x46, 0x57, 0xdc, 0x8c, 0x8f, 0x3a, 0xa3, 0x4f, 0x60,
+	0x34, 0xaf, 0x19, 0x23, 0xa5, 0xb8, 0x32, 0x8f, 0x5c, 0x8f, 0xd3, 0xd0, 0x68, 0x7f, 0x56, 0x4a,
+	0xf4, 0x05, 0xfc, 0xcf, 0x9a, 0x3d, 0x0e, 0xa7, 0xaf, 0x2c, 0xf7, 0xcd, 0x87, 0x86, 0x55, 0xd0,
+	0x97, 0x10, 0xe9, 0x58, 0x3c, 0x0e, 0x26, 0x1d, 0xa7, 0x8c, 0x3a, 0x98, 0xbe, 0xae, 0x35, 0x41,
+	0xdf, 0x00, 0x34, 0x21, 0x79, 0x1c, 0x4e, 0x3a, 0x4e, 0x29, 0x9b, 0x98, 0xda, 0xc7, 0x31, 0x94,
+	0xc9, 0xdc, 0xb2, 0x3c, 0x53, 0x13, 0xda, 0xc3, 0xea, 0x2c, 0x1f, 0xa3, 0x9c, 0x54, 0x1e, 0x77,
+	0xd5, 0xcd, 0xb4, 0x80, 0x3e, 0x82, 0x81, 0xbd, 0xbb, 0x54, 0xc4, 0x3d, 0xf5, 0xb1, 0x6f, 0x74,
+	0xe7, 0x72, 0xb4, 0x2f, 0xa0, 0xef, 0xdc, 0x4d, 0xf2, 0x4d, 0x59, 0x17, 0xd7, 0x84, 0x99, 0x0a,
+	0x1a, 0x49, 0x62, 0x4a, 0x62, 0x30, 0x3c, 0xa8, 0xce, 0xb2, 0xdc, 0x15, 0xcb, 0x8b, 0x94, 0x35,
+	0x6c, 0x6f, 0xc4, 0xe4, 0x6f, 0x0f, 0x46, 0xed, 0x04, 0xd0, 0x3e, 0x74, 0xca, 0xda, 0x12, 0x8a,
+	0x3c, 0x6e, 0x0c, 0xd9, 0xd0, 0x62, 0xc7, 0xa1, 0x45, 0x87, 0x86, 0xfd, 0x16, 0x0d, 0x2b, 0x46,
+	0x08, 0x5a, 0x8c, 0x10, 0x3a, 0xab, 0xe0, 0x86, 0xce, 0x6b, 0x4e, 0x74, 0x9d, 0xba, 0xd8, 0x8a,
+	0xf2, 0xcb, 0x32, 0xe7, 0xf9, 0xf5, 0x82, 0xa8, 0x62, 0x75, 0xb1, 0x15, 0x25, 0x4e, 0xcd, 0x6e,
+	0x49, 0x29, 0x54, 0xa1, 0xba, 0xd8, 0x48, 0x0d, 0x25, 0x80, 0x43, 0x09, 0xfb, 0x30, 0xfa, 0x85,
+	0x30, 0x9e, 0xd3, 0xd2, 0xce, 0x23, 0x87, 0xbd, 0x46, 0xf3, 0x38, 0x90, 0x4b, 0xad, 0x32, 0xdc,
+	0x67, 0x45, 0x09, 0x25, 0xdf, 0x43, 0xde, 0x6c, 0x16, 0x2d, 0x49, 0xa8, 0x2c, 0x15, 0xc4, 0xe4,
+	0xaf, 0xce, 0x68, 0x0c, 0xdd, 0x1b, 0x92, 0x8a, 0x9a, 0x11, 0x1e, 0xfb, 0x93, 0x8e, 0xe4, 0x31,
+	0x2b, 0x27, 0xaf, 0xe0, 0xb0, 0xf5, 0xac, 0x5d, 0xe8, 0x82, 0x70, 0x2e, 0xaf, 0x6d, 0xa0, 0x8d,
+	0x38, 0xfd, 0x27, 0xb4, 0x8b, 0xfe, 0x82, 0xb0, 0x65, 0x3e, 0x27, 0xe8, 0x0c, 0x86, 0xb3, 0xb4,
+	0xcc, 0x16, 0xcd, 0x56, 0xdc, 0xc8, 0x18, 0xe3, 0x17, 0x9b, 0xb4, 0x0d, 0xe0, 0xb7, 0xe0, 0xcb,
+	0x25, 0x8e, 0xec, 0xc4, 0x3b, 0x1b, 0x7d, 0xab, 0x67, 0x64, 0x56, 0x16, 0xb2, 0xd3, 0xdf, 0xde,
+	0x82, 0xe3, 0xa3, 0xb6, 0xba, 0xf1, 0x7c, 0x0b, 0xc3, 0xd6, 0x56, 0x47, 0xcf, 0x8d, 0xe1, 0xa6,
+	0x5d, 0xbf, 0xe5, 0x16, 0xaf, 0x21, 0x50, 0x1b, 0x1a, 0x3d, 0x33, 0x66, 0xee, 0xbe, 0xde, 0x9e,
+	0xbb, 0x5c, 0x7a, 0x4d, 0xee, 0xce, 0x06, 0xdc, 0xee, 0x29, 0x19, 0x16, 0x6d, 0xa0, 0xdb, 0x2d,
+	0x9e, 0x3f, 0x40, 0x64, 0xe8, 0x16, 0x6d, 0xa6, 0xdf, 0xed, 0xf9, 0xaa, 0xd5, 0xd1, 0xe4, 0xeb,
+	0xee, 0xa6, 0x2d, 0xbe, 0x33, 0x18, 0xb8, 0x14, 0x8e, 0xc6, 0xcd, 0x1f, 0x8a, 0xff, 0xf0, 0xfa,
+	0x96, 0x48, 0xdf, 0x41, 0xa8, 0x59, 0xbf, 0x99, 0xb9, 0xd6, 0x12, 0xd8, 0xe2, 0x3d, 0x85, 0x40,
+	0xb3, 0x8b, 0xcd, 0xc1, 0x5d, 0x18, 0xe3, 0x83, 0xb6, 0xd2, 0xf8, 0x7c, 0x0d, 0xc1, 0x65, 0x2b,
+	0x6f, 0x77, 0xa9, 0x6c, 0xf6, 0xf9, 0xca, 0x93, 0x33, 0x6a, 0x1e, 0x77, 0x53, 0xed, 0xf6, 0xf3,
+	0x1f, 0x1f, 0xbd, 0xaf, 0xd6, 0xbe, 0xd7, 0xa1, 0x52, 0x9f, 0xfc, 0x3b, 0x00, 0x23, 0xb7, 0xee,
+	0x2f, 0x72, 0x0b, 0x00, 0x00,
 }
@@ -3,10 +3,13 @@ package rpc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/inconshreveable/log15"
+	"github.com/seeruk/i3x3/internal/i3"
 	"github.com/seeruk/i3x3/internal/proto"
+	"github.com/seeruk/i3x3/internal/version"
 )
 
 const (
@@ -14,6 +17,10 @@ const (
 	DefaultPort uint16 = 44045
 	// DefaultTimeout is the time the server will spend waiting for a response from other threads.
 	DefaultTimeout = time.Second
+	// PickTimeout is the time the server will wait for a workspace to be picked interactively.
+	PickTimeout = time.Minute
+	// BatchTimeout is the time the server will wait for a batch of commands to be handled.
+	BatchTimeout = 5 * time.Second
 )
 
 var (
@@ -23,23 +30,33 @@ var (
 
 // Message is a message container that provides the structure to have more of a request / response
 // cycle. Once the message has been processed, a response can be issued by passing the result (an
-// error, or nil) to the response channel.
+// error, or nil, and maybe some data) to the response channel.
 type Message struct {
-	// Command is a command that comes from an RPC client.
-	Command proto.DaemonCommand
+	// Command is a command that comes from an RPC client. It will be one of the command types from
+	// the proto package (e.g. *proto.DaemonCommand, or *proto.SwapCommand).
+	Command interface{}
 	// Context is a context used to cancel downstream events. It should be set with a timeout.
 	Context context.Context
-	// ResponseCh is a channel to send a response down. The response may simply be nil, indicating
-	// success. An error sent down this channel will likely be sent to the client (i3x3ctl).
-	ResponseCh chan<- error
+	// ResponseCh is a channel to send a response down. The response's error may simply be nil,
+	// indicating success. An error sent down this channel will likely be sent to the client.
+	ResponseCh chan<- Response
+}
+
+// Response is the result of handling a Message.
+type Response struct {
+	// Result may contain a proto message for the client, for commands that produce a result other
+	// than success or failure (e.g. *proto.CompactResponse).
+	Result interface{}
+	// Error may contain an error that occurred.
+	Error error
 }
 
 // NewMessage creates a new RPC message, and returns a channel that a response should be passed to.
-func NewMessage(ctx context.Context, command *proto.DaemonCommand) (Message, chan error) {
-	responseCh := make(chan error, 1)
+func NewMessage(ctx context.Context, command interface{}) (Message, chan Response) {
+	responseCh := make(chan Response, 1)
 
 	message := Message{
-		Command:    *command,
+		Command:    command,
 		Context:    ctx,
 		ResponseCh: responseCh,
 	}
@@ -67,19 +84,191 @@ func NewService(logger log15.Logger, msgCh chan<- Message) *Service {
 // HandleCommand routes a command through the application so that it may be handled appropriately by
 // other
 func (s *Service) HandleCommand(ctx context.Context, cmd *proto.DaemonCommand) (*proto.DaemonCommandResponse, error) {
+	_, err := s.send(ctx, cmd)
+
+	return newDaemonCommandResponse(err)
+}
+
+// Swap routes a swap command through the application, in the same way as HandleCommand.
+func (s *Service) Swap(ctx context.Context, cmd *proto.SwapCommand) (*proto.DaemonCommandResponse, error) {
+	_, err := s.send(ctx, cmd)
+
+	return newDaemonCommandResponse(err)
+}
+
+// MoveWorkspace routes a move workspace command through the application, in the same way as
+// HandleCommand.
+func (s *Service) MoveWorkspace(ctx context.Context, cmd *proto.MoveWorkspaceCommand) (*proto.DaemonCommandResponse, error) {
+	_, err := s.send(ctx, cmd)
+
+	return newDaemonCommandResponse(err)
+}
+
+// Label routes a label command through the application, in the same way as HandleCommand.
+func (s *Service) Label(ctx context.Context, cmd *proto.LabelCommand) (*proto.DaemonCommandResponse, error) {
+	_, err := s.send(ctx, cmd)
+
+	return newDaemonCommandResponse(err)
+}
+
+// Pick routes a pick command through the application. Unlike other commands, it waits for the user
+// to pick a workspace, so it has a much longer timeout.
+func (s *Service) Pick(ctx context.Context, cmd *proto.PickCommand) (*proto.DaemonCommandResponse, error) {
+	_, err := s.sendWithTimeout(ctx, cmd, PickTimeout)
+
+	return newDaemonCommandResponse(err)
+}
+
+// Jump routes a jump command through the application, in the same way as HandleCommand.
+func (s *Service) Jump(ctx context.Context, cmd *proto.JumpCommand) (*proto.DaemonCommandResponse, error) {
+	_, err := s.send(ctx, cmd)
+
+	return newDaemonCommandResponse(err)
+}
+
+// History routes a history command through the application, in the same way as HandleCommand.
+func (s *Service) History(ctx context.Context, cmd *proto.HistoryCommand) (*proto.DaemonCommandResponse, error) {
+	_, err := s.send(ctx, cmd)
+
+	return newDaemonCommandResponse(err)
+}
+
+// Batch routes a batch command through the application. A batch may contain many commands, so it
+// has a longer timeout.
+func (s *Service) Batch(ctx context.Context, cmd *proto.BatchCommand) (*proto.DaemonCommandResponse, error) {
+	_, err := s.sendWithTimeout(ctx, cmd, BatchTimeout)
+
+	return newDaemonCommandResponse(err)
+}
+
+// Redistribute routes a redistribute command through the application, in the same way as
+// HandleCommand.
+func (s *Service) Redistribute(ctx context.Context, cmd *proto.RedistributeCommand) (*proto.DaemonCommandResponse, error) {
+	_, err := s.send(ctx, cmd)
+
+	return newDaemonCommandResponse(err)
+}
+
+// Reload routes a reload command through the application, in the same way as HandleCommand.
+func (s *Service) Reload(ctx context.Context, cmd *proto.ReloadCommand) (*proto.DaemonCommandResponse, error) {
+	_, err := s.send(ctx, cmd)
+
+	return newDaemonCommandResponse(err)
+}
+
+// State routes a state request through the application, returning the current state of the grid.
+func (s *Service) State(ctx context.Context, req *proto.StateRequest) (*proto.StateResponse, error) {
+	return s.state(ctx)
+}
+
+// Watch sends the current state of the grid to the client, and then sends it again each time i3
+// reports that workspaces or outputs have changed, until the client goes away.
+func (s *Service) Watch(req *proto.WatchRequest, stream proto.DaemonService_WatchServer) error {
+	ctx, cfn := context.WithCancel(stream.Context())
+	defer cfn()
+
+	// Changes often come in bursts (e.g. a switch is both a "focus" and an "init" event), so they're
+	// coalesced, and only the latest state is sent.
+	changeCh := make(chan struct{}, 1)
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- i3.Subscribe(ctx, []string{"workspace", "output"}, func() {
+			select {
+			case changeCh <- struct{}{}:
+			default:
+			}
+		})
+	}()
+
+	for {
+		res, err := s.state(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = stream.Send(res)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-changeCh:
+		case err := <-errCh:
+			if err == nil {
+				err = errors.New("i3 event subscription ended")
+			}
+
+			return fmt.Errorf("rpc/rpc: error watching i3: %v", err)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Version returns the daemon's version, and the features it supports. It's answered straight away,
+// without going through the rest of the application.
+func (s *Service) Version(ctx context.Context, req *proto.VersionRequest) (*proto.VersionResponse, error) {
+	return &proto.VersionResponse{
+		Version:  version.Version,
+		Commit:   version.Commit,
+		Date:     version.Date,
+		Features: version.Features,
+	}, nil
+}
+
+// Compact routes a compact command through the application, returning the renames that were made
+// (or would be made, if it's a dry run).
+func (s *Service) Compact(ctx context.Context, cmd *proto.CompactCommand) (*proto.CompactResponse, error) {
+	result, err := s.send(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := result.(*proto.CompactResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type: %T", result)
+	}
+
+	return res, nil
+}
+
+// state sends a state request through the application, and waits for the state to be returned.
+func (s *Service) state(ctx context.Context) (*proto.StateResponse, error) {
+	result, err := s.send(ctx, &proto.StateRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := result.(*proto.StateResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type: %T", result)
+	}
+
+	return res, nil
+}
+
+// send passes the given command to the rest of the application as a Message, and waits for the
+// response to be sent back, returning it's result.
+func (s *Service) send(ctx context.Context, cmd interface{}) (interface{}, error) {
+	return s.sendWithTimeout(ctx, cmd, DefaultTimeout)
+}
+
+// sendWithTimeout is the same as send, but waits for the given amount of time for a response.
+func (s *Service) sendWithTimeout(ctx context.Context, cmd interface{}, timeout time.Duration) (interface{}, error) {
 	// For every new command that comes in, we make a new context. Sort of like a HTTP server.
-	msgCtx, _ := context.WithTimeout(context.Background(), DefaultTimeout)
+	msgCtx, cfn := context.WithTimeout(context.Background(), timeout)
+	defer cfn()
+
 	msg, responseCh := NewMessage(msgCtx, cmd)
 
 	var err error
-	var res proto.DaemonCommandResponse
+	var res Response
 
 	select {
 	case s.msgCh <- msg:
 		s.logger.Debug("sent message",
-			"direction", cmd.Direction,
-			"move", cmd.Move,
-			"overlay", cmd.Overlay,
+			"command", cmd,
 		)
 	case <-ctx.Done():
 		err = ctx.Err()
@@ -88,27 +277,34 @@ func (s *Service) HandleCommand(ctx context.Context, cmd *proto.DaemonCommand) (
 	}
 
 	if err != nil {
-		res.Message = err.Error()
-		return &res, err
+		return nil, err
 	}
 
 	select {
-	case err = <-responseCh:
+	case res = <-responseCh:
 	case <-ctx.Done():
-		err = ctx.Err()
+		res.Error = ctx.Err()
 	case <-msgCtx.Done():
-		err = ErrTimeout
-	}
-
-	if err != nil {
-		res.Message = err.Error()
+		res.Error = ErrTimeout
 	}
 
 	defer func() {
 		s.logger.Debug("sent response",
-			"response", res,
+			"result", res.Result,
+			"error", res.Error,
 		)
 	}()
 
+	return res.Result, res.Error
+}
+
+// newDaemonCommandResponse creates the response for commands that only succeed or fail.
+func newDaemonCommandResponse(err error) (*proto.DaemonCommandResponse, error) {
+	var res proto.DaemonCommandResponse
+
+	if err != nil {
+		res.Message = err.Error()
+	}
+
 	return &res, err
 }
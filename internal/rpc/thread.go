@@ -15,6 +15,7 @@ type Thread struct {
 	sync.Mutex
 
 	logger  log15.Logger
+	ready   chan struct{}
 	service *Service
 	server  *grpc.Server
 }
@@ -25,6 +26,7 @@ func NewThread(logger log15.Logger, service *Service) *Thread {
 
 	return &Thread{
 		logger:  logger,
+		ready:   make(chan struct{}),
 		service: service,
 	}
 }
@@ -51,9 +53,16 @@ func (t *Thread) Start() error {
 		"port", DefaultPort,
 	)
 
+	close(t.ready)
+
 	return t.server.Serve(listener)
 }
 
+// Ready returns a channel that's closed once the server is listening, and clients can connect.
+func (t *Thread) Ready() <-chan struct{} {
+	return t.ready
+}
+
 // Stop gracefully stops this server.
 func (t *Thread) Stop() error {
 	t.Lock()
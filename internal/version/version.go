@@ -0,0 +1,106 @@
+package version
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// These describe the build, and are set when building a release, e.g.:
+//
+//	go build -ldflags "-X github.com/seeruk/i3x3/internal/version.Version=1.2.3 \
+//	    -X github.com/seeruk/i3x3/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	    -X github.com/seeruk/i3x3/internal/version.Date=$(date -u +%Y-%m-%d)" ./cmd/...
+var (
+	// Version is the version of i3x3.
+	Version = "dev"
+	// Commit is the commit that i3x3 was built from.
+	Commit = ""
+	// Date is the date that i3x3 was built on.
+	Date = ""
+)
+
+// Features that i3x3d may support. Older versions of i3x3d ignore fields in requests that they don't
+// know about, so i3x3ctl checks that i3x3d supports the features a command needs before sending it.
+const (
+	FeatureBatch         = "batch"
+	FeatureCompact       = "compact"
+	FeatureDiagonal      = "diagonal"
+	FeatureHistory       = "history"
+	FeatureJump          = "jump"
+	FeatureLabel         = "label"
+	FeatureMoveMode      = "move-mode"
+	FeatureMoveWorkspace = "move-workspace"
+	FeaturePages         = "pages"
+	FeaturePick          = "pick"
+	FeatureRedistribute  = "redistribute"
+	FeatureReload        = "reload"
+	FeatureState         = "state"
+	FeatureSwap          = "swap"
+	FeatureWatch         = "watch"
+)
+
+// Features are the features that this build of i3x3d supports.
+var Features = []string{
+	FeatureBatch,
+	FeatureCompact,
+	FeatureDiagonal,
+	FeatureHistory,
+	FeatureJump,
+	FeatureLabel,
+	FeatureMoveMode,
+	FeatureMoveWorkspace,
+	FeaturePages,
+	FeaturePick,
+	FeatureRedistribute,
+	FeatureReload,
+	FeatureState,
+	FeatureSwap,
+	FeatureWatch,
+}
+
+func init() {
+	// Builds installed with "go install" at a tagged version know their version without being told.
+	if info, ok := debug.ReadBuildInfo(); ok && Version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		Version = strings.TrimPrefix(info.Main.Version, "v")
+	}
+}
+
+// String describes the given version, commit, and date, leaving out the parts that are empty.
+func String(version, commit, date string) string {
+	var details []string
+	if commit != "" {
+		details = append(details, "commit "+commit)
+	}
+
+	if date != "" {
+		details = append(details, "built "+date)
+	}
+
+	if len(details) == 0 {
+		return version
+	}
+
+	return fmt.Sprintf("%s (%s)", version, strings.Join(details, ", "))
+}
+
+// Missing returns the required features that aren't in the given supported features.
+func Missing(supported []string, required ...string) []string {
+	var missing []string
+
+	for _, feature := range required {
+		found := false
+		for _, s := range supported {
+			if s == feature {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			missing = append(missing, feature)
+		}
+	}
+
+	return missing
+}
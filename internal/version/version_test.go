@@ -0,0 +1,49 @@
+package version_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/version"
+)
+
+func TestString(t *testing.T) {
+	var tests = []struct {
+		version  string
+		commit   string
+		date     string
+		expected string
+	}{
+		{"dev", "", "", "dev"},
+		{"1.2.3", "abc123", "", "1.2.3 (commit abc123)"},
+		{"1.2.3", "", "2020-05-01", "1.2.3 (built 2020-05-01)"},
+		{"1.2.3", "abc123", "2020-05-01", "1.2.3 (commit abc123, built 2020-05-01)"},
+	}
+
+	for _, test := range tests {
+		actual := version.String(test.version, test.commit, test.date)
+		if actual != test.expected {
+			t.Errorf("Expected %q to equal %q", actual, test.expected)
+		}
+	}
+}
+
+func TestMissing(t *testing.T) {
+	var tests = []struct {
+		supported []string
+		required  []string
+		expected  []string
+	}{
+		{nil, nil, nil},
+		{version.Features, []string{version.FeatureJump, version.FeatureBatch}, nil},
+		{[]string{version.FeatureSwap}, []string{version.FeatureSwap, version.FeatureJump}, []string{version.FeatureJump}},
+		{nil, []string{version.FeatureJump}, []string{version.FeatureJump}},
+	}
+
+	for _, test := range tests {
+		actual := version.Missing(test.supported, test.required...)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected %v to equal %v, with supported features %v", actual, test.expected, test.supported)
+		}
+	}
+}
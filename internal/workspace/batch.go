@@ -0,0 +1,81 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+// handleBatch takes a batch command, and actions each of it's steps in order. The state is only
+// fetched from i3 once, and is then kept up to date as each step is made. The overlay is only shown
+// once, for the last step's target.
+func (t *SwitchThread) handleBatch(ctx context.Context, cmd proto.BatchCommand) error {
+	if len(cmd.Steps) == 0 {
+		return errors.New("batch has no steps")
+	}
+
+	st, err := findState()
+	if err != nil {
+		return err
+	}
+
+	target := st.env.CurrentWorkspace
+
+	for i, step := range cmd.Steps {
+		target, err = t.batchStep(&st, step)
+		if err != nil {
+			return fmt.Errorf("step %d: %v", i+1, err)
+		}
+	}
+
+	if !cmd.Overlay {
+		return nil
+	}
+
+	return t.notify(ctx, st, target)
+}
+
+// batchStep actions a single step of a batch, starting from the given state, which is then updated
+// to reflect the switch. The workspace that was switched to is returned.
+func (t *SwitchThread) batchStep(st *state, step *proto.BatchStep) (int, error) {
+	var err error
+	var mode MoveMode
+	var target int
+
+	switch cmd := step.GetCommand().(type) {
+	case *proto.BatchStep_DaemonCommand:
+		mode, err = NewMoveMode(*cmd.DaemonCommand)
+		if err == nil {
+			target, err = st.target(cmd.DaemonCommand.Direction)
+		}
+	case *proto.BatchStep_Jump:
+		mode, err = NewMoveMode(proto.DaemonCommand{
+			Move:     cmd.Jump.Move,
+			MoveMode: cmd.Jump.MoveMode,
+		})
+
+		if err == nil {
+			target, err = jumpTarget(*st, *cmd.Jump)
+		}
+	case *proto.BatchStep_History:
+		target, err = t.historyTarget(st.env.CurrentWorkspace, cmd.History.Forward)
+	default:
+		err = fmt.Errorf("unknown step type: %T", step.GetCommand())
+	}
+
+	if err != nil || target == st.env.CurrentWorkspace {
+		return target, err
+	}
+
+	err = t.switchTo(*st, target, mode)
+	if err != nil {
+		return 0, err
+	}
+
+	label, _ := t.labels.Get(target)
+	st.switched(target, mode, label)
+
+	return target, nil
+}
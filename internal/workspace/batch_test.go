@@ -0,0 +1,59 @@
+package workspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+// historyBatchStep returns a batch step that goes back, or forward, through the history.
+func historyBatchStep(forward bool) *proto.BatchStep {
+	return &proto.BatchStep{
+		Command: &proto.BatchStep_History{History: &proto.HistoryCommand{Forward: forward}},
+	}
+}
+
+func TestHandleBatchHistory(t *testing.T) {
+	workspaces := []i3.Workspace{
+		{Num: 1, Name: "1", Output: "DP-1"},
+		{Num: 2, Name: "2", Output: "DP-1"},
+		{Num: 3, Name: "3", Output: "DP-1", Focused: true},
+	}
+
+	fake := newFakeI3(t, testOutputs()[:1], workspaces)
+	defer fake.close()
+
+	thread := testSwitchThread(nil)
+	thread.history.Visit(1, 2)
+	thread.history.Visit(2, 3)
+
+	err := thread.handleBatch(context.Background(), proto.BatchCommand{
+		Steps: []*proto.BatchStep{
+			historyBatchStep(false),
+			historyBatchStep(false),
+			historyBatchStep(true),
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expectCommands(t, fake.commands(), "workspace number 2", "workspace number 1", "workspace number 2")
+
+	// There's nothing to go forward to from the newest workspace.
+	workspaces[1].Focused, workspaces[2].Focused = true, false
+	fake.setState(testOutputs()[:1], workspaces)
+
+	err = thread.handleBatch(context.Background(), proto.BatchCommand{
+		Steps: []*proto.BatchStep{historyBatchStep(true), historyBatchStep(true)},
+	})
+
+	if err == nil {
+		t.Error("Expected error going forward past the newest workspace")
+	}
+
+	expectCommands(t, fake.commands(), "workspace number 3")
+}
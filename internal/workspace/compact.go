@@ -0,0 +1,86 @@
+package workspace
+
+import (
+	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+// handleCompact takes a compact command, and actions it, returning the renames that were made.
+func (t *SwitchThread) handleCompact(cmd proto.CompactCommand) (*proto.CompactResponse, error) {
+	st, err := findState()
+	if err != nil {
+		return nil, err
+	}
+
+	nums := make([]int, 0, len(st.workspaces))
+	for _, workspace := range st.workspaces {
+		nums = append(nums, workspace.Num)
+	}
+
+	renames := grid.Compact(st.env, st.size, nums, cmd.Shrink)
+
+	var res proto.CompactResponse
+	for _, rename := range renames {
+		res.Renames = append(res.Renames, &proto.Rename{
+			From:  int32(rename.From),
+			To:    int32(rename.To),
+			Merge: rename.Merge,
+		})
+	}
+
+	if cmd.DryRun {
+		return &res, nil
+	}
+
+	return &res, t.compactWorkspaces(st, renames)
+}
+
+// compactWorkspaces applies the given renames, in order. Renaming a workspace keeps it on the same
+// output, and keeps it focused if it was already, but if the focused workspace's containers are
+// merged into another workspace, focus will follow them. Labels move with renamed workspaces, but
+// are dropped from merged workspaces.
+func (t *SwitchThread) compactWorkspaces(st state, renames []grid.Rename) error {
+	current := st.env.CurrentWorkspace
+	merged := false
+
+	for _, rename := range renames {
+		var err error
+
+		label, _ := t.labels.Get(rename.From)
+
+		if rename.Merge {
+			err = i3.MoveContainersToWorkspace(rename.From, rename.To)
+		} else {
+			workspace, _ := st.workspace(rename.From)
+			err = i3.RenameWorkspace(workspace.Name, i3.WorkspaceName(rename.To, label))
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !rename.Merge {
+			err = t.labels.Set(rename.To, label)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = t.labels.Set(rename.From, "")
+		if err != nil {
+			return err
+		}
+
+		if rename.From == current {
+			current = rename.To
+			merged = rename.Merge
+		}
+	}
+
+	if merged {
+		return i3.SwitchToWorkspace(current)
+	}
+
+	return nil
+}
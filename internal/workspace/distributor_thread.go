@@ -24,16 +24,18 @@ type DistributorThread struct {
 	ctx    context.Context
 	cfn    context.CancelFunc
 	logger log15.Logger
+	labels *Labels
 
 	msgCh <-chan struct{}
 }
 
 // NewDistributorThread creates a new workspace distributor thread.
-func NewDistributorThread(logger log15.Logger, msgCh chan struct{}) *DistributorThread {
+func NewDistributorThread(logger log15.Logger, labels *Labels, msgCh chan struct{}) *DistributorThread {
 	logger = logger.New("module", "workspace/distributorThread")
 
 	return &DistributorThread{
 		logger: logger,
+		labels: labels,
 		msgCh:  msgCh,
 	}
 }
@@ -63,6 +65,11 @@ func (t *DistributorThread) start(attempt int) error {
 
 	doDistribute := func() error {
 		err := redistributeWorkspaces()
+		if err == nil {
+			// Workspaces lose their labels when i3 restarts, so they're restored here too.
+			err = applyLabels(t.labels)
+		}
+
 		if err != nil && attempt >= threshold {
 			return err
 		}
@@ -104,6 +111,8 @@ func (t *DistributorThread) Stop() error {
 	return nil
 }
 
+// redistributeWorkspaces moves each workspace to the output it should be on, based on it's number.
+// Workspaces that aren't numbered aren't on the grid, so they're left on whichever output they're on.
 func redistributeWorkspaces() error {
 	outputs, err := i3.FindOutputs()
 	if err != nil {
@@ -115,32 +124,55 @@ func redistributeWorkspaces() error {
 		return fmt.Errorf("couldn't find workspaces: %v", err)
 	}
 
-	activeOutputs := i3.ActiveOutputs(outputs)
-	activeOutputsNum := len(activeOutputs)
-	currentWorkspace := i3.CurrentWorkspaceNum(workspaces)
-
-	// Sort the active outputs so that the primary display is always first.
-	sort.Slice(activeOutputs, func(i, j int) bool {
-		return activeOutputs[i].Primary
-	})
+	activeOutputs := sortedActiveOutputs(outputs)
+	currentWorkspace, focused := i3.FocusedWorkspace(workspaces)
 
 	// Loop over the existing workspaces, and ensure they're on the display we expect them to be on,
 	// only moving them if they're not in the right place.
 	for _, workspace := range workspaces {
-		workspaces := float64(workspace.Num)
-		outputs := float64(activeOutputsNum)
+		workspaceNum := workspace.Num
 
-		expected := i3.CurrentOutputNum(workspaces, outputs)
-		expectedOutput := activeOutputs[int(expected)-1]
+		expectedOutput, ok := expectedOutput(activeOutputs, workspaceNum)
+		if !ok {
+			continue
+		}
 
 		if expectedOutput.Name != workspace.Output {
-			err := i3.MoveWorkspaceToOutput(workspaces, expectedOutput.Name)
+			err := i3.MoveWorkspaceToOutput(workspaceNum, expectedOutput.Name)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	// Move focus back to original workspace.
-	return i3.SwitchToWorkspace(currentWorkspace)
+	if !focused {
+		return nil
+	}
+
+	// Move focus back to original workspace, by name, in case it isn't numbered.
+	return i3.FocusWorkspace(currentWorkspace.Name)
+}
+
+// sortedActiveOutputs returns the active outputs in the given slice of outputs, sorted so that the
+// primary display is always first.
+func sortedActiveOutputs(outputs []i3.Output) []i3.Output {
+	activeOutputs := i3.ActiveOutputs(outputs)
+
+	sort.Slice(activeOutputs, func(i, j int) bool {
+		return activeOutputs[i].Primary
+	})
+
+	return activeOutputs
+}
+
+// expectedOutput returns the output that the given workspace should be on, from the given sorted
+// active outputs. Workspaces that aren't numbered don't belong on any output, so ok will be false
+// for them, and if there are no active outputs.
+func expectedOutput(activeOutputs []i3.Output, workspace int) (output i3.Output, ok bool) {
+	expected := i3.CurrentOutputNum(workspace, len(activeOutputs))
+	if expected < 1 {
+		return i3.Output{}, false
+	}
+
+	return activeOutputs[expected-1], true
 }
@@ -0,0 +1,130 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/seeruk/i3x3/internal/i3"
+)
+
+// fakeI3Script is a stand-in for i3-msg. It answers queries with the JSON files in it's directory,
+// and records every other command it's sent, one per line.
+const fakeI3Script = `#!/bin/sh
+dir=$(dirname "$0")
+
+if [ "$1" = "-t" ]; then
+	case "$2" in
+		get_outputs) cat "$dir/outputs.json" ;;
+		get_workspaces) cat "$dir/workspaces.json" ;;
+		*) echo '{}' ;;
+	esac
+	exit 0
+fi
+
+echo "$*" >> "$dir/commands"
+`
+
+// fakeI3 puts a fake i3-msg first on the PATH, so that the switcher's handlers can be run without
+// i3, and the commands they send checked.
+type fakeI3 struct {
+	t    *testing.T
+	dir  string
+	path string
+}
+
+// newFakeI3 creates a fake i3 with the given outputs and workspaces. It must be closed once the
+// test is done with it.
+func newFakeI3(t *testing.T, outputs []i3.Output, workspaces []i3.Workspace) *fakeI3 {
+	dir, err := ioutil.TempDir("", "i3x3-fake-i3")
+	if err != nil {
+		t.Fatalf("Expected no error creating temporary directory, got %v", err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, "i3-msg"), []byte(fakeI3Script), 0700)
+	if err != nil {
+		t.Fatalf("Expected no error writing fake i3-msg, got %v", err)
+	}
+
+	f := &fakeI3{t: t, dir: dir, path: os.Getenv("PATH")}
+	f.setState(outputs, workspaces)
+
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+f.path)
+
+	return f
+}
+
+// setState changes the outputs and workspaces that the fake i3 reports.
+func (f *fakeI3) setState(outputs []i3.Output, workspaces []i3.Workspace) {
+	f.writeJSON("outputs.json", outputs)
+	f.writeJSON("workspaces.json", workspaces)
+}
+
+// writeJSON writes the given value to the file with the given name, as JSON.
+func (f *fakeI3) writeJSON(name string, v interface{}) {
+	bs, err := json.Marshal(v)
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(f.dir, name), bs, 0600)
+	}
+
+	if err != nil {
+		f.t.Fatalf("Expected no error writing %s, got %v", name, err)
+	}
+}
+
+// commands returns the commands that have been sent since the last call, and forgets them.
+func (f *fakeI3) commands() []string {
+	path := filepath.Join(f.dir, "commands")
+
+	bs, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		f.t.Fatalf("Expected no error reading commands, got %v", err)
+	}
+
+	os.Remove(path)
+
+	return strings.Split(strings.TrimSpace(string(bs)), "\n")
+}
+
+// close restores the PATH, and removes the fake i3.
+func (f *fakeI3) close() {
+	os.Setenv("PATH", f.path)
+	os.RemoveAll(f.dir)
+}
+
+// testOutputs returns two outputs, side by side.
+func testOutputs() []i3.Output {
+	return []i3.Output{
+		{Name: "DP-1", Active: true, Rect: i3.Rect{Width: 1920, Height: 1080}},
+		{Name: "DP-2", Active: true, Rect: i3.Rect{X: 1920, Width: 1920, Height: 1080}},
+	}
+}
+
+// testSwitchThread creates a switcher thread for testing handlers with, without labels, thumbnails,
+// or any windows. Messages for the overlay are sent down the given channel, which may be nil if
+// the overlay isn't used.
+func testSwitchThread(outCh chan<- SwitchMessage) *SwitchThread {
+	logger := log15.New()
+	logger.SetHandler(log15.DiscardHandler())
+
+	thread := NewSwitchThread(logger, NewLabels(""), NewTreeThread(logger), NewThumbnails(logger, nil), nil, outCh)
+	thread.ctx = context.Background()
+
+	return thread
+}
+
+// expectCommands checks that the given commands were sent to i3, in order.
+func expectCommands(t *testing.T, actual []string, expected ...string) {
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected i3 commands %q, got %q", expected, actual)
+	}
+}
@@ -0,0 +1,172 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+// HistorySize is the number of workspaces that are remembered, to go back and forward through.
+const HistorySize = 100
+
+// History is a record of the workspaces that have been switched to with i3x3, which can be gone
+// back and forward through, like a web browser's history. Switches made without i3x3 (e.g. with
+// i3's own bindings) aren't seen, but going back from a workspace that isn't in the history returns
+// to the last workspace that is. Workspaces that aren't on the grid are never remembered.
+type History struct {
+	sync.Mutex
+
+	size    int
+	entries []int
+	index   int
+	// returned is the workspace that Back or Forward last returned, until the next Visit.
+	returned int
+}
+
+// NewHistory creates a new, empty history, that remembers up to the given number of workspaces.
+func NewHistory(size int) *History {
+	return &History{
+		size: size,
+	}
+}
+
+// Visit records a switch between the given workspaces. Any workspaces that could have been gone
+// forward to are forgotten. Switching to the workspace that Back or Forward just returned doesn't
+// change the history.
+func (h *History) Visit(from, to int) {
+	h.Lock()
+	defer h.Unlock()
+
+	returned := h.returned
+	h.returned = 0
+
+	if from == to || returned == to {
+		return
+	}
+
+	h.truncate(from)
+	h.entries = append(h.entries, to)
+
+	// Forget the oldest workspaces, once the history is full.
+	if len(h.entries) > h.size {
+		h.entries = append([]int(nil), h.entries[len(h.entries)-h.size:]...)
+	}
+
+	h.index = len(h.entries) - 1
+}
+
+// Back returns the workspace before the given current workspace in the history, if there is one.
+func (h *History) Back(current int) (int, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.returned = 0
+
+	if len(h.entries) == 0 {
+		return 0, false
+	}
+
+	// If the user has moved on without i3x3, the current workspace becomes the newest entry, so that
+	// they can come forward to it again.
+	if h.entries[h.index] != current {
+		h.truncate(current)
+		h.index = len(h.entries) - 1
+
+		// A workspace that isn't on the grid isn't added, so the newest entry is the one to go
+		// back to.
+		if current < 1 {
+			h.returned = h.entries[h.index]
+			return h.returned, true
+		}
+	}
+
+	if h.index == 0 {
+		return 0, false
+	}
+
+	h.index--
+	h.returned = h.entries[h.index]
+
+	return h.returned, true
+}
+
+// Forward returns the workspace after the given current workspace in the history, if there is one.
+func (h *History) Forward(current int) (int, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.returned = 0
+
+	// If the user has moved on without i3x3, there's nothing to go forward to.
+	if len(h.entries) == 0 || h.entries[h.index] != current || h.index == len(h.entries)-1 {
+		return 0, false
+	}
+
+	h.index++
+	h.returned = h.entries[h.index]
+
+	return h.returned, true
+}
+
+// truncate forgets the workspaces after the current entry, and then adds the given workspace if it
+// isn't already the current entry, and it's on the grid. The lock must be held.
+func (h *History) truncate(current int) {
+	if len(h.entries) > 0 {
+		h.entries = h.entries[:h.index+1]
+	}
+
+	if current < 1 {
+		return
+	}
+
+	if len(h.entries) == 0 || h.entries[len(h.entries)-1] != current {
+		h.entries = append(h.entries, current)
+	}
+}
+
+// handleHistory takes a history command, and actions it, switching to the previous or next
+// workspace in the history.
+func (t *SwitchThread) handleHistory(ctx context.Context, cmd proto.HistoryCommand) error {
+	st, err := findState()
+	if err != nil {
+		return err
+	}
+
+	target, err := t.historyTarget(st.env.CurrentWorkspace, cmd.Forward)
+	if err != nil {
+		return err
+	}
+
+	err = t.switchTo(st, target, MoveNone)
+	if err != nil || !cmd.Overlay {
+		return err
+	}
+
+	// The workspace may be on another output, in which case the overlay should show that output's
+	// grid.
+	st.env.CurrentOutput = i3.CurrentOutputNum(target, st.env.ActiveOutputs)
+
+	return t.notify(ctx, st, target)
+}
+
+// historyTarget returns the workspace to go forward to from the given current workspace, or back to
+// if forward isn't set. Only one of Back or Forward is called, as both move through the history.
+func (t *SwitchThread) historyTarget(current int, forward bool) (int, error) {
+	var target int
+	var ok bool
+
+	if forward {
+		target, ok = t.history.Forward(current)
+	} else {
+		target, ok = t.history.Back(current)
+	}
+
+	if !ok {
+		return 0, errors.New("no workspace in history")
+	}
+
+	return target, nil
+}
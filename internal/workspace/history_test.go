@@ -0,0 +1,153 @@
+package workspace
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+// historyStep is a step in a test of the history. Back and forward steps switch to the workspace
+// they return, like the switcher does.
+type historyStep struct {
+	// action is "visit", "back", or "forward".
+	action string
+	// from is the current workspace, which isn't necessarily the last one switched to with i3x3.
+	from int
+	// to is the workspace to visit, or the workspace that going back or forward should return.
+	to int
+	// ok is whether going back or forward should find a workspace.
+	ok bool
+}
+
+func TestHistory(t *testing.T) {
+	var tests = []struct {
+		name  string
+		size  int
+		steps []historyStep
+	}{
+		{"empty", 10, []historyStep{
+			{"back", 1, 0, false},
+			{"forward", 1, 0, false},
+		}},
+		{"back and forward", 10, []historyStep{
+			{"visit", 1, 2, true},
+			{"visit", 2, 3, true},
+			{"back", 3, 2, true},
+			{"back", 2, 1, true},
+			{"back", 1, 0, false},
+			{"forward", 1, 2, true},
+			{"forward", 2, 3, true},
+			{"forward", 3, 0, false},
+		}},
+		{"truncation", 10, []historyStep{
+			{"visit", 1, 2, true},
+			{"visit", 2, 3, true},
+			{"back", 3, 2, true},
+			{"visit", 2, 5, true},
+			{"forward", 5, 0, false},
+			{"back", 5, 2, true},
+			{"back", 2, 1, true},
+		}},
+		{"size cap", 3, []historyStep{
+			{"visit", 1, 2, true},
+			{"visit", 2, 3, true},
+			{"visit", 3, 4, true},
+			{"visit", 4, 5, true},
+			{"back", 5, 4, true},
+			{"back", 4, 3, true},
+			{"back", 3, 0, false},
+		}},
+		{"moved away without i3x3", 10, []historyStep{
+			{"visit", 1, 2, true},
+			{"back", 7, 2, true},
+			{"forward", 2, 7, true},
+			{"back", 7, 2, true},
+			{"back", 2, 1, true},
+		}},
+		{"moved away without i3x3, then back with i3x3", 10, []historyStep{
+			{"visit", 1, 2, true},
+			{"visit", 5, 2, true},
+			{"back", 2, 5, true},
+			{"back", 5, 2, true},
+			{"back", 2, 1, true},
+		}},
+		{"moved off the grid without i3x3", 10, []historyStep{
+			{"visit", 1, 2, true},
+			{"back", -1, 2, true},
+			{"back", 2, 1, true},
+			{"forward", 1, 2, true},
+			{"forward", 2, 0, false},
+		}},
+	}
+
+	for _, test := range tests {
+		history := NewHistory(test.size)
+
+		for i, step := range test.steps {
+			if step.action == "visit" {
+				history.Visit(step.from, step.to)
+				continue
+			}
+
+			move := history.Back
+			if step.action == "forward" {
+				move = history.Forward
+			}
+
+			to, ok := move(step.from)
+			if ok != step.ok || to != step.to {
+				t.Errorf("Expected %s from %v to return %v (%v), got %v (%v), at step %v of %q",
+					step.action, step.from, step.to, step.ok, to, ok, i, test.name)
+			}
+
+			// The switcher switches to the workspace that was returned, which is visited.
+			if ok {
+				history.Visit(step.from, to)
+			}
+		}
+	}
+}
+
+func TestHandleHistory(t *testing.T) {
+	workspaces := func(current int) []i3.Workspace {
+		var workspaces []i3.Workspace
+		for _, num := range []int{1, 2, 3} {
+			workspaces = append(workspaces, i3.Workspace{Num: num, Name: strconv.Itoa(num), Output: "DP-1", Focused: num == current})
+		}
+
+		return workspaces
+	}
+
+	fake := newFakeI3(t, testOutputs()[:1], workspaces(3))
+	defer fake.close()
+
+	thread := testSwitchThread(nil)
+	thread.history.Visit(1, 2)
+	thread.history.Visit(2, 3)
+
+	var steps = []struct {
+		forward  bool
+		current  int
+		expected []string
+	}{
+		{false, 3, []string{"workspace number 2"}},
+		{false, 2, []string{"workspace number 1"}},
+		{true, 1, []string{"workspace number 2"}},
+		{true, 2, []string{"workspace number 3"}},
+		{true, 3, nil},
+	}
+
+	for i, step := range steps {
+		fake.setState(testOutputs()[:1], workspaces(step.current))
+
+		err := thread.handleHistory(context.Background(), proto.HistoryCommand{Forward: step.forward})
+		if (err != nil) != (step.expected == nil) {
+			t.Errorf("Expected error to be %v at step %v, got %v", step.expected == nil, i, err)
+		}
+
+		expectCommands(t, fake.commands(), step.expected...)
+	}
+}
@@ -0,0 +1,88 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+// handleJump takes a jump command, and actions it.
+func (t *SwitchThread) handleJump(ctx context.Context, cmd proto.JumpCommand) error {
+	mode, err := NewMoveMode(proto.DaemonCommand{
+		Move:     cmd.Move,
+		MoveMode: cmd.MoveMode,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	st, err := findState()
+	if err != nil {
+		return err
+	}
+
+	target, err := jumpTarget(st, cmd)
+	if err != nil {
+		return err
+	}
+
+	// Switching to the current workspace would trigger i3's workspace_auto_back_and_forth, if the
+	// user has it enabled, which isn't what was asked for.
+	if target == st.env.CurrentWorkspace {
+		return nil
+	}
+
+	err = t.switchTo(st, target, mode)
+	if err != nil || !cmd.Overlay {
+		return err
+	}
+
+	// The target may be on another output, in which case the overlay should show that output's grid.
+	if mode != MoveStay {
+		st.env.CurrentOutput = i3.CurrentOutputNum(target, st.env.ActiveOutputs)
+	}
+
+	return t.notify(ctx, st, target)
+}
+
+// jumpTarget returns the workspace that the given jump command points at, either by it's number,
+// by it's cell on the current output's grid, or by it's page.
+func jumpTarget(st state, cmd proto.JumpCommand) (int, error) {
+	if cmd.Workspace != 0 {
+		if cmd.Workspace < 1 {
+			return 0, fmt.Errorf("invalid workspace: %d", cmd.Workspace)
+		}
+
+		return int(cmd.Workspace), nil
+	}
+
+	// Without a cell, the current workspace's cell is used on the given page.
+	if cmd.X == 0 && cmd.Y == 0 && cmd.Page != 0 {
+		if st.env.CurrentWorkspace < 1 {
+			return 0, errNotOnGrid
+		}
+
+		target, ok := grid.PageWorkspace(st.env, st.size, int(cmd.Page))
+		if !ok {
+			return 0, fmt.Errorf("page is outside of the grid: %d", cmd.Page)
+		}
+
+		return target, nil
+	}
+
+	page := int(cmd.Page)
+	if page == 0 {
+		page = grid.WorkspacePage(st.env.CurrentWorkspace, st.env.ActiveOutputs, st.size)
+	}
+
+	target, ok := grid.PageCellWorkspace(st.env, st.size, page, int(cmd.X), int(cmd.Y))
+	if !ok {
+		return 0, fmt.Errorf("cell is outside of the grid: %d, %d, on page %d", cmd.X, cmd.Y, page)
+	}
+
+	return target, nil
+}
@@ -0,0 +1,231 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+// Labels is a store of workspace labels, keyed by workspace number. Labels are saved to a file
+// whenever they change, so that they survive restarts of both i3x3d, and i3.
+type Labels struct {
+	sync.Mutex
+
+	path   string
+	labels map[int]string
+}
+
+// NewLabels creates a new, empty label store that will be saved to the given path.
+func NewLabels(path string) *Labels {
+	return &Labels{
+		path:   path,
+		labels: make(map[int]string),
+	}
+}
+
+// DefaultLabelsPath returns the default location of the labels file, following the XDG base
+// directory specification.
+func DefaultLabelsPath() string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(os.Getenv("HOME"), ".local", "share")
+	}
+
+	return filepath.Join(dataHome, "i3x3", "labels.json")
+}
+
+// Load reads the labels from the store's file, replacing any labels currently in the store. If the
+// file doesn't exist yet, the store is left empty.
+func (l *Labels) Load() error {
+	l.Lock()
+	defer l.Unlock()
+
+	bs, err := ioutil.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("workspace/labels: error reading labels: %v", err)
+	}
+
+	labels := make(map[int]string)
+
+	err = json.Unmarshal(bs, &labels)
+	if err != nil {
+		return fmt.Errorf("workspace/labels: error decoding labels: %v", err)
+	}
+
+	l.labels = labels
+
+	return nil
+}
+
+// Get returns the label for the given workspace, if it has one.
+func (l *Labels) Get(workspace int) (string, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	label, ok := l.labels[workspace]
+
+	return label, ok
+}
+
+// All returns a copy of all of the labels in the store.
+func (l *Labels) All() map[int]string {
+	l.Lock()
+	defer l.Unlock()
+
+	labels := make(map[int]string, len(l.labels))
+	for workspace, label := range l.labels {
+		labels[workspace] = label
+	}
+
+	return labels
+}
+
+// Set sets the label for the given workspace, and saves the store. An empty label removes the
+// workspace's label.
+func (l *Labels) Set(workspace int, label string) error {
+	l.Lock()
+	defer l.Unlock()
+
+	if label == "" {
+		delete(l.labels, workspace)
+	} else {
+		l.labels[workspace] = label
+	}
+
+	return l.save()
+}
+
+// save writes the labels to the store's file. The file is replaced atomically, so a failed write
+// can't leave a broken file behind.
+func (l *Labels) save() error {
+	bs, err := json.Marshal(l.labels)
+	if err != nil {
+		return fmt.Errorf("workspace/labels: error encoding labels: %v", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(l.path), 0755)
+	if err != nil {
+		return fmt.Errorf("workspace/labels: error creating directory: %v", err)
+	}
+
+	tmp := l.path + ".tmp"
+
+	err = ioutil.WriteFile(tmp, bs, 0644)
+	if err != nil {
+		return fmt.Errorf("workspace/labels: error writing labels: %v", err)
+	}
+
+	return os.Rename(tmp, l.path)
+}
+
+// handleLabel takes a label command, and actions it, labelling the current workspace.
+func (t *SwitchThread) handleLabel(cmd proto.LabelCommand) error {
+	st, err := findState()
+	if err != nil {
+		return err
+	}
+
+	// Labels are kept alongside workspace numbers, so workspaces that aren't numbered can't have one.
+	current := st.env.CurrentWorkspace
+	workspace, ok := st.workspace(current)
+	if !ok {
+		return errNotOnGrid
+	}
+
+	err = t.labels.Set(current, cmd.Label)
+	if err != nil {
+		return err
+	}
+
+	name := i3.WorkspaceName(current, cmd.Label)
+	if workspace.Name == name {
+		return nil
+	}
+
+	return i3.RenameWorkspace(workspace.Name, name)
+}
+
+// handleReload takes a reload command, and actions it, reloading the labels from disk (e.g. after
+// they've been edited by hand), and then applying them.
+func (t *SwitchThread) handleReload() error {
+	err := t.labels.Load()
+	if err != nil {
+		return err
+	}
+
+	return applyLabels(t.labels)
+}
+
+// applyLabel renames the given workspace so that it's name includes it's label, if it has one
+// and it isn't already named that way. A workspace that doesn't exist in the given state is assumed
+// to have just been created by i3, named with only it's number.
+func applyLabel(st state, labels *Labels, num int) error {
+	label, ok := labels.Get(num)
+	if !ok {
+		return nil
+	}
+
+	name := i3.WorkspaceName(num, "")
+	if workspace, exists := st.workspace(num); exists {
+		name = workspace.Name
+	}
+
+	labelled := i3.WorkspaceName(num, label)
+	if name == labelled {
+		return nil
+	}
+
+	return i3.RenameWorkspace(name, labelled)
+}
+
+// applyLabels ensures that every existing workspace is named with it's stored label. Workspaces
+// that have been labelled outside of i3x3 (e.g. renamed to "5:mail" in i3) have their label adopted
+// into the store, if the store doesn't already have a label for them.
+func applyLabels(labels *Labels) error {
+	workspaces, err := i3.FindWorkspaces()
+	if err != nil {
+		return fmt.Errorf("couldn't find workspaces: %v", err)
+	}
+
+	for _, workspace := range workspaces {
+		if workspace.Num < 1 {
+			continue
+		}
+
+		num := workspace.Num
+		nameLabel := i3.WorkspaceLabel(workspace.Name)
+
+		label, ok := labels.Get(num)
+		if !ok {
+			if nameLabel != "" {
+				err = labels.Set(num, nameLabel)
+				if err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if nameLabel == label {
+			continue
+		}
+
+		err = i3.RenameWorkspace(workspace.Name, i3.WorkspaceName(num, label))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
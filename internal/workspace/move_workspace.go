@@ -0,0 +1,168 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+// handleMoveWorkspace takes a move workspace command, and actions it.
+func (t *SwitchThread) handleMoveWorkspace(ctx context.Context, cmd proto.MoveWorkspaceCommand) error {
+	st, tar, err := t.moveWorkspace(cmd)
+	if err != nil || !cmd.Overlay {
+		return err
+	}
+
+	return t.notify(ctx, st, tar)
+}
+
+// moveWorkspace moves all containers on the current workspace to the workspace in the same cell on
+// the output that the given command points at. Unlike i3's "move workspace to output", this keeps
+// the workspace numbering intact, so the distributor won't just move it back again.
+//
+// The returned state's environment has it's current output set to the target output, so that the
+// overlay shows the grid that the containers were moved to.
+func (t *SwitchThread) moveWorkspace(cmd proto.MoveWorkspaceCommand) (state, int, error) {
+	st, err := findState()
+	if err != nil {
+		return st, 0, err
+	}
+
+	activeOutputs := sortedActiveOutputs(st.outputs)
+	current := st.env.CurrentWorkspace
+	currentWorkspace, ok := st.workspace(current)
+	if !ok {
+		return st, 0, errNotOnGrid
+	}
+
+	var targetOutputIdx int
+
+	if cmd.Output != "" {
+		targetOutputIdx, ok = outputIndexByName(activeOutputs, cmd.Output)
+		if !ok {
+			return st, 0, fmt.Errorf("unknown output: %q", cmd.Output)
+		}
+	} else {
+		currentOutputIdx, ok := outputIndexByName(activeOutputs, currentWorkspace.Output)
+		if !ok {
+			return st, 0, fmt.Errorf("unknown output: %q", currentWorkspace.Output)
+		}
+
+		targetOutputIdx, ok = adjacentOutputIndex(activeOutputs, currentOutputIdx, grid.Direction(cmd.Direction))
+		if !ok {
+			return st, 0, fmt.Errorf("no output in direction: %q", cmd.Direction)
+		}
+	}
+
+	targetOutput := activeOutputs[targetOutputIdx]
+	if targetOutput.Name == currentWorkspace.Output {
+		return st, 0, fmt.Errorf("workspace is already on output: %q", targetOutput.Name)
+	}
+
+	// The same cell, on the target output's grid.
+	g := grid.NewGrid(st.env, st.size)
+	page, x, y, _ := g.Cell(current)
+
+	target, ok := g.Workspace(targetOutputIdx+1, page, x, y)
+	if !ok {
+		return st, 0, errNotOnGrid
+	}
+
+	_, targetExists := st.workspace(target)
+
+	err = i3.MoveAllToWorkspace(target)
+	if err != nil {
+		return st, 0, err
+	}
+
+	// If the target workspace didn't exist, i3 will have created it on the current output, so it
+	// needs moving to where it belongs. This requires switching to it.
+	if !targetExists {
+		err = i3.MoveWorkspaceToOutput(target, targetOutput.Name)
+		if err != nil {
+			return st, 0, err
+		}
+	}
+
+	err = applyLabel(st, t.labels, target)
+	if err != nil {
+		return st, 0, err
+	}
+
+	// Moving the target workspace to it's output focused it there. By now the current workspace is
+	// empty, so i3 will have destroyed it. Switching back to it by number from the target output
+	// would create it again on the wrong output, so the output it was on is focused first. Focusing
+	// that output alone would show whichever workspace i3 picks, rather than the user's cell.
+	if cmd.Follow {
+		err = i3.SwitchToWorkspace(target)
+	} else if !targetExists {
+		err = i3.FocusOutput(currentWorkspace.Output)
+		if err == nil {
+			err = i3.SwitchToWorkspace(current)
+		}
+	}
+
+	if err != nil {
+		return st, 0, err
+	}
+
+	st.env.CurrentOutput = targetOutputIdx + 1
+
+	return st, target, nil
+}
+
+// outputIndexByName finds the index of the output with the given name in the given outputs.
+func outputIndexByName(outputs []i3.Output, name string) (int, bool) {
+	for i, output := range outputs {
+		if output.Name == name {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// adjacentOutputIndex finds the index of the output that is physically next to the output at the
+// given index, in the given direction. If there are several, the closest one is chosen.
+func adjacentOutputIndex(outputs []i3.Output, current int, direction grid.Direction) (int, bool) {
+	cur := outputs[current].Rect
+	cx := cur.X + (cur.Width / 2)
+	cy := cur.Y + (cur.Height / 2)
+
+	found := -1
+	best := 0
+
+	for i, output := range outputs {
+		if i == current {
+			continue
+		}
+
+		ox := output.Rect.X + (output.Rect.Width / 2)
+		oy := output.Rect.Y + (output.Rect.Height / 2)
+
+		var distance int
+
+		switch direction {
+		case grid.Up:
+			distance = cy - oy
+		case grid.Down:
+			distance = oy - cy
+		case grid.Left:
+			distance = cx - ox
+		case grid.Right:
+			distance = ox - cx
+		default:
+			return 0, false
+		}
+
+		if distance > 0 && (found == -1 || distance < best) {
+			found = i
+			best = distance
+		}
+	}
+
+	return found, found != -1
+}
@@ -0,0 +1,65 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+func TestMoveWorkspace(t *testing.T) {
+	var tests = []struct {
+		name     string
+		cmd      proto.MoveWorkspaceCommand
+		existing bool
+		expected []string
+	}{
+		{"new target", proto.MoveWorkspaceCommand{Direction: "right"}, false, []string{
+			`[workspace="__focused__"] move container to workspace number 4`,
+			"workspace number 4",
+			"move workspace to output DP-2",
+			"focus output DP-1",
+			"workspace number 3",
+		}},
+		{"new target, following", proto.MoveWorkspaceCommand{Direction: "right", Follow: true}, false, []string{
+			`[workspace="__focused__"] move container to workspace number 4`,
+			"workspace number 4",
+			"move workspace to output DP-2",
+			"workspace number 4",
+		}},
+		// The target is already on it's output, so focus never leaves the current workspace.
+		{"existing target", proto.MoveWorkspaceCommand{Output: "DP-2"}, true, []string{
+			`[workspace="__focused__"] move container to workspace number 4`,
+		}},
+		{"existing target, following", proto.MoveWorkspaceCommand{Output: "DP-2", Follow: true}, true, []string{
+			`[workspace="__focused__"] move container to workspace number 4`,
+			"workspace number 4",
+		}},
+	}
+
+	for _, test := range tests {
+		workspaces := []i3.Workspace{
+			{Num: 1, Name: "1", Output: "DP-1"},
+			{Num: 2, Name: "2", Output: "DP-2", Visible: true},
+			{Num: 3, Name: "3", Output: "DP-1", Visible: true, Focused: true},
+		}
+
+		if test.existing {
+			workspaces = append(workspaces, i3.Workspace{Num: 4, Name: "4", Output: "DP-2"})
+		}
+
+		fake := newFakeI3(t, testOutputs(), workspaces)
+
+		st, target, err := testSwitchThread(nil).moveWorkspace(test.cmd)
+		if err != nil {
+			t.Errorf("Expected no error (%s), got %v", test.name, err)
+		}
+
+		if target != 4 || st.env.CurrentOutput != 2 {
+			t.Errorf("Expected target 4 on output 2 (%s), got %v on output %v", test.name, target, st.env.CurrentOutput)
+		}
+
+		expectCommands(t, fake.commands(), test.expected...)
+		fake.close()
+	}
+}
@@ -0,0 +1,155 @@
+//go:build !nogtk
+// +build !nogtk
+
+package workspace
+
+import (
+	"time"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// OverlayFrameInterval is the time between each frame of the overlay's animations.
+const OverlayFrameInterval = 16 * time.Millisecond
+
+// animation calls a step function on every frame, for a set amount of time, with the eased progress
+// of the animation. Frames are scheduled on the GTK main loop, so an animation must only be started
+// or stopped on the GTK main thread.
+type animation struct {
+	start    time.Time
+	duration time.Duration
+	easing   OverlayEasing
+	step     func(progress float64)
+	done     func()
+	stopped  bool
+}
+
+// animate starts a new animation. The done function is called once the animation has finished,
+// unless it's stopped first.
+func animate(duration time.Duration, easing OverlayEasing, step func(progress float64), done func()) *animation {
+	a := &animation{
+		start:    time.Now(),
+		duration: duration,
+		easing:   easing,
+		step:     step,
+		done:     done,
+	}
+
+	glib.TimeoutAdd(uint(OverlayFrameInterval/time.Millisecond), a.frame)
+
+	return a
+}
+
+// frame draws a single frame of the animation, returning whether there are more frames to come.
+func (a *animation) frame() bool {
+	if a.stopped {
+		return false
+	}
+
+	progress := float64(time.Since(a.start)) / float64(a.duration)
+	if progress < 1 {
+		a.step(a.easing.ease(progress))
+		return true
+	}
+
+	a.step(1)
+	a.stopped = true
+
+	if a.done != nil {
+		a.done()
+	}
+
+	return false
+}
+
+// stop stops the animation, leaving it wherever it had got to.
+func (a *animation) stop() {
+	a.stopped = true
+}
+
+// animationDuration returns the configured duration of the overlay's animations.
+func (t *OverlayThread) animationDuration() time.Duration {
+	return time.Duration(t.config.AnimationDuration) * time.Millisecond
+}
+
+// animateHighlight wraps the given grid so that a highlight can slide over it, from the current
+// workspace's cell to the target workspace's cell. If animations are disabled, or either cell isn't
+// in the given grid, the grid is returned as it is.
+func (t *OverlayThread) animateHighlight(msg SwitchMessage, ogrid *gtk.Grid, cells map[int]*gtk.EventBox) gtk.IWidget {
+	source, sourceOK := cells[msg.Environment.CurrentWorkspace]
+	target, targetOK := cells[int(msg.Target)]
+
+	if t.animationDuration() == 0 || !sourceOK || !targetOK || source == target {
+		return ogrid
+	}
+
+	// The target is only marked as active once the highlight has reached it.
+	targetStyles, _ := target.GetStyleContext()
+	targetStyles.RemoveClass("i3x3-grid__box--active")
+
+	highlight, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 0)
+	highlight.SetHAlign(gtk.ALIGN_START)
+	highlight.SetVAlign(gtk.ALIGN_START)
+	highlight.SetOpacity(0)
+
+	highlightStyles, _ := highlight.GetStyleContext()
+	highlightStyles.AddClass("i3x3-grid__highlight")
+
+	container, _ := gtk.OverlayNew()
+	container.Add(ogrid)
+	container.AddOverlay(highlight)
+
+	// Cell positions are only known once GTK has laid out the grid, so they're looked up on every
+	// frame, rather than up front. They're relative to the grid, which fills the container.
+	step := func(progress float64) {
+		gridAlloc := ogrid.GetAllocation()
+		sourceAlloc := source.GetAllocation()
+		targetAlloc := target.GetAllocation()
+
+		x := lerp(sourceAlloc.GetX(), targetAlloc.GetX(), progress) - gridAlloc.GetX()
+		y := lerp(sourceAlloc.GetY(), targetAlloc.GetY(), progress) - gridAlloc.GetY()
+
+		highlight.SetSizeRequest(targetAlloc.GetWidth(), targetAlloc.GetHeight())
+		highlight.SetMarginStart(x)
+		highlight.SetMarginTop(y)
+		highlight.SetOpacity(1)
+	}
+
+	done := func() {
+		highlight.Hide()
+		targetStyles.AddClass("i3x3-grid__box--active")
+	}
+
+	t.slide = animate(t.animationDuration(), t.config.Easing, step, done)
+
+	return container
+}
+
+// hideWindow hides the overlay window, fading it out first if animations are enabled. This must be
+// called on the GTK main thread.
+func (t *OverlayThread) hideWindow() bool {
+	if t.animationDuration() == 0 {
+		t.window.Hide()
+		return false
+	}
+
+	step := func(progress float64) {
+		t.window.SetOpacity(1 - progress)
+	}
+
+	done := func() {
+		t.window.Hide()
+		t.window.SetOpacity(1)
+		t.fade = nil
+	}
+
+	t.fade = animate(t.animationDuration(), t.config.Easing, step, done)
+
+	return false
+}
+
+// lerp linearly interpolates between the given values, by the given amount (from 0 to 1).
+func lerp(from, to int, amount float64) int {
+	return from + int(float64(to-from)*amount)
+}
@@ -0,0 +1,185 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/seeruk/i3x3/internal/daemon"
+	"github.com/seeruk/i3x3/internal/overlay"
+)
+
+// OverlayDuration specifies how long the overlay will stay on the screen for.
+const OverlayDuration = 500 * time.Millisecond
+
+// OverlayBackendName identifies a way of showing the overlay.
+type OverlayBackendName string
+
+// Possible OverlayBackendName values.
+const (
+	// OverlayBackendGTK shows the overlay in a GTK window. It's the only backend that can be used
+	// to interactively pick a workspace.
+	OverlayBackendGTK OverlayBackendName = "gtk"
+	// OverlayBackendNotification shows the overlay as a desktop notification, over D-Bus.
+	OverlayBackendNotification OverlayBackendName = "notification"
+	// OverlayBackendTerminal draws the overlay as text in the terminal i3x3d is running in.
+	OverlayBackendTerminal OverlayBackendName = "terminal"
+	// OverlayBackendNone doesn't show the overlay at all.
+	OverlayBackendNone OverlayBackendName = "none"
+)
+
+// OverlayBackend is a way of showing the overlay that isn't interactive, run by an
+// OverlayBackendThread. The GTK overlay is interactive, so it's a thread of it's own instead.
+type OverlayBackend interface {
+	// Show shows the overlay for the given message. It's only called from one goroutine.
+	Show(msg SwitchMessage) error
+}
+
+// OverlayOptions are the settings used to create an overlay backend.
+type OverlayOptions struct {
+	// ConfigPath is the path to the user's overlay configuration file.
+	ConfigPath string
+	// Backend overrides the backend set in the configuration file, if it's set.
+	Backend OverlayBackendName
+	// Windows is how the windows on each workspace are shown, where the backend supports it.
+	Windows OverlayWindows
+	// AllOutputs enables showing the grid of every active output, not just the current output.
+	AllOutputs bool
+	// Thumbnails is told when the overlay is mapped, by backends that draw on the screen, so that
+	// it isn't captured in a thumbnail.
+	Thumbnails *Thumbnails
+}
+
+// NewOverlay creates the thread for the overlay backend chosen by the given options, or the user's
+// overlay configuration. Changing the backend in the configuration file needs a restart to take
+// effect.
+func NewOverlay(logger log15.Logger, opts OverlayOptions, msgCh <-chan SwitchMessage) (daemon.Thread, error) {
+	name := opts.Backend
+	if name == "" {
+		name = OverlayBackendDefault
+
+		// A broken configuration file shouldn't stop the daemon from starting, the GTK overlay
+		// falls back to the defaults in the same way.
+		config, err := loadOverlayConfig(opts.ConfigPath)
+		if err != nil {
+			logger.Error("error loading overlay config, using default backend", "error", err)
+		} else {
+			name = config.Backend
+		}
+	}
+
+	switch name {
+	case OverlayBackendGTK:
+		return newGTKOverlay(logger, opts, msgCh)
+	case OverlayBackendNotification:
+		return NewOverlayBackendThread(logger, name, NewNotificationOverlay(opts.AllOutputs), msgCh), nil
+	case OverlayBackendTerminal:
+		return NewOverlayBackendThread(logger, name, NewTerminalOverlay(opts.AllOutputs, os.Stdout), msgCh), nil
+	case OverlayBackendNone:
+		return NewOverlayBackendThread(logger, name, NoopOverlay{}, msgCh), nil
+	}
+
+	return nil, fmt.Errorf("workspace/overlay: invalid backend: %q", name)
+}
+
+// OverlayBackendThread is a long-running process that shows the overlay with an OverlayBackend.
+// Each message is acknowledged before it's shown, and requests to pick a workspace are refused,
+// because the backend isn't interactive.
+type OverlayBackendThread struct {
+	sync.Mutex
+
+	ctx     context.Context
+	cfn     context.CancelFunc
+	logger  log15.Logger
+	name    OverlayBackendName
+	backend OverlayBackend
+
+	msgCh <-chan SwitchMessage
+}
+
+// NewOverlayBackendThread creates a new overlay thread, showing the overlay with the given backend.
+// If the backend is an io.Closer, it's closed when the thread stops.
+func NewOverlayBackendThread(logger log15.Logger, name OverlayBackendName, backend OverlayBackend, msgCh <-chan SwitchMessage) *OverlayBackendThread {
+	logger = logger.New("module", "workspace/overlayBackendThread", "backend", name)
+
+	return &OverlayBackendThread{
+		logger:  logger,
+		name:    name,
+		backend: backend,
+		msgCh:   msgCh,
+	}
+}
+
+// Start attempts to start the overlay thread.
+func (t *OverlayBackendThread) Start() error {
+	t.Lock()
+	t.ctx, t.cfn = context.WithCancel(context.Background())
+	t.Unlock()
+
+	t.logger.Info("thread started")
+
+	defer func() {
+		if closer, ok := t.backend.(io.Closer); ok {
+			closer.Close()
+		}
+
+		t.logger.Info("thread stopped")
+	}()
+
+	for {
+		select {
+		case msg := <-t.msgCh:
+			if msg.PickCh != nil {
+				msg.ResponseCh <- fmt.Errorf("workspace/overlay: the %s overlay can't be used to pick a workspace", t.name)
+				continue
+			}
+
+			// The switch has already happened, so the switcher doesn't need to wait for the
+			// overlay to be shown.
+			msg.ResponseCh <- nil
+
+			if err := t.backend.Show(msg); err != nil {
+				t.logger.Error("error showing overlay", "error", err)
+			}
+		case <-t.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Stop attempts to stop the overlay thread.
+func (t *OverlayBackendThread) Stop() error {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.ctx != nil && t.cfn != nil {
+		t.cfn()
+	}
+
+	return nil
+}
+
+// overlayState returns the parts of the message that the overlay's layout is based on.
+func (m SwitchMessage) overlayState() overlay.State {
+	return overlay.State{
+		Environment: m.Environment,
+		Size:        m.Size,
+		Workspaces:  m.Workspaces,
+		Outputs:     m.Outputs,
+		Target:      m.Target,
+		Labels:      m.Labels,
+		Windows:     m.Windows,
+	}
+}
+
+// NoopOverlay is an overlay backend that doesn't show anything.
+type NoopOverlay struct{}
+
+// Show does nothing.
+func (NoopOverlay) Show(SwitchMessage) error {
+	return nil
+}
@@ -0,0 +1,92 @@
+package workspace_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/seeruk/i3x3/internal/daemon"
+	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/workspace"
+)
+
+// syncBuffer is a strings.Builder that's safe to write to from the overlay thread, whilst the test
+// reads it.
+type syncBuffer struct {
+	sync.Mutex
+	buf strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.buf.String()
+}
+
+// failingOverlay is an overlay backend that always fails to show the overlay.
+type failingOverlay struct{}
+
+func (failingOverlay) Show(workspace.SwitchMessage) error {
+	return errors.New("failed")
+}
+
+func TestOverlayBackendThread(t *testing.T) {
+	logger := log15.New()
+	logger.SetHandler(log15.DiscardHandler())
+
+	out := &syncBuffer{}
+	msgCh := make(chan workspace.SwitchMessage)
+
+	ctx, cfn := context.WithCancel(context.Background())
+	done := daemon.NewBackgroundThread(ctx, workspace.NewOverlayBackendThread(logger, workspace.OverlayBackendTerminal, workspace.NewTerminalOverlay(false, out), msgCh))
+
+	env := grid.Environment{ActiveOutputs: 1, CurrentOutput: 1, CurrentWorkspace: 1, MaxWorkspace: 2}
+	size := grid.Size{RealX: 2, RealY: 1, OriginalX: 2, OriginalY: 1}
+
+	// Picking isn't possible without the GTK overlay.
+	pick, responseCh := workspace.NewSwitchMessage(ctx, env, size, nil, 1, nil, nil, nil)
+	pick.PickCh = make(chan workspace.PickResult, 1)
+
+	msgCh <- pick
+	if err := <-responseCh; err == nil {
+		t.Error("Expected an error picking with the terminal overlay")
+	}
+
+	msg, responseCh := workspace.NewSwitchMessage(ctx, env, size, nil, 2, nil, nil, nil)
+
+	msgCh <- msg
+	if err := <-responseCh; err != nil {
+		t.Errorf("Unexpected error showing the terminal overlay: %v", err)
+	}
+
+	// Errors showing the overlay are only logged, because the switch has already happened.
+	failing := make(chan workspace.SwitchMessage)
+	failingDone := daemon.NewBackgroundThread(ctx, workspace.NewOverlayBackendThread(logger, workspace.OverlayBackendNone, failingOverlay{}, failing))
+
+	msg, responseCh = workspace.NewSwitchMessage(ctx, env, size, nil, 1, nil, nil, nil)
+
+	failing <- msg
+	if err := <-responseCh; err != nil {
+		t.Errorf("Expected no error to be sent to the switcher, got: %v", err)
+	}
+
+	// Each message is shown before the next message, or the thread stopping, is handled.
+	cfn()
+	<-done
+	<-failingDone
+
+	if expected := "\x1b[H\x1b[2J 1 [2]\n"; out.String() != expected {
+		t.Errorf("Expected the terminal to show %q, got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,312 @@
+package workspace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/seeruk/i3x3/internal/overlay"
+)
+
+// OverlayPosition is where the overlay is shown on the screen.
+type OverlayPosition string
+
+// Possible OverlayPosition values. The corners and centre are relative to the output that the
+// target workspace is on.
+const (
+	OverlayPositionCenter      OverlayPosition = "center"
+	OverlayPositionTopLeft     OverlayPosition = "top-left"
+	OverlayPositionTopRight    OverlayPosition = "top-right"
+	OverlayPositionBottomLeft  OverlayPosition = "bottom-left"
+	OverlayPositionBottomRight OverlayPosition = "bottom-right"
+	OverlayPositionMouse       OverlayPosition = "mouse"
+)
+
+// OverlayHide is when the overlay is hidden after a switch.
+type OverlayHide string
+
+// Possible OverlayHide values.
+const (
+	// OverlayHideTimeout hides the overlay a fixed time after the last switch.
+	OverlayHideTimeout OverlayHide = "timeout"
+	// OverlayHideRelease keeps the overlay visible whilst the modifier keys that were held when
+	// switching are still held, and hides it as soon as one of them is released. If no modifiers
+	// were held, or they can't be read from X, the overlay is hidden after a timeout instead.
+	OverlayHideRelease OverlayHide = "release"
+)
+
+// OverlayEasing is how an animation in the overlay progresses over time.
+type OverlayEasing string
+
+// Possible OverlayEasing values.
+const (
+	OverlayEasingLinear    OverlayEasing = "linear"
+	OverlayEasingEaseIn    OverlayEasing = "ease-in"
+	OverlayEasingEaseOut   OverlayEasing = "ease-out"
+	OverlayEasingEaseInOut OverlayEasing = "ease-in-out"
+)
+
+// ease maps the given linear progress of an animation (from 0 to 1) to it's eased progress.
+func (e OverlayEasing) ease(progress float64) float64 {
+	switch e {
+	case OverlayEasingEaseIn:
+		return progress * progress
+	case OverlayEasingEaseOut:
+		return 1 - ((1 - progress) * (1 - progress))
+	case OverlayEasingEaseInOut:
+		return progress * progress * (3 - (2 * progress))
+	}
+
+	return progress
+}
+
+// OverlayWindows is how the windows on each workspace are shown in the overlay.
+type OverlayWindows string
+
+// Possible OverlayWindows values.
+const (
+	// OverlayWindowsNone doesn't show windows in the overlay at all.
+	OverlayWindowsNone OverlayWindows = "none"
+	// OverlayWindowsIcon shows each window's application icon, falling back to it's class.
+	OverlayWindowsIcon OverlayWindows = "icon"
+	// OverlayWindowsClass shows each window's class (i.e. the application name).
+	OverlayWindowsClass OverlayWindows = "class"
+	// OverlayWindowsTitle shows each window's title.
+	OverlayWindowsTitle OverlayWindows = "title"
+)
+
+// NewOverlayWindows returns the OverlayWindows value with the given name, or an error if the name
+// isn't valid.
+func NewOverlayWindows(name string) (OverlayWindows, error) {
+	windows := OverlayWindows(name)
+
+	switch windows {
+	case OverlayWindowsNone, OverlayWindowsIcon, OverlayWindowsClass, OverlayWindowsTitle:
+		return windows, nil
+	}
+
+	return OverlayWindowsNone, fmt.Errorf("invalid overlay windows: %q", name)
+}
+
+// OverlayDefaultFormat is the default template used for the text in each cell of the overlay.
+const OverlayDefaultFormat = `{{.Number}}{{if .Label}}
+<small>{{.Label}}</small>{{end}}`
+
+// OverlayConfig is the user's configuration of the overlay's appearance. Any settings that are not
+// in the configuration file keep their default values.
+type OverlayConfig struct {
+	// Backend is how the overlay is shown. Changing it needs i3x3d to be restarted. The rest of
+	// the settings only apply to the gtk backend.
+	Backend OverlayBackendName `json:"backend"`
+	// CSS is the path to a CSS file that is applied on top of the built-in theme. Relative paths are
+	// relative to the configuration file.
+	CSS string `json:"css"`
+	// CellWidth is the minimum width of each cell, in pixels.
+	CellWidth int `json:"cell_width"`
+	// CellHeight is the minimum height of each cell, in pixels.
+	CellHeight int `json:"cell_height"`
+	// Spacing is the gap between each cell, in pixels.
+	Spacing int `json:"spacing"`
+	// OutputSpacing is the gap between each output's grid, in pixels, when every output is shown.
+	OutputSpacing int `json:"output_spacing"`
+	// Margin is the gap between the overlay and the edge of the output, when it's in a corner.
+	Margin int `json:"margin"`
+	// Font is a CSS font value (e.g. "12px Monospace") used for the text in the overlay.
+	Font string `json:"font"`
+	// Format is a template for the Pango markup shown in each cell. See OverlayCell for the values
+	// available to it.
+	Format string `json:"format"`
+	// Position is where the overlay is shown.
+	Position OverlayPosition `json:"position"`
+	// DarkTheme is whether or not to ask the GTK theme for it's dark variant.
+	DarkTheme bool `json:"dark_theme"`
+	// AnimationDuration is how long the overlay's animations last, in milliseconds. The highlight
+	// slides from the current workspace to the target workspace, and the overlay fades out instead
+	// of disappearing. Animations are disabled if this is 0.
+	AnimationDuration int `json:"animation_duration"`
+	// Easing is how the overlay's animations progress over time.
+	Easing OverlayEasing `json:"easing"`
+	// Hide is when the overlay is hidden after a switch.
+	Hide OverlayHide `json:"hide"`
+}
+
+// OverlayCell is the data given to the format template of each cell in the overlay.
+type OverlayCell struct {
+	// Number is the workspace number.
+	Number int
+	// X is the column of the cell, starting from 1.
+	X int
+	// Y is the row of the cell, starting from 1.
+	Y int
+	// Label is the workspace's label, if it has one.
+	Label string
+}
+
+// DefaultOverlayConfig returns the configuration used if the user hasn't configured the overlay.
+func DefaultOverlayConfig() OverlayConfig {
+	return OverlayConfig{
+		Backend:       OverlayBackendDefault,
+		CellWidth:     50,
+		CellHeight:    50,
+		Margin:        20,
+		OutputSpacing: overlay.DefaultTheme().OutputSpacing,
+		Format:        OverlayDefaultFormat,
+		Position:      OverlayPositionCenter,
+		DarkTheme:     true,
+		Easing:        OverlayEasingEaseOut,
+		Hide:          OverlayHideTimeout,
+	}
+}
+
+// DefaultOverlayConfigPath returns the default location of the overlay configuration file,
+// following the XDG base directory specification.
+func DefaultOverlayConfigPath() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+
+	return filepath.Join(configHome, "i3x3", "overlay.json")
+}
+
+// loadedOverlayConfig is an overlay configuration, along with everything that has been loaded and
+// parsed based on it.
+type loadedOverlayConfig struct {
+	OverlayConfig
+
+	// format is the parsed cell format template.
+	format *template.Template
+	// css is the user's CSS, including any generated from the configuration.
+	css string
+	// stamp identifies the versions of the files the configuration was loaded from.
+	stamp string
+}
+
+// cellMarkup renders the cell format template for the given cell.
+func (c loadedOverlayConfig) cellMarkup(cell OverlayCell) (string, error) {
+	var buf bytes.Buffer
+
+	err := c.format.Execute(&buf, cell)
+	if err != nil {
+		return "", fmt.Errorf("workspace/overlay: error rendering format: %v", err)
+	}
+
+	return buf.String(), nil
+}
+
+// defaultLoadedOverlayConfig returns the default configuration, ready to use.
+func defaultLoadedOverlayConfig() loadedOverlayConfig {
+	return loadedOverlayConfig{
+		OverlayConfig: DefaultOverlayConfig(),
+		format:        template.Must(template.New("format").Parse(OverlayDefaultFormat)),
+	}
+}
+
+// overlayConfigStamp returns a string that changes whenever the configuration file at the given
+// path, or the CSS file it refers to, is changed. Files that don't exist are included as such, so
+// creating or removing them also changes the stamp.
+func overlayConfigStamp(path string, cssPath string) string {
+	stamp := func(path string) string {
+		if path == "" {
+			return ""
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return "missing"
+		}
+
+		return info.ModTime().Format(time.RFC3339Nano)
+	}
+
+	return stamp(path) + "|" + stamp(cssPath)
+}
+
+// loadOverlayConfig reads the overlay configuration from the given path, falling back to the
+// defaults for anything that isn't set. If the file doesn't exist, the defaults are used.
+func loadOverlayConfig(path string) (loadedOverlayConfig, error) {
+	config := defaultLoadedOverlayConfig()
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return config, fmt.Errorf("workspace/overlay: error reading config: %v", err)
+	}
+
+	if err == nil {
+		err = json.Unmarshal(bs, &config.OverlayConfig)
+		if err != nil {
+			return config, fmt.Errorf("workspace/overlay: error decoding config: %v", err)
+		}
+	}
+
+	switch config.Backend {
+	case OverlayBackendGTK, OverlayBackendNotification, OverlayBackendTerminal, OverlayBackendNone:
+	default:
+		return config, fmt.Errorf("workspace/overlay: invalid backend: %q", config.Backend)
+	}
+
+	switch config.Position {
+	case OverlayPositionCenter, OverlayPositionTopLeft, OverlayPositionTopRight,
+		OverlayPositionBottomLeft, OverlayPositionBottomRight, OverlayPositionMouse:
+	default:
+		return config, fmt.Errorf("workspace/overlay: invalid position: %q", config.Position)
+	}
+
+	switch config.Easing {
+	case OverlayEasingLinear, OverlayEasingEaseIn, OverlayEasingEaseOut, OverlayEasingEaseInOut:
+	default:
+		return config, fmt.Errorf("workspace/overlay: invalid easing: %q", config.Easing)
+	}
+
+	switch config.Hide {
+	case OverlayHideTimeout, OverlayHideRelease:
+	default:
+		return config, fmt.Errorf("workspace/overlay: invalid hide: %q", config.Hide)
+	}
+
+	if config.OutputSpacing < 0 {
+		return config, fmt.Errorf("workspace/overlay: invalid output spacing: %d", config.OutputSpacing)
+	}
+
+	if config.AnimationDuration < 0 {
+		return config, fmt.Errorf("workspace/overlay: invalid animation duration: %d", config.AnimationDuration)
+	}
+
+	config.format, err = template.New("format").Parse(config.Format)
+	if err != nil {
+		return config, fmt.Errorf("workspace/overlay: error parsing format: %v", err)
+	}
+
+	if config.Font != "" {
+		config.css = fmt.Sprintf(".i3x3-window { font: %s; }\n", config.Font)
+	}
+
+	cssPath := config.cssPath(path)
+	if cssPath != "" {
+		css, err := ioutil.ReadFile(cssPath)
+		if err != nil {
+			return config, fmt.Errorf("workspace/overlay: error reading css: %v", err)
+		}
+
+		config.css += string(css)
+	}
+
+	config.stamp = overlayConfigStamp(path, cssPath)
+
+	return config, nil
+}
+
+// cssPath returns the path to the user's CSS file, resolved relative to the given configuration
+// file path. If there isn't one, an empty string is returned.
+func (c OverlayConfig) cssPath(configPath string) string {
+	if c.CSS == "" || filepath.IsAbs(c.CSS) {
+		return c.CSS
+	}
+
+	return filepath.Join(filepath.Dir(configPath), c.CSS)
+}
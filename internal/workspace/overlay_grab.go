@@ -0,0 +1,38 @@
+//go:build !nogtk
+// +build !nogtk
+
+package workspace
+
+// #cgo pkg-config: gdk-3.0
+// #include <gdk/gdk.h>
+//
+// static GdkGrabStatus i3x3_grab_seat(GdkWindow *window) {
+// 	GdkSeat *seat = gdk_display_get_default_seat(gdk_window_get_display(window));
+// 	GdkSeatCapabilities caps = GDK_SEAT_CAPABILITY_KEYBOARD | GDK_SEAT_CAPABILITY_ALL_POINTING;
+//
+// 	return gdk_seat_grab(seat, window, caps, TRUE, NULL, NULL, NULL, NULL);
+// }
+//
+// static void i3x3_ungrab_seat(GdkWindow *window) {
+// 	gdk_seat_ungrab(gdk_display_get_default_seat(gdk_window_get_display(window)));
+// }
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/gotk3/gotk3/gdk"
+)
+
+// grabSeat grabs the keyboard and pointer for the given window, so that every key press and click
+// is sent to it, wherever the pointer is. Events for the window itself are reported as normal. It
+// returns false if the grab couldn't be taken, e.g. because another client has already grabbed the
+// keyboard, or the window isn't viewable yet.
+func grabSeat(window *gdk.Window) bool {
+	return C.i3x3_grab_seat((*C.GdkWindow)(unsafe.Pointer(window.GObject))) == C.GDK_GRAB_SUCCESS
+}
+
+// ungrabSeat releases a grab taken with grabSeat.
+func ungrabSeat(window *gdk.Window) {
+	C.i3x3_ungrab_seat((*C.GdkWindow)(unsafe.Pointer(window.GObject)))
+}
@@ -0,0 +1,17 @@
+//go:build !nogtk
+// +build !nogtk
+
+package workspace
+
+import (
+	"github.com/inconshreveable/log15"
+	"github.com/seeruk/i3x3/internal/daemon"
+)
+
+// OverlayBackendDefault is the overlay backend used if one hasn't been configured.
+const OverlayBackendDefault = OverlayBackendGTK
+
+// newGTKOverlay creates the GTK overlay thread.
+func newGTKOverlay(logger log15.Logger, opts OverlayOptions, msgCh <-chan SwitchMessage) (daemon.Thread, error) {
+	return NewOverlayThread(logger, opts.ConfigPath, opts.Windows, opts.AllOutputs, opts.Thumbnails, msgCh), nil
+}
@@ -0,0 +1,20 @@
+//go:build nogtk
+// +build nogtk
+
+package workspace
+
+import (
+	"errors"
+
+	"github.com/inconshreveable/log15"
+	"github.com/seeruk/i3x3/internal/daemon"
+)
+
+// OverlayBackendDefault is the overlay backend used if one hasn't been configured. Without GTK,
+// desktop notifications are the next best thing.
+const OverlayBackendDefault = OverlayBackendNotification
+
+// newGTKOverlay returns an error, because i3x3 was built without GTK.
+func newGTKOverlay(logger log15.Logger, opts OverlayOptions, msgCh <-chan SwitchMessage) (daemon.Thread, error) {
+	return nil, errors.New("workspace/overlay: the gtk overlay is unavailable, i3x3 was built with the nogtk tag")
+}
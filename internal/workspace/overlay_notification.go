@@ -0,0 +1,44 @@
+package workspace
+
+import (
+	"fmt"
+
+	"github.com/seeruk/i3x3/internal/notify"
+	"github.com/seeruk/i3x3/internal/overlay"
+)
+
+// NotificationOverlay is an overlay backend that shows the overlay as a desktop notification,
+// through whatever notification daemon is running (e.g. dunst). The grid is shown as text in the
+// notification's body.
+type NotificationOverlay struct {
+	notifier   *notify.Notifier
+	allOutputs bool
+}
+
+// NewNotificationOverlay creates a new notification overlay backend. If allOutputs is set, the grid
+// of every active output is shown, not just the current output's.
+func NewNotificationOverlay(allOutputs bool) *NotificationOverlay {
+	return &NotificationOverlay{
+		notifier:   notify.NewNotifier("i3x3"),
+		allOutputs: allOutputs,
+	}
+}
+
+// Show shows a notification for the given message, replacing the previous one.
+func (o *NotificationOverlay) Show(msg SwitchMessage) error {
+	summary := fmt.Sprintf("Workspace %d", int(msg.Target))
+	if label := msg.Labels[int(msg.Target)]; label != "" {
+		summary += ": " + label
+	}
+
+	body := overlay.RenderText(overlay.NewLayout(msg.overlayState(), o.allOutputs))
+
+	return o.notifier.Notify(summary, body, OverlayDuration)
+}
+
+// Close closes the connection to the notification service.
+func (o *NotificationOverlay) Close() error {
+	o.notifier.Close()
+
+	return nil
+}
@@ -0,0 +1,273 @@
+//go:build !nogtk
+// +build !nogtk
+
+package workspace
+
+import (
+	"time"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/overlay"
+)
+
+// OverlayPickGrabInterval is how long to wait between attempts to grab the keyboard and pointer
+// for a pick.
+const OverlayPickGrabInterval = 20 * time.Millisecond
+
+// OverlayPickGrabAttempts is how many times grabbing the keyboard and pointer for a pick is
+// attempted, before giving up.
+const OverlayPickGrabAttempts = 25
+
+// pickState is the state of an interactive pick in the overlay.
+type pickState struct {
+	msg SwitchMessage
+
+	// selected is the index of the selected cell in the current output's grid, counting the cells
+	// on every page before the selected cell's page.
+	selected int
+	// grabbed is set once the keyboard and pointer have been grabbed for the pick window.
+	grabbed bool
+}
+
+// workspace returns the workspace in the cell at the given index of the current output's grid.
+func (p *pickState) workspace(index int) int {
+	env := p.msg.Environment
+
+	return env.CurrentOutput + env.ActiveOutputs*index
+}
+
+// startPick takes a pick message, and shows the pick window with the current workspace selected.
+// Any pick that is already in progress is cancelled.
+func (t *OverlayThread) startPick(msg SwitchMessage) bool {
+	t.reloadConfig()
+
+	if t.pick != nil {
+		t.finishPick(PickResult{Cancelled: true})
+	}
+
+	t.window.Hide()
+
+	env := msg.Environment
+	pick := &pickState{
+		msg:      msg,
+		selected: grid.WorkspaceGridPosition(env.CurrentWorkspace, env.ActiveOutputs) - 1,
+	}
+
+	// If the current workspace isn't on the grid, the first cell is selected instead.
+	if pick.selected < 0 {
+		pick.selected = 0
+	}
+
+	t.pick = pick
+
+	// If the switcher stops waiting (e.g. the pick timed out), there's no point leaving the pick
+	// window open.
+	go func() {
+		<-msg.Context.Done()
+
+		glib.IdleAdd(func() bool {
+			if t.pick == pick {
+				t.finishPick(PickResult{Cancelled: true})
+			}
+
+			return false
+		})
+	}()
+
+	t.renderPick()
+
+	t.pickWindow.ShowAll()
+	t.placeWindow(t.pickWindow, msg)
+	t.pickWindow.Present()
+
+	t.grabPick(pick)
+
+	return false
+}
+
+// grabPick grabs the keyboard and pointer for the pick window, so that every key press and click
+// goes to it. The key binding that started the pick usually still has the keyboard grabbed, and the
+// window may not be mapped yet, so the grab is retried for a little while. If it still can't be
+// taken, the pick carries on without it, and is cancelled if the pick window loses focus.
+func (t *OverlayThread) grabPick(pick *pickState) {
+	attempts := 0
+
+	grab := func() bool {
+		if t.pick != pick {
+			return false
+		}
+
+		window, err := t.pickWindow.GetWindow()
+		if err == nil && grabSeat(window) {
+			pick.grabbed = true
+			return false
+		}
+
+		attempts++
+		if attempts >= OverlayPickGrabAttempts {
+			t.logger.Warn("couldn't grab the keyboard and pointer for picking")
+			return false
+		}
+
+		return true
+	}
+
+	if grab() {
+		glib.TimeoutAdd(uint(OverlayPickGrabInterval/time.Millisecond), grab)
+	}
+}
+
+// renderPick rebuilds the pick window's grid, highlighting the selected cell.
+func (t *OverlayThread) renderPick() {
+	msg := t.pick.msg
+	msg.Target = t.pick.workspace(t.pick.selected)
+
+	t.pickWindow.GetChildren().Foreach(func(item interface{}) {
+		t.pickWindow.Remove(item.(*gtk.Widget))
+	})
+
+	g := overlay.NewGrid(msg.overlayState(), msg.Environment.CurrentOutput)
+
+	ogrid, _ := t.buildGrid(msg, g, t.pickWorkspace)
+	t.pickWindow.Add(ogrid)
+	t.pickWindow.ShowAll()
+}
+
+// pickWorkspace finishes the current pick, with the given workspace picked.
+func (t *OverlayThread) pickWorkspace(ws int, move bool) {
+	t.finishPick(PickResult{
+		Workspace: ws,
+		Move:      move,
+	})
+}
+
+// finishPick sends the given result to the switcher, and hides the pick window.
+func (t *OverlayThread) finishPick(res PickResult) {
+	if t.pick == nil {
+		return
+	}
+
+	select {
+	case t.pick.msg.PickCh <- res:
+	default:
+	}
+
+	if t.pick.grabbed {
+		if window, err := t.pickWindow.GetWindow(); err == nil {
+			ungrabSeat(window)
+		}
+	}
+
+	t.pick = nil
+	t.pickWindow.Hide()
+}
+
+// handlePickKey handles key presses in the pick window. The arrow keys, or h, j, k, and l, move
+// the selection around the grid, and page up and page down move it between pages. Enter picks the
+// selected workspace (moving the focused container too if shift is held), and escape cancels the
+// pick.
+func (t *OverlayThread) handlePickKey(_ *gtk.Window, ev *gdk.Event) bool {
+	if t.pick == nil {
+		return false
+	}
+
+	key := gdk.EventKeyNewFromEvent(ev)
+	size := t.pick.msg.Size
+
+	cells := size.PageCells()
+
+	page := t.pick.selected / cells
+	row := (t.pick.selected % cells) / size.RealX
+	col := (t.pick.selected % cells) - (row * size.RealX)
+
+	switch key.KeyVal() {
+	case gdk.KEY_Left, gdk.KEY_h:
+		col--
+	case gdk.KEY_Right, gdk.KEY_l:
+		col++
+	case gdk.KEY_Up, gdk.KEY_k:
+		row--
+	case gdk.KEY_Down, gdk.KEY_j:
+		row++
+	case gdk.KEY_Page_Up:
+		page--
+	case gdk.KEY_Page_Down:
+		page++
+	case gdk.KEY_Return, gdk.KEY_KP_Enter:
+		t.pickWorkspace(t.pick.workspace(t.pick.selected), key.State()&uint(gdk.SHIFT_MASK) != 0)
+		return true
+	case gdk.KEY_Escape:
+		t.finishPick(PickResult{Cancelled: true})
+		return true
+	default:
+		return false
+	}
+
+	// Like switching with a direction, the selection stops at the edges of the grid.
+	if col < 0 || col >= size.RealX || row < 0 || row >= size.RealY || page < 0 || page >= size.Pages() {
+		return true
+	}
+
+	t.pick.selected = (page * cells) + (row * size.RealX) + col
+	t.renderPick()
+
+	return true
+}
+
+// handlePickButton cancels the current pick if the user clicks away from the pick window. Clicks
+// anywhere are only sent to the pick window whilst it has the pointer grabbed. Clicks on a cell
+// are handled by the cell, so they never get here.
+func (t *OverlayThread) handlePickButton(_ *gtk.Window, ev *gdk.Event) bool {
+	if t.pick == nil {
+		return false
+	}
+
+	button := gdk.EventButtonNewFromEvent(ev)
+	width, height := t.pickWindow.GetSize()
+
+	if button.X() < 0 || button.Y() < 0 || button.X() >= float64(width) || button.Y() >= float64(height) {
+		t.finishPick(PickResult{Cancelled: true})
+		return true
+	}
+
+	return false
+}
+
+// handlePickFocusOut cancels the current pick if the pick window loses focus, e.g. because the
+// user clicked somewhere else. This is only needed if the keyboard and pointer couldn't be
+// grabbed; whilst they are, focus only changes because of the grab itself.
+func (t *OverlayThread) handlePickFocusOut(_ *gtk.Window, _ *gdk.Event) bool {
+	if t.pick != nil && t.pick.grabbed {
+		return false
+	}
+
+	t.finishPick(PickResult{Cancelled: true})
+
+	return false
+}
+
+// buildPickWindow creates the window used to interactively pick a workspace. Unlike the normal
+// overlay window, it's a dialog that takes focus, and grabs the keyboard and pointer once it's
+// shown, so that it receives key presses and clicks. i3 floats dialogs, so it isn't tiled.
+func buildPickWindow() *gtk.Window {
+	window, _ := gtk.WindowNew(gtk.WINDOW_TOPLEVEL)
+	window.AddEvents(int(gdk.BUTTON_PRESS_MASK))
+	window.SetAcceptFocus(true)
+	window.SetDecorated(false)
+	window.SetKeepAbove(true)
+	window.SetModal(true)
+	window.SetPosition(gtk.WIN_POS_CENTER_ALWAYS)
+	window.SetResizable(false)
+	window.SetSkipTaskbarHint(true)
+	window.SetTitle("i3x3 GTK WSS Pick")
+	window.SetTypeHint(gdk.WINDOW_TYPE_HINT_DIALOG)
+	window.Stick()
+
+	windowStyleContext, _ := window.GetStyleContext()
+	windowStyleContext.AddClass("i3x3-window")
+
+	return window
+}
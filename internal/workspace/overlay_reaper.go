@@ -0,0 +1,109 @@
+package workspace
+
+import (
+	"context"
+	"time"
+
+	"github.com/inconshreveable/log15"
+)
+
+// OverlayReleaseInterval is how often the keyboard is checked, whilst waiting for the modifiers to
+// be released to hide the overlay.
+const OverlayReleaseInterval = 20 * time.Millisecond
+
+// Modifiers reads which modifier keys are currently held down. It's satisfied by xserver.Keyboard.
+type Modifiers interface {
+	Modifiers() (uint16, error)
+}
+
+// OverlayReaper decides when to hide the overlay after it's been shown.
+type OverlayReaper struct {
+	logger   log15.Logger
+	keyboard Modifiers
+	hideMode func() OverlayHide
+	hide     func()
+
+	// Duration is how long the overlay is shown for, if it's not waiting for modifiers.
+	Duration time.Duration
+	// Interval is how often the modifiers are polled, whilst waiting for them to be released.
+	Interval time.Duration
+}
+
+// NewOverlayReaper creates a new OverlayReaper, that calls hide when the overlay should be hidden.
+// The hide mode is read every time the overlay is shown, so that it can be reconfigured.
+func NewOverlayReaper(logger log15.Logger, keyboard Modifiers, hideMode func() OverlayHide, hide func()) *OverlayReaper {
+	return &OverlayReaper{
+		logger:   logger,
+		keyboard: keyboard,
+		hideMode: hideMode,
+		hide:     hide,
+		Duration: OverlayDuration,
+		Interval: OverlayReleaseInterval,
+	}
+}
+
+// Run waits for a set amount of time after each message before hiding the overlay. If another
+// message comes in whilst the overlay is shown, it's life is extended. If the overlay is configured
+// to hide when the binding's modifiers are released, and some are held, the keyboard is polled
+// instead. If the keyboard can't be read, the timer is used. Run blocks until the context is done.
+func (r *OverlayReaper) Run(ctx context.Context, messages <-chan struct{}) {
+	var timer *time.Timer
+	var ticker *time.Ticker
+	var ticks <-chan time.Time
+	var held uint16
+
+	stop := func() {
+		if timer != nil {
+			timer.Stop()
+		}
+
+		if ticker != nil {
+			ticker.Stop()
+			ticker = nil
+			ticks = nil
+		}
+	}
+
+	for {
+		select {
+		case <-messages:
+			stop()
+
+			// The modifiers are read as soon as possible, so they're still held if the user is
+			// holding them to keep switching.
+			if r.hideMode() == OverlayHideRelease {
+				mods, err := r.keyboard.Modifiers()
+				if err != nil {
+					r.logger.Warn("couldn't read modifiers, falling back to timeout", "error", err)
+				}
+
+				if mods != 0 {
+					held = mods
+					ticker = time.NewTicker(r.Interval)
+					ticks = ticker.C
+					continue
+				}
+			}
+
+			timer = time.AfterFunc(r.Duration, r.hide)
+		case <-ticks:
+			mods, err := r.keyboard.Modifiers()
+			if err != nil {
+				r.logger.Warn("couldn't read modifiers, falling back to timeout", "error", err)
+
+				stop()
+				timer = time.AfterFunc(r.Duration, r.hide)
+				continue
+			}
+
+			// Hide as soon as any of the modifiers are released.
+			if mods&held != held {
+				stop()
+				r.hide()
+			}
+		case <-ctx.Done():
+			stop()
+			return
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package workspace_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/seeruk/i3x3/internal/workspace"
+)
+
+// fakeModifiers returns each of it's results in turn, and then keeps returning the last one.
+type fakeModifiers struct {
+	sync.Mutex
+	results []fakeModifiersResult
+}
+
+type fakeModifiersResult struct {
+	mods uint16
+	err  error
+}
+
+func (m *fakeModifiers) Modifiers() (uint16, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	res := m.results[0]
+	if len(m.results) > 1 {
+		m.results = m.results[1:]
+	}
+
+	return res.mods, res.err
+}
+
+// runReaper starts an OverlayReaper with the given hide mode and modifiers, sends it one message,
+// and returns a channel that receives whenever it hides the overlay, and a function to stop it.
+func runReaper(mode workspace.OverlayHide, duration, interval time.Duration, results ...fakeModifiersResult) (<-chan struct{}, context.CancelFunc) {
+	logger := log15.New()
+	logger.SetHandler(log15.DiscardHandler())
+
+	hidden := make(chan struct{}, 1)
+	hideMode := func() workspace.OverlayHide {
+		return mode
+	}
+
+	reaper := workspace.NewOverlayReaper(logger, &fakeModifiers{results: results}, hideMode, func() {
+		hidden <- struct{}{}
+	})
+
+	reaper.Duration = duration
+	reaper.Interval = interval
+
+	ctx, cfn := context.WithCancel(context.Background())
+
+	messages := make(chan struct{})
+	go reaper.Run(ctx, messages)
+
+	messages <- struct{}{}
+
+	return hidden, cfn
+}
+
+func TestOverlayReaper(t *testing.T) {
+	const mod = 0x40
+	errX := errors.New("lost connection to X")
+
+	var tests = []struct {
+		name     string
+		mode     workspace.OverlayHide
+		duration time.Duration
+		interval time.Duration
+		results  []fakeModifiersResult
+		hides    bool
+	}{
+		// The ticker is never used for timeouts, so only the timer can hide the overlay.
+		{"timeout", workspace.OverlayHideTimeout, time.Millisecond, time.Hour, []fakeModifiersResult{{mod, nil}}, true},
+		// The timer is too long to fire, so only releasing the modifiers can hide the overlay.
+		{"released", workspace.OverlayHideRelease, time.Hour, time.Millisecond, []fakeModifiersResult{{mod, nil}, {mod, nil}, {0, nil}}, true},
+		{"partially released", workspace.OverlayHideRelease, time.Hour, time.Millisecond, []fakeModifiersResult{{mod | 0x1, nil}, {mod, nil}}, true},
+		{"held", workspace.OverlayHideRelease, time.Millisecond, time.Millisecond, []fakeModifiersResult{{mod, nil}}, false},
+		{"nothing held", workspace.OverlayHideRelease, time.Millisecond, time.Hour, []fakeModifiersResult{{0, nil}}, true},
+		// The ticker is too long to fire, so only the timer can hide the overlay.
+		{"error when shown", workspace.OverlayHideRelease, time.Millisecond, time.Hour, []fakeModifiersResult{{0, errX}}, true},
+		{"error whilst held", workspace.OverlayHideRelease, time.Millisecond, time.Millisecond, []fakeModifiersResult{{mod, nil}, {0, errX}, {mod, nil}}, true},
+	}
+
+	for _, test := range tests {
+		hidden, cfn := runReaper(test.mode, test.duration, test.interval, test.results...)
+
+		select {
+		case <-hidden:
+			if !test.hides {
+				t.Errorf("Expected overlay not to be hidden (%s)", test.name)
+			}
+		case <-time.After(200 * time.Millisecond):
+			if test.hides {
+				t.Errorf("Expected overlay to be hidden (%s)", test.name)
+			}
+		}
+
+		cfn()
+	}
+}
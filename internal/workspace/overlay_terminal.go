@@ -0,0 +1,38 @@
+package workspace
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/seeruk/i3x3/internal/overlay"
+)
+
+// TerminalOverlay is an overlay backend that draws the overlay as text, redrawing the whole terminal
+// for every switch. The last grid is left on the terminal, so it's handy for keeping an eye on the
+// grid in a terminal of it's own.
+type TerminalOverlay struct {
+	out        io.Writer
+	allOutputs bool
+}
+
+// NewTerminalOverlay creates a new terminal overlay backend, that draws the overlay to the given
+// writer. If allOutputs is set, the grid of every active output is shown, not just the current
+// output's.
+func NewTerminalOverlay(allOutputs bool, out io.Writer) *TerminalOverlay {
+	return &TerminalOverlay{
+		out:        out,
+		allOutputs: allOutputs,
+	}
+}
+
+// Show redraws the terminal with the grid for the given message.
+func (o *TerminalOverlay) Show(msg SwitchMessage) error {
+	text := overlay.RenderText(overlay.NewLayout(msg.overlayState(), o.allOutputs))
+
+	_, err := fmt.Fprint(o.out, overlay.TerminalClear+text)
+	if err != nil {
+		return fmt.Errorf("workspace/overlay: error writing to terminal: %v", err)
+	}
+
+	return nil
+}
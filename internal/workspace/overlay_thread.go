@@ -1,40 +1,91 @@
+//go:build !nogtk
+// +build !nogtk
+
 package workspace
 
 import (
 	"context"
 	"fmt"
+	"image"
+	"strings"
 	"sync"
-	"time"
 
 	"github.com/gotk3/gotk3/gdk"
 	"github.com/gotk3/gotk3/glib"
 	"github.com/gotk3/gotk3/gtk"
 	"github.com/inconshreveable/log15"
-	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/overlay"
+	"github.com/seeruk/i3x3/internal/xserver"
 )
 
-// OverlayDuration specifies how long the overlay will stay on the screen for.
-const OverlayDuration = 500 * time.Millisecond
+// OverlayMaxWindows is the maximum number of windows shown in each cell of the overlay. Any more
+// than this are summarised as a count.
+const OverlayMaxWindows = 3
+
+// OverlayIconSize is the size, in pixels, of the application icons shown in the overlay.
+const OverlayIconSize = 16
+
+// OverlayMaxWindowChars is the maximum number of characters of a window's name shown in the overlay.
+const OverlayMaxWindowChars = 12
 
 // OverlayThread is a long-running process than handles showing the GTK-based overlay.
 type OverlayThread struct {
 	sync.Mutex
 
-	ctx    context.Context
-	cfn    context.CancelFunc
-	logger log15.Logger
-	window *gtk.Window
+	ctx     context.Context
+	cfn     context.CancelFunc
+	logger  log15.Logger
+	window  *gtk.Window
+	windows OverlayWindows
+
+	// configPath is the path to the user's overlay configuration file.
+	configPath string
+	// config is the currently loaded overlay configuration.
+	config loadedOverlayConfig
+	// pickWindow is the window used to interactively pick a workspace. It can take focus, unlike the
+	// normal overlay window.
+	pickWindow *gtk.Window
+	// slide and fade are the overlay's animations that are currently running, if any. They must only
+	// be accessed on the GTK main thread.
+	slide *animation
+	fade  *animation
+
+	// pick is the state of the current interactive pick, if there is one. It must only be accessed
+	// on the GTK main thread.
+	pick *pickState
+
+	// userCSS is the style provider for the user's CSS, replaced whenever the config is reloaded.
+	userCSS *gtk.CssProvider
+
+	// allOutputs enables showing the grid of every active output, not just the current output.
+	allOutputs bool
+
+	// thumbnails is told whenever the overlay's windows are mapped or unmapped. It may be nil.
+	thumbnails *Thumbnails
+
+	// keyboard is used to see if the modifiers used to switch are still held.
+	keyboard *xserver.Keyboard
+	// hide is the configured OverlayHide, for the window reaper. It's guarded by the mutex.
+	hide OverlayHide
 
 	msgCh <-chan SwitchMessage
 }
 
 // NewOverlayThread creates a new workspace overlay thread.
-func NewOverlayThread(logger log15.Logger, msgCh <-chan SwitchMessage) *OverlayThread {
+func NewOverlayThread(logger log15.Logger, configPath string, windows OverlayWindows, allOutputs bool, thumbnails *Thumbnails, msgCh <-chan SwitchMessage) *OverlayThread {
 	logger = logger.New("module", "workspace/overlayThread")
 
 	return &OverlayThread{
-		logger: logger,
-		msgCh:  msgCh,
+		logger:     logger,
+		windows:    windows,
+		configPath: configPath,
+		config:     loadedOverlayConfig{stamp: "unloaded"},
+		allOutputs: allOutputs,
+		thumbnails: thumbnails,
+		keyboard:   xserver.NewKeyboard(),
+		hide:       OverlayHideTimeout,
+		msgCh:      msgCh,
 	}
 }
 
@@ -49,22 +100,42 @@ func (t *OverlayThread) Start() error {
 
 	t.Lock()
 	t.window = buildWindow()
+	t.pickWindow = buildPickWindow()
 	t.Unlock()
 
-	// Use dark theme.
-	settings, _ := gtk.SettingsGetDefault()
-	settings.SetProperty("gtk-application-prefer-dark-theme", true)
+	t.pickWindow.Connect("key-press-event", t.handlePickKey)
+	t.pickWindow.Connect("button-press-event", t.handlePickButton)
+	t.pickWindow.Connect("focus-out-event", t.handlePickFocusOut)
+
+	for _, window := range []*gtk.Window{t.window, t.pickWindow} {
+		window.Connect("map", t.handleMapped)
+		window.Connect("unmap", t.handleMapped)
+	}
+
+	// The built-in theme is applied to the whole screen once, so it doesn't need parsing again for
+	// every message, or adding to every widget.
+	screen, _ := gdk.ScreenGetDefault()
+	cssProvider, _ := gtk.CssProviderNew()
+	cssProvider.LoadFromData(overlay.CSS(overlay.DefaultTheme()))
+	gtk.AddProviderForScreen(screen, cssProvider, uint(gtk.STYLE_PROVIDER_PRIORITY_APPLICATION))
+
+	t.reloadConfig()
 
 	reaperChan := make(chan struct{})
 
+	reaper := NewOverlayReaper(t.logger, t.keyboard, t.hideMode, func() {
+		glib.IdleAdd(t.hideWindow)
+	})
+
 	go t.enqueueMessages(reaperChan)
-	go t.windowReaper(reaperChan)
+	go reaper.Run(t.ctx, reaperChan)
 
 	t.logger.Info("thread started")
 
 	// This is a blocking call.
 	gtk.Main()
 
+	t.keyboard.Close()
 	t.logger.Info("thread stopped")
 
 	return nil
@@ -81,79 +152,398 @@ func (t *OverlayThread) Stop() error {
 	return nil
 }
 
+// handleMapped tells the thumbnail cache whether any of the overlay's windows are mapped, whenever
+// one of them is mapped or unmapped.
+func (t *OverlayThread) handleMapped() {
+	if t.thumbnails != nil {
+		t.thumbnails.SetOverlayMapped(t.window.GetMapped() || t.pickWindow.GetMapped())
+	}
+}
+
 // handleMessage takes a message and updates the window UI appropriately, finally showing the
 // window (if it's not already visible) at the end.
 func (t *OverlayThread) handleMessage(msg SwitchMessage) bool {
-	// Set up custom styles
-	cssProvider, _ := gtk.CssProviderNew()
-	cssProvider.LoadFromData(`
-			.i3x3-grid {
-				background: #2A2A2A;
-				padding: 3px;
-			}
-
-			.i3x3-grid__box {
-				background: #1A1A1A;
-			}
-
-			.i3x3-grid__box--active {
-				background: #2A2A2A;
-				color: #FFFFFF;
-				font-weight: bold;
-			}
-		`)
-
-	size := grid.NewSize(msg.Environment, 3, 3)
+	// Pick up any changes to the user's configuration, without needing a restart.
+	t.reloadConfig()
 
 	// Remove all children...
 	t.window.GetChildren().Foreach(func(item interface{}) {
 		t.window.Remove(item.(*gtk.Widget))
 	})
 
+	// Stop any animations of the previous message, and make sure the window isn't left faded out.
+	if t.slide != nil {
+		t.slide.stop()
+		t.slide = nil
+	}
+
+	if t.fade != nil {
+		t.fade.stop()
+		t.fade = nil
+	}
+
+	t.window.SetOpacity(1)
+
+	layout := overlay.NewLayout(msg.overlayState(), t.allOutputs)
+
+	if len(layout.Grids) > 1 {
+		t.window.Add(t.buildOutputs(msg, layout))
+	} else {
+		ogrid, cells := t.buildGrid(msg, layout.Grids[0], nil)
+		t.window.Add(t.animateHighlight(msg, ogrid, cells))
+	}
+
+	t.window.ShowAll()
+	t.placeWindow(t.window, msg)
+
+	return false
+}
+
+// buildOutputs creates a grid for every active output, arranged in the same way as the outputs are
+// physically arranged. The current output's grid is emphasised.
+func (t *OverlayThread) buildOutputs(msg SwitchMessage, layout overlay.Layout) *gtk.Grid {
+	outputs, _ := gtk.GridNew()
+	outputs.SetRowSpacing(uint(t.config.OutputSpacing))
+	outputs.SetColumnSpacing(uint(t.config.OutputSpacing))
+
+	outputsSC, _ := outputs.GetStyleContext()
+	outputsSC.AddClass("i3x3-outputs")
+
+	for _, g := range layout.Grids {
+		name, _ := gtk.LabelNew(g.Name)
+
+		nameSC, _ := name.GetStyleContext()
+		nameSC.AddClass("i3x3-output__name")
+
+		box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 3)
+		box.PackStart(name, false, false, 0)
+		ogrid, cells := t.buildGrid(msg, g, nil)
+		box.PackStart(t.animateHighlight(msg, ogrid, cells), false, false, 0)
+
+		boxSC, _ := box.GetStyleContext()
+		boxSC.AddClass("i3x3-output")
+
+		if g.Current {
+			boxSC.AddClass("i3x3-output--current")
+		}
+
+		outputs.Attach(box, g.Column, g.Row, 1, 1)
+	}
+
+	return outputs
+}
+
+// buildGrid creates the widgets for the given grid of workspaces, returning it along with each of
+// it's cells, keyed by workspace number. If a pick function is given, it's called with
+// the cell's workspace when a cell is clicked, and whether or not the move modifier was held.
+func (t *OverlayThread) buildGrid(msg SwitchMessage, g overlay.Grid, pick func(ws int, move bool)) (*gtk.Grid, map[int]*gtk.EventBox) {
+	cells := make(map[int]*gtk.EventBox, len(g.Cells))
+
 	ogrid, _ := gtk.GridNew()
+	ogrid.SetRowSpacing(uint(t.config.Spacing))
+	ogrid.SetColumnSpacing(uint(t.config.Spacing))
 
 	ogridStyleContext, _ := ogrid.GetStyleContext()
 	ogridStyleContext.AddClass("i3x3-grid")
-	ogridStyleContext.AddProvider(cssProvider, 1)
 
-	labelCount := size.RealX * size.RealY
+	for _, c := range g.Cells {
+		ws := c.Workspace
 
-	for i := 0; i < labelCount; i++ {
-		iao := int(msg.Environment.ActiveOutputs)
-		ico := int(msg.Environment.CurrentOutput)
+		markup, err := t.config.cellMarkup(OverlayCell{
+			Number: ws,
+			X:      c.Column + 1,
+			Y:      c.Row + 1,
+			Label:  c.Label,
+		})
 
-		ws := ico + (iao * i)
+		if err != nil {
+			t.logger.Error("error rendering cell", "error", err)
+			markup = fmt.Sprintf("%d", ws)
+		}
 
 		label, _ := gtk.LabelNew("")
-		label.SetMarkup(fmt.Sprintf("%d", int(ws)))
+		label.SetJustify(gtk.JUSTIFY_CENTER)
+		label.SetMarkup(markup)
+
+		cell, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 2)
+		cell.SetVAlign(gtk.ALIGN_CENTER)
+		cell.PackStart(label, false, false, 0)
+
+		if windows := t.buildWindows(c.Windows); windows != nil {
+			cell.PackStart(windows, false, false, 0)
+		}
 
 		box, _ := gtk.EventBoxNew()
-		box.SetSizeRequest(50, 50)
+		box.SetSizeRequest(t.config.CellWidth, t.config.CellHeight)
 
 		styles, _ := box.GetStyleContext()
 		styles.AddClass("i3x3-grid__box")
 
-		boxSC, _ := box.GetStyleContext()
-		boxSC.AddProvider(cssProvider, 1)
+		if c.Overflow {
+			styles.AddClass("i3x3-grid__box--overflow")
+		}
+
+		if c.Occupied {
+			styles.AddClass("i3x3-grid__box--occupied")
+		}
+
+		if c.Visible {
+			styles.AddClass("i3x3-grid__box--visible")
+		}
+
+		if c.Urgent {
+			styles.AddClass("i3x3-grid__box--urgent")
+		}
 
 		// Highlight the active workspace
-		if int(msg.Target) == ws {
+		if c.Active {
 			styles.AddClass("i3x3-grid__box--active")
 		}
 
-		box.Add(label)
+		if thumbnail := t.buildThumbnail(msg.Thumbnails[ws]); thumbnail != nil {
+			// The thumbnail goes behind the rest of the cell's contents.
+			background, _ := gtk.OverlayNew()
+			background.Add(thumbnail)
+			background.AddOverlay(cell)
 
-		row := i / size.RealX
-		col := i - (row * size.RealX)
+			box.Add(background)
+		} else {
+			box.Add(cell)
+		}
+
+		if pick != nil {
+			box.Connect("button-press-event", func(_ *gtk.EventBox, ev *gdk.Event) bool {
+				state := gdk.EventButtonNewFromEvent(ev).State()
+				pick(ws, state&uint(gdk.SHIFT_MASK) != 0)
+				return true
+			})
+		}
 
 		// Attach it to the correct place in the table
-		ogrid.Attach(box, col, row, 1, 1)
+		ogrid.Attach(box, c.Column, c.Row, 1, 1)
+
+		cells[ws] = box
 	}
 
-	t.window.Add(ogrid)
-	t.window.ShowAll()
+	if g.Pages > 1 {
+		page, _ := gtk.LabelNew(fmt.Sprintf("page %d/%d", g.Page, g.Pages))
 
-	return false
+		pageSC, _ := page.GetStyleContext()
+		pageSC.AddClass("i3x3-grid__page")
+
+		ogrid.Attach(page, 0, g.Rows, g.Columns, 1)
+	}
+
+	return ogrid, cells
+}
+
+// buildThumbnail creates an image of the given thumbnail, scaled to the size of a cell. If there is
+// no thumbnail, nil is returned.
+func (t *OverlayThread) buildThumbnail(thumbnail *image.RGBA) *gtk.Image {
+	if thumbnail == nil {
+		return nil
+	}
+
+	bounds := thumbnail.Bounds()
+
+	pixbuf, err := gdk.PixbufNewFromData(thumbnail.Pix, gdk.COLORSPACE_RGB, true, 8, bounds.Dx(), bounds.Dy(), thumbnail.Stride)
+	if err != nil {
+		t.logger.Warn("couldn't load thumbnail", "error", err)
+		return nil
+	}
+
+	// Scaling also copies the pixels, so GTK doesn't hold on to the thumbnail's memory.
+	scaled, err := pixbuf.ScaleSimple(t.config.CellWidth, t.config.CellHeight, gdk.INTERP_BILINEAR)
+	if err != nil {
+		t.logger.Warn("couldn't scale thumbnail", "error", err)
+		return nil
+	}
+
+	img, _ := gtk.ImageNewFromPixbuf(scaled)
+
+	styles, _ := img.GetStyleContext()
+	styles.AddClass("i3x3-grid__thumbnail")
+
+	return img
+}
+
+// placeWindow moves the given window to the configured position on the output that the target
+// workspace is on. If the outputs aren't known, the window is left where GTK put it.
+func (t *OverlayThread) placeWindow(window *gtk.Window, msg SwitchMessage) {
+	if t.config.Position == OverlayPositionMouse {
+		window.SetPosition(gtk.WIN_POS_MOUSE)
+		return
+	}
+
+	output, ok := expectedOutput(msg.Outputs, msg.Target)
+	if !ok {
+		window.SetPosition(gtk.WIN_POS_CENTER_ALWAYS)
+		return
+	}
+
+	rect := output.Rect
+	width, height := window.GetSize()
+	margin := t.config.Margin
+
+	x := rect.X + (rect.Width-width)/2
+	y := rect.Y + (rect.Height-height)/2
+
+	switch t.config.Position {
+	case OverlayPositionTopLeft:
+		x, y = rect.X+margin, rect.Y+margin
+	case OverlayPositionTopRight:
+		x, y = rect.X+rect.Width-width-margin, rect.Y+margin
+	case OverlayPositionBottomLeft:
+		x, y = rect.X+margin, rect.Y+rect.Height-height-margin
+	case OverlayPositionBottomRight:
+		x, y = rect.X+rect.Width-width-margin, rect.Y+rect.Height-height-margin
+	}
+
+	window.SetPosition(gtk.WIN_POS_NONE)
+	window.Move(x, y)
+}
+
+// reloadConfig loads the user's overlay configuration if it has changed since it was last loaded,
+// and applies it. If the configuration can't be loaded, the previous configuration is kept. This
+// must be called on the GTK main thread.
+func (t *OverlayThread) reloadConfig() {
+	cssPath := t.config.cssPath(t.configPath)
+	if overlayConfigStamp(t.configPath, cssPath) == t.config.stamp {
+		return
+	}
+
+	config, err := loadOverlayConfig(t.configPath)
+	if err != nil {
+		t.logger.Error("error loading overlay config", "error", err)
+
+		// Until a config has been loaded successfully, the defaults are used.
+		if t.config.format == nil {
+			t.applyConfig(defaultLoadedOverlayConfig())
+		}
+
+		// Don't try again until something changes.
+		t.config.stamp = overlayConfigStamp(t.configPath, config.cssPath(t.configPath))
+		return
+	}
+
+	t.logger.Debug("loaded overlay config", "path", t.configPath)
+	t.applyConfig(config)
+}
+
+// applyConfig replaces the user's CSS and GTK settings with those from the given configuration,
+// and makes it the current configuration.
+func (t *OverlayThread) applyConfig(config loadedOverlayConfig) {
+	screen, _ := gdk.ScreenGetDefault()
+
+	if t.userCSS != nil {
+		gtk.RemoveProviderForScreen(screen, t.userCSS)
+	}
+
+	t.userCSS, _ = gtk.CssProviderNew()
+	t.userCSS.LoadFromData(config.css)
+	gtk.AddProviderForScreen(screen, t.userCSS, uint(gtk.STYLE_PROVIDER_PRIORITY_USER))
+
+	settings, _ := gtk.SettingsGetDefault()
+	settings.SetProperty("gtk-application-prefer-dark-theme", config.DarkTheme)
+
+	t.config = config
+
+	// The window reaper runs in it's own goroutine, so it can't read the config directly.
+	t.Lock()
+	t.hide = config.Hide
+	t.Unlock()
+}
+
+// buildWindows creates the widget showing the given windows in a cell of the overlay, based on how
+// the overlay has been configured to show windows. If there's nothing to show, nil is returned.
+func (t *OverlayThread) buildWindows(windows []i3.WindowProperties) gtk.IWidget {
+	if t.windows == OverlayWindowsNone || len(windows) == 0 {
+		return nil
+	}
+
+	shown := windows
+	if len(shown) > OverlayMaxWindows {
+		shown = shown[:OverlayMaxWindows]
+	}
+
+	orientation := gtk.ORIENTATION_VERTICAL
+	if t.windows == OverlayWindowsIcon {
+		orientation = gtk.ORIENTATION_HORIZONTAL
+	}
+
+	container, _ := gtk.BoxNew(orientation, 2)
+	container.SetHAlign(gtk.ALIGN_CENTER)
+
+	styles, _ := container.GetStyleContext()
+	styles.AddClass("i3x3-grid__windows")
+
+	for _, window := range shown {
+		if t.windows == OverlayWindowsIcon {
+			if icon := loadWindowIcon(window); icon != nil {
+				icon.SetTooltipText(window.Title)
+				container.PackStart(icon, false, false, 0)
+				continue
+			}
+		}
+
+		name := window.Class
+		if t.windows == OverlayWindowsTitle {
+			name = window.Title
+		}
+
+		label, _ := gtk.LabelNew(truncate(name, OverlayMaxWindowChars))
+		label.SetMaxWidthChars(OverlayMaxWindowChars)
+		container.PackStart(label, false, false, 0)
+	}
+
+	if len(windows) > len(shown) {
+		more, _ := gtk.LabelNew(fmt.Sprintf("+%d", len(windows)-len(shown)))
+		container.PackStart(more, false, false, 0)
+	}
+
+	return container
+}
+
+// loadWindowIcon attempts to find an application icon for the given window in the current icon
+// theme. Icons are usually named after the window's class or instance, in lower case. If no icon
+// can be found, nil is returned.
+func loadWindowIcon(window i3.WindowProperties) *gtk.Image {
+	theme, err := gtk.IconThemeGetDefault()
+	if err != nil || theme == nil {
+		return nil
+	}
+
+	for _, name := range []string{window.Class, window.Instance} {
+		name = strings.ToLower(name)
+		if name == "" || !theme.HasIcon(name) {
+			continue
+		}
+
+		pixbuf, err := theme.LoadIcon(name, OverlayIconSize, gtk.ICON_LOOKUP_FORCE_SIZE)
+		if err != nil {
+			continue
+		}
+
+		image, err := gtk.ImageNewFromPixbuf(pixbuf)
+		if err != nil {
+			continue
+		}
+
+		return image
+	}
+
+	return nil
+}
+
+// truncate shortens the given string to at most max characters, adding an ellipsis if any
+// characters were removed.
+func truncate(str string, max int) string {
+	runes := []rune(str)
+	if len(runes) <= max {
+		return str
+	}
+
+	return string(runes[:max-1]) + "…"
 }
 
 // enqueueMessages routes incoming and outgoing messages, handling updating the overlay window.
@@ -161,6 +551,13 @@ func (t *OverlayThread) enqueueMessages(reaperCh chan<- struct{}) {
 	for {
 		select {
 		case msg := <-t.msgCh:
+			// Picking is interactive, so the window reaper is left alone.
+			if msg.PickCh != nil {
+				glib.IdleAdd(t.startPick, msg)
+				msg.ResponseCh <- nil
+				continue
+			}
+
 			// Show the overlay
 			glib.IdleAdd(t.handleMessage, msg)
 
@@ -173,37 +570,16 @@ func (t *OverlayThread) enqueueMessages(reaperCh chan<- struct{}) {
 	}
 }
 
-// windowReaper waits for a set amount of time before hiding the overlay window. If another message
-// comes in whilst the window is open, it's life is extended.
-func (t *OverlayThread) windowReaper(messages <-chan struct{}) {
-	var timer *time.Timer
-
-	for {
-		select {
-		case <-messages:
-			if timer != nil {
-				timer.Stop()
-			}
+// hideMode returns when the overlay should be hidden, as currently configured.
+func (t *OverlayThread) hideMode() OverlayHide {
+	t.Lock()
+	defer t.Unlock()
 
-			timer = time.AfterFunc(OverlayDuration, func() {
-				glib.IdleAdd(t.window.Hide)
-			})
-		case <-t.ctx.Done():
-			break
-		}
-	}
+	return t.hide
 }
 
 // buildWindow creates the basic window that our overlay grid goes into.
 func buildWindow() *gtk.Window {
-	cssProvider, _ := gtk.CssProviderNew()
-	cssProvider.LoadFromData(`
-			.i3x3-window {
-				background: #000000;
-				color: #D3D3D3;
-			}
-		`)
-
 	window, _ := gtk.WindowNew(gtk.WINDOW_POPUP)
 	window.SetAcceptFocus(false)
 	window.SetDecorated(false)
@@ -217,7 +593,6 @@ func buildWindow() *gtk.Window {
 
 	windowStyleContext, _ := window.GetStyleContext()
 	windowStyleContext.AddClass("i3x3-window")
-	windowStyleContext.AddProvider(cssProvider, 1)
 
 	return window
 }
@@ -0,0 +1,95 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+// PickResult is the outcome of the user interactively picking a workspace in the overlay.
+type PickResult struct {
+	// Workspace is the workspace that was picked.
+	Workspace int
+	// Move is set if the move modifier was held when the workspace was picked.
+	Move bool
+	// Cancelled is set if the user closed the overlay without picking a workspace.
+	Cancelled bool
+}
+
+// handlePick takes a pick command, and actions it. The overlay is shown for the user to pick a
+// workspace from, and then we switch to it in the same way as if a direction had been given.
+func (t *SwitchThread) handlePick(ctx context.Context, cmd proto.PickCommand) error {
+	// Picking with the move modifier held should move containers, even if no mode was given.
+	mode, err := NewMoveMode(proto.DaemonCommand{
+		Move:     true,
+		MoveMode: cmd.MoveMode,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	st, err := findState()
+	if err != nil {
+		return err
+	}
+
+	res, err := t.pick(ctx, st)
+	if err != nil || res.Cancelled {
+		return err
+	}
+
+	// Like jumping, switching to the current workspace would trigger i3's
+	// workspace_auto_back_and_forth, if the user has it enabled, so picking it does nothing.
+	if res.Workspace == st.env.CurrentWorkspace {
+		return nil
+	}
+
+	if !res.Move {
+		mode = MoveNone
+	}
+
+	return t.switchTo(st, res.Workspace, mode)
+}
+
+// pick sends a SwitchMessage to the overlay asking the user to pick a workspace, and then waits for
+// them to do so. The current workspace is selected to begin with.
+func (t *SwitchThread) pick(ctx context.Context, st state) (PickResult, error) {
+	var res PickResult
+
+	pickCh := make(chan PickResult, 1)
+
+	msg, responseCh := NewSwitchMessage(ctx, st.env, st.size, st.workspaces, st.env.CurrentWorkspace, t.labels.All(), t.tree.Windows(), sortedActiveOutputs(st.outputs))
+	msg.Thumbnails = t.thumbnails.All()
+	msg.PickCh = pickCh
+
+	select {
+	case t.outCh <- msg:
+		t.logger.Debug("sent pick message")
+	case <-t.ctx.Done():
+		return res, fmt.Errorf("workspace/switcher: sending: %v", t.ctx.Err())
+	case <-ctx.Done():
+		return res, fmt.Errorf("workspace/switcher: sending: timed out")
+	}
+
+	select {
+	case err := <-responseCh:
+		if err != nil {
+			return res, err
+		}
+	case <-t.ctx.Done():
+		return res, fmt.Errorf("workspace/switcher: receiving: %v", t.ctx.Err())
+	case <-ctx.Done():
+		return res, fmt.Errorf("workspace/switcher: receiving: timed out")
+	}
+
+	select {
+	case res = <-pickCh:
+		return res, nil
+	case <-t.ctx.Done():
+		return res, fmt.Errorf("workspace/switcher: picking: %v", t.ctx.Err())
+	case <-ctx.Done():
+		return res, fmt.Errorf("workspace/switcher: picking: timed out")
+	}
+}
@@ -0,0 +1,48 @@
+package workspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+func TestHandlePick(t *testing.T) {
+	var tests = []struct {
+		pick     PickResult
+		expected []string
+	}{
+		// Switching to the current workspace could take the user back to the previous one.
+		{PickResult{Workspace: 3}, nil},
+		{PickResult{Workspace: 3, Move: true}, nil},
+		{PickResult{Workspace: 5}, []string{"workspace number 5"}},
+		{PickResult{Workspace: 5, Move: true}, []string{"move container to workspace number 5", "workspace number 5"}},
+		{PickResult{Cancelled: true}, nil},
+	}
+
+	workspaces := []i3.Workspace{
+		{Num: 3, Name: "3", Output: "DP-1", Visible: true, Focused: true},
+	}
+
+	fake := newFakeI3(t, testOutputs()[:1], workspaces)
+	defer fake.close()
+
+	for _, test := range tests {
+		outCh := make(chan SwitchMessage, 1)
+
+		// The overlay picks the test's workspace as soon as it's asked.
+		go func(pick PickResult) {
+			msg := <-outCh
+			msg.ResponseCh <- nil
+			msg.PickCh <- pick
+		}(test.pick)
+
+		err := testSwitchThread(outCh).handlePick(context.Background(), proto.PickCommand{})
+		if err != nil {
+			t.Errorf("Expected no error picking %+v, got %v", test.pick, err)
+		}
+
+		expectCommands(t, fake.commands(), test.expected...)
+	}
+}
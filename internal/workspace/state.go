@@ -0,0 +1,223 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/overlay"
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+// errNotOnGrid is returned when trying to move around the grid from a workspace that isn't on it,
+// i.e. one that isn't numbered.
+var errNotOnGrid = errors.New("the current workspace isn't on the grid")
+
+// state is a snapshot of i3's outputs and workspaces, along with the grid built from them.
+type state struct {
+	env        grid.Environment
+	size       grid.Size
+	outputs    []i3.Output
+	workspaces []i3.Workspace
+}
+
+// findState fetches the current outputs and workspaces from i3, and initialises the state of the
+// grid based on them, and the grid size configured in the environment.
+func findState() (state, error) {
+	var st state
+
+	// Env-based config
+	ix, err := envAsInt("I3X3_X_SIZE", 3)
+	if err != nil {
+		return st, err
+	}
+
+	iy, err := envAsInt("I3X3_Y_SIZE", 3)
+	if err != nil {
+		return st, err
+	}
+
+	ip, err := envAsInt("I3X3_PAGES", 1)
+	if err != nil {
+		return st, err
+	}
+
+	st.outputs, err = i3.FindOutputs()
+	if err != nil {
+		return st, err
+	}
+
+	st.workspaces, err = i3.FindWorkspaces()
+	if err != nil {
+		return st, err
+	}
+
+	// Initialise the state of the grid.
+	st.env = grid.NewEnvironment(st.outputs, st.workspaces)
+	st.size = grid.NewPagedSize(st.env, ix, iy, ip)
+
+	// A workspace that isn't on the grid stays on whichever output it's on, so cells are found on
+	// that output's grid while it's focused.
+	if focused, ok := i3.FocusedWorkspace(st.workspaces); ok && !focused.Numbered() {
+		if idx, ok := outputIndexByName(sortedActiveOutputs(st.outputs), focused.Output); ok {
+			st.env.CurrentOutput = idx + 1
+		}
+	}
+
+	return st, nil
+}
+
+// target returns the workspace in the given direction from the current workspace. An error is
+// returned if the direction is invalid, if the current workspace isn't on the grid, or if we're
+// already at the edge of the grid.
+func (s state) target(direction string) (int, error) {
+	dir := grid.Direction(direction)
+
+	if s.env.CurrentWorkspace < 1 {
+		return 0, errNotOnGrid
+	}
+
+	edgeFuncs := grid.BuildEdgeFuncs(s.env, s.size)
+	targetFuncs := grid.BuildTargetFuncs(s.env, s.size)
+
+	targetFunc, ok := targetFuncs[dir]
+	if !ok {
+		return 0, fmt.Errorf("invalid direction: %q", direction)
+	}
+
+	edgeFunc, ok := edgeFuncs[dir]
+	if !ok {
+		return 0, fmt.Errorf("invalid direction: %q", direction)
+	}
+
+	// Check if we're at an edge...
+	if edgeFunc(s.env.CurrentWorkspace) {
+		// ... and if we are, just return.
+		return 0, fmt.Errorf("hit edge of grid")
+	}
+
+	return targetFunc(), nil
+}
+
+// switched updates the state to reflect a switch to the given target workspace, made with the given
+// mode, so that further switches can be worked out without fetching the state from i3 again. The
+// given label is the target's label, if it has one, for if the target has to be created.
+func (s *state) switched(target int, mode MoveMode, label string) {
+	if _, ok := s.workspace(target); !ok {
+		// i3 creates new workspaces on the current output.
+		current, _ := i3.FocusedWorkspace(s.workspaces)
+
+		s.workspaces = append(s.workspaces, i3.Workspace{
+			Num:    target,
+			Name:   i3.WorkspaceName(target, label),
+			Output: current.Output,
+		})
+
+		if target > s.env.MaxWorkspace {
+			s.env.MaxWorkspace = target
+			s.size = grid.NewPagedSize(s.env, s.size.OriginalX, s.size.OriginalY, s.size.OriginalPages)
+		}
+	}
+
+	if mode == MoveStay {
+		return
+	}
+
+	s.env.CurrentWorkspace = target
+	s.env.CurrentOutput = i3.CurrentOutputNum(target, s.env.ActiveOutputs)
+
+	for i := range s.workspaces {
+		s.workspaces[i].Focused = s.workspaces[i].Num == target
+	}
+}
+
+// workspace finds the workspace with the given number, if it exists. Workspaces that aren't numbered
+// are never found, as they all share the same number.
+func (s state) workspace(num int) (i3.Workspace, bool) {
+	for _, workspace := range s.workspaces {
+		if workspace.Numbered() && workspace.Num == num {
+			return workspace, true
+		}
+	}
+
+	return i3.Workspace{}, false
+}
+
+// envAsInt attempts to lookup the value of an environment variable by the given key. If it is not
+// found then the given fallback value is used. If the value is found but can't be converted to a
+// int, an error will be returned.
+func envAsInt(key string, fallback int) (int, error) {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback, nil
+	}
+
+	return strconv.Atoi(val)
+}
+
+// handleState takes a state request, and describes the current state of the grid in response.
+func (t *SwitchThread) handleState() (*proto.StateResponse, error) {
+	st, err := findState()
+	if err != nil {
+		return nil, err
+	}
+
+	activeOutputs := sortedActiveOutputs(st.outputs)
+	labels := t.labels.All()
+
+	layout := overlay.NewLayout(overlay.State{
+		Environment: st.env,
+		Size:        st.size,
+		Workspaces:  st.workspaces,
+		Outputs:     activeOutputs,
+		Target:      st.env.CurrentWorkspace,
+		Labels:      labels,
+		Windows:     t.tree.Windows(),
+	}, false)
+
+	res := &proto.StateResponse{
+		Columns:          int32(st.size.RealX),
+		Rows:             int32(st.size.RealY),
+		CurrentOutput:    int32(st.env.CurrentOutput),
+		CurrentWorkspace: int32(st.env.CurrentWorkspace),
+		Grid:             overlay.RenderText(layout),
+		Pages:            int32(st.size.Pages()),
+		CurrentPage:      int32(grid.WorkspacePage(st.env.CurrentWorkspace, st.env.ActiveOutputs, st.size)),
+	}
+
+	for i, output := range activeOutputs {
+		res.Outputs = append(res.Outputs, &proto.OutputState{
+			Number:  int32(i + 1),
+			Name:    output.Name,
+			Primary: output.Primary,
+		})
+	}
+
+	for _, workspace := range st.workspaces {
+		ws := &proto.WorkspaceState{
+			Num:     int32(workspace.Num),
+			Name:    workspace.Name,
+			Label:   labels[workspace.Num],
+			Output:  workspace.Output,
+			Focused: workspace.Focused,
+			Visible: workspace.Visible,
+			Urgent:  workspace.Urgent,
+		}
+
+		// Workspaces that aren't numbered aren't on the grid at all.
+		if workspace.Numbered() {
+			pos := (grid.WorkspaceGridPosition(workspace.Num, st.env.ActiveOutputs) - 1) % st.size.PageCells()
+
+			ws.X = int32((pos % st.size.RealX) + 1)
+			ws.Y = int32((pos / st.size.RealX) + 1)
+			ws.Page = int32(grid.WorkspacePage(workspace.Num, st.env.ActiveOutputs, st.size))
+		}
+
+		res.Workspaces = append(res.Workspaces, ws)
+	}
+
+	return res, nil
+}
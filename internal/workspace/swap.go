@@ -0,0 +1,122 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/seeruk/i3x3/internal/grid"
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/proto"
+)
+
+// handleSwap takes a swap command, and actions it.
+func (t *SwitchThread) handleSwap(ctx context.Context, cmd proto.SwapCommand) error {
+	st, tar, err := t.swapWorkspace(cmd)
+	if err != nil || !cmd.Overlay {
+		return err
+	}
+
+	return t.notify(ctx, st, tar)
+}
+
+// swapWorkspace swaps the contents of the current workspace with the workspace that the given
+// command points at. This is done by renaming the workspaces, so that their containers stay put,
+// and focus follows the current workspace's containers to their new cell. Labels move along with
+// the workspaces they belong to.
+func (t *SwitchThread) swapWorkspace(cmd proto.SwapCommand) (state, int, error) {
+	st, err := findState()
+	if err != nil {
+		return st, 0, err
+	}
+
+	// Swapping would give the current workspace a number, putting it on the grid.
+	if st.env.CurrentWorkspace < 1 {
+		return st, 0, errNotOnGrid
+	}
+
+	var target int
+
+	if cmd.Direction != "" {
+		target, err = st.target(cmd.Direction)
+		if err != nil {
+			return st, 0, err
+		}
+	} else {
+		var ok bool
+
+		target, ok = grid.CellWorkspace(st.env, st.size, int(cmd.X), int(cmd.Y))
+		if !ok {
+			return st, 0, fmt.Errorf("cell %d,%d is outside of the grid", cmd.X, cmd.Y)
+		}
+	}
+
+	current := st.env.CurrentWorkspace
+	if target == current {
+		return st, target, nil
+	}
+
+	currentWorkspace, _ := st.workspace(current)
+	targetWorkspace, targetExists := st.workspace(target)
+
+	currentLabel, _ := t.labels.Get(current)
+	targetLabel, _ := t.labels.Get(target)
+
+	// The outputs each workspace will be on after the renames, keyed by their new number.
+	placement := map[int]string{
+		target: currentWorkspace.Output,
+	}
+
+	if targetExists {
+		placement[current] = targetWorkspace.Output
+
+		err = i3.SwapWorkspaces(
+			currentWorkspace.Name, i3.WorkspaceName(target, currentLabel),
+			targetWorkspace.Name, i3.WorkspaceName(current, targetLabel),
+		)
+	} else {
+		err = i3.RenameWorkspace(currentWorkspace.Name, i3.WorkspaceName(target, currentLabel))
+	}
+
+	if err != nil {
+		return st, 0, err
+	}
+
+	err = t.labels.Set(target, currentLabel)
+	if err != nil {
+		return st, 0, err
+	}
+
+	err = t.labels.Set(current, targetLabel)
+	if err != nil {
+		return st, 0, err
+	}
+
+	// The renamed workspaces keep their outputs, which should already be correct because they're in
+	// the same grid. If either wasn't in the right place to begin with, it's moved now.
+	activeOutputs := sortedActiveOutputs(st.outputs)
+	moved := false
+
+	for num, output := range placement {
+		expected, ok := expectedOutput(activeOutputs, num)
+		if !ok || expected.Name == output {
+			continue
+		}
+
+		err = i3.MoveWorkspaceToOutput(num, expected.Name)
+		if err != nil {
+			return st, 0, err
+		}
+
+		moved = true
+	}
+
+	if moved {
+		// Moving a workspace requires switching to it, so focus must be restored.
+		err = i3.SwitchToWorkspace(target)
+		if err != nil {
+			return st, 0, err
+		}
+	}
+
+	return st, target, nil
+}
@@ -3,8 +3,7 @@ package workspace
 import (
 	"context"
 	"fmt"
-	"os"
-	"strconv"
+	"image"
 	"sync"
 	"time"
 
@@ -18,6 +17,40 @@ import (
 // SwitchTimeout is the amount of time the switcher will wait for outbound message acknowledgement.
 const SwitchTimeout = time.Second
 
+// MoveMode represents how containers are moved when switching to a target workspace.
+type MoveMode string
+
+// The move mode constants are the available ways that containers can be moved to a target workspace.
+const (
+	// MoveNone doesn't move any containers, only focus is switched.
+	MoveNone MoveMode = ""
+	// MoveFollow moves the focused container, and then switches to the target workspace.
+	MoveFollow MoveMode = "follow"
+	// MoveStay moves the focused container, leaving focus on the current workspace.
+	MoveStay MoveMode = "stay"
+	// MoveAll moves all containers on the current workspace, and then switches to the target.
+	MoveAll MoveMode = "all"
+)
+
+// NewMoveMode resolves the move mode requested by the given command. The move flag on it's own
+// implies MoveFollow, for compatibility with clients that don't send a mode.
+func NewMoveMode(cmd proto.DaemonCommand) (MoveMode, error) {
+	mode := MoveMode(cmd.MoveMode)
+
+	switch mode {
+	case MoveNone:
+		if cmd.Move {
+			return MoveFollow, nil
+		}
+
+		return MoveNone, nil
+	case MoveFollow, MoveStay, MoveAll:
+		return mode, nil
+	}
+
+	return MoveNone, fmt.Errorf("invalid move mode: %q", cmd.MoveMode)
+}
+
 // SwitchResult is the result of an attempt to switch workspaces.
 type SwitchMessage struct {
 	// Context is a context used to cancel downstream events. It should be set with a timeout.
@@ -27,19 +60,41 @@ type SwitchMessage struct {
 	ResponseCh chan<- error
 	// Environment is a grid environment (containing things about the current state of the grid).
 	Environment grid.Environment
+	// Size is the size of the grid, including any extra rows if the grid has grown.
+	Size grid.Size
+	// Workspaces contains all of the workspaces that exist in i3, after switching.
+	Workspaces []i3.Workspace
 	// Target is the workspace we're going to switch to, if we're going to switch workspaces.
-	Target float64
+	Target int
+	// Labels contains the label of each labelled workspace, keyed by workspace number.
+	Labels map[int]string
+	// Windows contains the windows on each workspace, keyed by workspace number.
+	Windows map[int][]i3.WindowProperties
+	// Outputs contains the active outputs, sorted so that each output's index is one less than it's
+	// output number on the grid.
+	Outputs []i3.Output
+	// Thumbnails contains a thumbnail of what each workspace looked like when it was last visible,
+	// keyed by workspace number. It may be empty, if thumbnails are disabled.
+	Thumbnails map[int]*image.RGBA
+	// PickCh is set if the overlay should let the user pick a workspace, instead of only showing the
+	// grid. The user's pick is sent down it once they've made it.
+	PickCh chan<- PickResult
 }
 
 // NewSwitchMessage creates a new switch message, used to notify some consumer.
-func NewSwitchMessage(ctx context.Context, env grid.Environment, target float64) (SwitchMessage, chan error) {
+func NewSwitchMessage(ctx context.Context, env grid.Environment, size grid.Size, workspaces []i3.Workspace, target int, labels map[int]string, windows map[int][]i3.WindowProperties, outputs []i3.Output) (SwitchMessage, chan error) {
 	responseCh := make(chan error, 1)
 
 	message := SwitchMessage{
 		Context:     ctx,
 		ResponseCh:  responseCh,
 		Environment: env,
+		Size:        size,
+		Workspaces:  workspaces,
 		Target:      target,
+		Labels:      labels,
+		Windows:     windows,
+		Outputs:     outputs,
 	}
 
 	return message, responseCh
@@ -49,22 +104,31 @@ func NewSwitchMessage(ctx context.Context, env grid.Environment, target float64)
 type SwitchThread struct {
 	sync.Mutex
 
-	ctx    context.Context
-	cfn    context.CancelFunc
-	logger log15.Logger
+	ctx        context.Context
+	cfn        context.CancelFunc
+	logger     log15.Logger
+	batch      sync.RWMutex
+	labels     *Labels
+	history    *History
+	tree       *TreeThread
+	thumbnails *Thumbnails
 
 	msgCh <-chan rpc.Message
 	outCh chan<- SwitchMessage
 }
 
 // NewSwitchThread creates a new workspace switcher thread.
-func NewSwitchThread(logger log15.Logger, msgCh <-chan rpc.Message, outCh chan<- SwitchMessage) *SwitchThread {
+func NewSwitchThread(logger log15.Logger, labels *Labels, tree *TreeThread, thumbnails *Thumbnails, msgCh <-chan rpc.Message, outCh chan<- SwitchMessage) *SwitchThread {
 	logger = logger.New("module", "workspace/switcherThread")
 
 	return &SwitchThread{
-		logger: logger,
-		msgCh:  msgCh,
-		outCh:  outCh,
+		logger:     logger,
+		labels:     labels,
+		history:    NewHistory(HistorySize),
+		tree:       tree,
+		thumbnails: thumbnails,
+		msgCh:      msgCh,
+		outCh:      outCh,
 	}
 }
 
@@ -87,15 +151,15 @@ func (t *SwitchThread) Start() error {
 			// Similar to how an HTTP server might work, we accept new messages, and process them in
 			// a goroutine. This allows messages to avoid blocking each other.
 			go func() {
-				ctx := msg.Context
-				cmd := msg.Command
+				result, err := t.handleMessage(msg.Context, msg.Command)
 
-				msg.ResponseCh <- t.handleCommand(ctx, cmd)
+				msg.ResponseCh <- rpc.Response{
+					Result: result,
+					Error:  err,
+				}
 
 				t.logger.Debug("sent response",
-					"direction", cmd.Direction,
-					"move", cmd.Move,
-					"overlay", cmd.Overlay,
+					"command", msg.Command,
 				)
 			}()
 		case <-t.ctx.Done():
@@ -116,120 +180,177 @@ func (t *SwitchThread) Stop() error {
 	return nil
 }
 
-// handleCommand takes a daemon command, and actions it.
-func (t *SwitchThread) handleCommand(ctx context.Context, cmd proto.DaemonCommand) error {
-	// Perform the switch, returning information to react on in other threads.
-	env, tar, err := t.switchWorkspace(cmd.Direction, cmd.Move, cmd.Overlay)
-
-	ctx, _ = context.WithTimeout(ctx, SwitchTimeout)
-	msg, responseCh := NewSwitchMessage(ctx, env, tar)
-
-	if err == nil && cmd.Overlay {
-		select {
-		case t.outCh <- msg:
-			t.logger.Debug("sent message",
-				"target", fmt.Sprintf("%.0f", tar),
-			)
-		case <-t.ctx.Done():
-			return fmt.Errorf("workspace/switcher: sending: %v", t.ctx.Err())
-		case <-ctx.Done():
-			return fmt.Errorf("workspace/switcher: sending: timed out")
-		}
+// handleMessage takes the command from an RPC message, and actions it based on it's type. Some
+// commands also produce a result to send back to the client.
+//
+// Commands are handled alongside each other, except for batches, which run on their own. Picking
+// waits for the user, and state requests don't change anything, so they don't wait for batches.
+func (t *SwitchThread) handleMessage(ctx context.Context, command interface{}) (interface{}, error) {
+	switch command.(type) {
+	case *proto.BatchCommand:
+		t.batch.Lock()
+		defer t.batch.Unlock()
+	case *proto.PickCommand, *proto.StateRequest:
+	default:
+		t.batch.RLock()
+		defer t.batch.RUnlock()
+	}
 
-		select {
-		case err := <-responseCh:
-			if err != nil {
-				return err
-			}
-		case <-t.ctx.Done():
-			return fmt.Errorf("workspace/switcher: receiving: %v", t.ctx.Err())
-		case <-ctx.Done():
-			return fmt.Errorf("workspace/switcher: receiving: timed out")
-		}
+	switch cmd := command.(type) {
+	case *proto.DaemonCommand:
+		return nil, t.handleCommand(ctx, *cmd)
+	case *proto.SwapCommand:
+		return nil, t.handleSwap(ctx, *cmd)
+	case *proto.CompactCommand:
+		return t.handleCompact(*cmd)
+	case *proto.MoveWorkspaceCommand:
+		return nil, t.handleMoveWorkspace(ctx, *cmd)
+	case *proto.LabelCommand:
+		return nil, t.handleLabel(*cmd)
+	case *proto.PickCommand:
+		return nil, t.handlePick(ctx, *cmd)
+	case *proto.JumpCommand:
+		return nil, t.handleJump(ctx, *cmd)
+	case *proto.HistoryCommand:
+		return nil, t.handleHistory(ctx, *cmd)
+	case *proto.BatchCommand:
+		return nil, t.handleBatch(ctx, *cmd)
+	case *proto.RedistributeCommand:
+		return nil, t.handleRedistribute()
+	case *proto.ReloadCommand:
+		return nil, t.handleReload()
+	case *proto.StateRequest:
+		return t.handleState()
 	}
 
-	return err
+	return nil, fmt.Errorf("workspace/switcher: unknown command type: %T", command)
 }
 
-// switchWorkspace actually performs the workspace switching, communicating with i3.
-func (t *SwitchThread) switchWorkspace(direction string, move, overlay bool) (grid.Environment, float64, error) {
-	dir := grid.Direction(direction)
-	env := grid.Environment{}
-
-	// Env-based config
-	ix, err := envAsInt("I3X3_X_SIZE", 3)
+// handleCommand takes a daemon command, and actions it.
+func (t *SwitchThread) handleCommand(ctx context.Context, cmd proto.DaemonCommand) error {
+	mode, err := NewMoveMode(cmd)
 	if err != nil {
-		return env, 0, err
+		return err
 	}
 
-	iy, err := envAsInt("I3X3_Y_SIZE", 3)
-	if err != nil {
-		return env, 0, err
+	// Perform the switch, returning information to react on in other threads.
+	st, tar, err := t.switchWorkspace(cmd.Direction, mode)
+	if err != nil || !cmd.Overlay {
+		return err
 	}
 
-	outputs, err := i3.FindOutputs()
+	return t.notify(ctx, st, tar)
+}
+
+// handleRedistribute takes a redistribute command, and actions it straight away, rather than waiting
+// for the distributor thread to do it.
+func (t *SwitchThread) handleRedistribute() error {
+	err := redistributeWorkspaces()
 	if err != nil {
-		return env, 0, err
+		return err
 	}
 
+	return applyLabels(t.labels)
+}
+
+// notify sends a SwitchMessage to the overlay, and waits for it to be acknowledged. The given state
+// is from before the switch, so the workspaces are fetched again to show what they look like now.
+func (t *SwitchThread) notify(ctx context.Context, st state, tar int) error {
+	ctx, cfn := context.WithTimeout(ctx, SwitchTimeout)
+	defer cfn()
+
 	workspaces, err := i3.FindWorkspaces()
 	if err != nil {
-		return env, 0, err
+		t.logger.Warn("couldn't refresh workspaces for overlay", "error", err)
+		workspaces = st.workspaces
 	}
 
-	// Initialise the state of the grid.
-	gridEnv := grid.NewEnvironment(outputs, workspaces)
-	gridSize := grid.NewSize(gridEnv, ix, iy)
+	// The windows come from the tree thread's cache, because fetching the tree from i3 is too slow
+	// to do before showing the overlay.
+	windows := t.tree.Windows()
+
+	msg, responseCh := NewSwitchMessage(ctx, st.env, st.size, workspaces, tar, t.labels.All(), windows, sortedActiveOutputs(st.outputs))
+	msg.Thumbnails = t.thumbnails.All()
+
+	select {
+	case t.outCh <- msg:
+		t.logger.Debug("sent message",
+			"target", tar,
+		)
+	case <-t.ctx.Done():
+		return fmt.Errorf("workspace/switcher: sending: %v", t.ctx.Err())
+	case <-ctx.Done():
+		return fmt.Errorf("workspace/switcher: sending: timed out")
+	}
 
-	edgeFuncs := grid.BuildEdgeFuncs(gridEnv, gridSize)
-	targetFuncs := grid.BuildTargetFuncs(gridEnv, gridSize)
+	select {
+	case err := <-responseCh:
+		return err
+	case <-t.ctx.Done():
+		return fmt.Errorf("workspace/switcher: receiving: %v", t.ctx.Err())
+	case <-ctx.Done():
+		return fmt.Errorf("workspace/switcher: receiving: timed out")
+	}
+}
 
-	targetFunc, ok := targetFuncs[dir]
-	if !ok {
-		return gridEnv, 0, fmt.Errorf("invalid direction: %q", direction)
+// switchWorkspace actually performs the workspace switching, communicating with i3.
+func (t *SwitchThread) switchWorkspace(direction string, mode MoveMode) (state, int, error) {
+	st, err := findState()
+	if err != nil {
+		return st, 0, err
 	}
 
-	edgeFunc, ok := edgeFuncs[dir]
-	if !ok {
-		return gridEnv, 0, fmt.Errorf("invalid direction: %q", direction)
+	// Retrieve the target workspace that we should be moving to.
+	target, err := st.target(direction)
+	if err != nil {
+		return st, 0, err
 	}
 
-	// Check if we're at an edge...
-	if edgeFunc(gridEnv.CurrentWorkspace) {
-		// ... and if we are, just return.
-		return gridEnv, 0, fmt.Errorf("hit edge of grid")
+	err = t.switchTo(st, target, mode)
+	if err != nil {
+		return st, 0, err
 	}
 
-	// Retrieve the target workspace that we should be moving to.
-	target := targetFunc()
+	return st, target, nil
+}
+
+// switchTo switches to the given target workspace, moving containers there first if the given mode
+// requires it.
+func (t *SwitchThread) switchTo(st state, target int, mode MoveMode) error {
+	var err error
+
+	// Whatever is visible is captured before anything changes, including containers being moved
+	// away. When staying, nothing is switched away from.
+	if mode != MoveStay {
+		t.thumbnails.CaptureVisible(st.workspaces)
+	}
 
-	if move {
-		// If we need to move the currently focused container, we must do it before switching space,
-		// because i3 will move whatever is focused when move is ran. In other words, this cannot be
-		// handled concurrently.
+	// If we need to move containers, we must do it before switching space, because i3 will move
+	// whatever is focused when move is ran. In other words, this cannot be handled concurrently.
+	switch mode {
+	case MoveFollow, MoveStay:
 		err = i3.MoveToWorkspace(target)
-		if err != nil {
-			return gridEnv, 0, err
-		}
+	case MoveAll:
+		err = i3.MoveAllToWorkspace(target)
 	}
 
-	// Switch to the target workspace.
-	err = i3.SwitchToWorkspace(target)
 	if err != nil {
-		return gridEnv, 0, err
+		return err
 	}
 
-	return gridEnv, target, nil
-}
+	// When staying, focus remains where it is, but the target is still returned so that it can be
+	// highlighted in the overlay.
+	if mode != MoveStay {
+		// Switch to the target workspace.
+		err = i3.SwitchToWorkspace(target)
+		if err != nil {
+			return err
+		}
 
-// envAsInt attempts to lookup the value of an environment variable by the given key. If it is not
-// found then the given fallback value is used. If the value is found but can't be converted to a
-// int, an error will be returned.
-func envAsInt(key string, fallback int) (int, error) {
-	val, ok := os.LookupEnv(key)
-	if !ok {
-		return fallback, nil
+		t.history.Visit(st.env.CurrentWorkspace, target)
+		t.thumbnails.Switched()
 	}
 
-	return strconv.Atoi(val)
+	// If the target workspace was just created by i3, it will only be named with it's number.
+	return applyLabel(st, t.labels, target)
 }
@@ -0,0 +1,140 @@
+package workspace
+
+import (
+	"image"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/seeruk/i3x3/internal/i3"
+	"github.com/seeruk/i3x3/internal/xserver"
+)
+
+// ThumbnailWidth is the width that workspace screenshots are scaled down to, before they're cached.
+// The overlay scales them again to fit it's cells.
+const ThumbnailWidth = 200
+
+// ThumbnailSettle is how long after a switch nothing is captured. Until then, the screen may still
+// show some of what was there before the switch, rather than the workspaces that are now visible.
+const ThumbnailSettle = 250 * time.Millisecond
+
+// thumbnail is a cached thumbnail, and the sequence number of the capture it came from.
+type thumbnail struct {
+	img *image.RGBA
+	seq uint64
+}
+
+// Thumbnails is a cache of thumbnails of what each workspace looked like when it was last visible,
+// keyed by workspace number. The visible workspaces are captured just before they're switched away
+// from, and are then scaled down and cached in the background, so that switching isn't held up for
+// longer than it takes to read the screen. Nothing is captured whilst the overlay is mapped, so that
+// it doesn't end up in the thumbnails, or straight after a switch, before the screen has caught up.
+type Thumbnails struct {
+	sync.Mutex
+
+	logger     log15.Logger
+	capturer   *xserver.Capturer
+	thumbnails map[int]thumbnail
+
+	// seq is incremented for every capture, so that a capture is never cached over a newer one of
+	// the same workspace, whichever finishes scaling first.
+	seq uint64
+	// switched is when a workspace was last switched to.
+	switched time.Time
+	// overlayMapped is set whilst any of the overlay's windows are mapped.
+	overlayMapped bool
+}
+
+// NewThumbnails creates a new, empty thumbnail cache. If the given capturer is nil, no thumbnails
+// will be captured.
+func NewThumbnails(logger log15.Logger, capturer *xserver.Capturer) *Thumbnails {
+	return &Thumbnails{
+		logger:     logger.New("module", "workspace/thumbnails"),
+		capturer:   capturer,
+		thumbnails: make(map[int]thumbnail),
+	}
+}
+
+// CaptureVisible captures each of the given workspaces that is visible, i.e. the workspace on each
+// output, before they're switched away from. It must be called before anything on screen changes,
+// so it returns once the screenshots have been taken, leaving them to be cached in the background.
+// Failing to capture a workspace isn't a reason to stop switching, so errors are only logged.
+func (t *Thumbnails) CaptureVisible(workspaces []i3.Workspace) {
+	if t.capturer == nil {
+		return
+	}
+
+	t.Lock()
+	skip := t.overlayMapped || time.Since(t.switched) < ThumbnailSettle
+	t.Unlock()
+
+	if skip {
+		return
+	}
+
+	for _, workspace := range workspaces {
+		if !workspace.Visible || !workspace.Numbered() {
+			continue
+		}
+
+		rect := workspace.Rect
+		region := image.Rect(rect.X, rect.Y, rect.X+rect.Width, rect.Y+rect.Height)
+
+		img, err := t.capturer.Capture(region)
+		if err != nil {
+			t.logger.Warn("couldn't capture thumbnail", "workspace", workspace.Num, "error", err)
+			continue
+		}
+
+		t.Lock()
+		t.seq++
+		seq := t.seq
+		t.Unlock()
+
+		go t.cache(workspace.Num, seq, img)
+	}
+}
+
+// Switched tells the cache that a workspace has just been switched to, so that nothing is captured
+// until the screen has caught up.
+func (t *Thumbnails) Switched() {
+	t.Lock()
+	defer t.Unlock()
+
+	t.switched = time.Now()
+}
+
+// SetOverlayMapped tells the cache whether any of the overlay's windows are mapped. Nothing is
+// captured whilst they are.
+func (t *Thumbnails) SetOverlayMapped(mapped bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.overlayMapped = mapped
+}
+
+// All returns a copy of the cache. The thumbnails themselves are shared, and must not be modified.
+func (t *Thumbnails) All() map[int]*image.RGBA {
+	t.Lock()
+	defer t.Unlock()
+
+	thumbnails := make(map[int]*image.RGBA, len(t.thumbnails))
+	for workspace, thumbnail := range t.thumbnails {
+		thumbnails[workspace] = thumbnail.img
+	}
+
+	return thumbnails
+}
+
+// cache scales down the given screenshot of the given workspace, and caches it, unless a newer
+// capture of the workspace has already been cached.
+func (t *Thumbnails) cache(num int, seq uint64, img *image.RGBA) {
+	thumb := xserver.Thumbnail(img, ThumbnailWidth)
+
+	t.Lock()
+	defer t.Unlock()
+
+	if existing, ok := t.thumbnails[num]; !ok || existing.seq < seq {
+		t.thumbnails[num] = thumbnail{img: thumb, seq: seq}
+	}
+}
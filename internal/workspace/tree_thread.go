@@ -0,0 +1,132 @@
+package workspace
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/seeruk/i3x3/internal/i3"
+)
+
+// TreeRefreshInterval is the amount of time between each refresh of the cached tree, regardless of
+// any events from i3. This is only a fallback, in case events are missed.
+const TreeRefreshInterval = 30 * time.Second
+
+// TreeSubscribeDelay is the amount of time to wait before re-subscribing to i3 events, if the
+// subscription ends (e.g. because i3 was restarted).
+const TreeSubscribeDelay = time.Second
+
+// TreeThread is a long-running process that keeps a cache of the windows on each workspace, built
+// from i3's layout tree. The cache is refreshed whenever i3 reports that windows or workspaces have
+// changed, so that reading it is instant (e.g. when showing the overlay).
+type TreeThread struct {
+	sync.Mutex
+
+	ctx     context.Context
+	cfn     context.CancelFunc
+	logger  log15.Logger
+	windows map[int][]i3.WindowProperties
+}
+
+// NewTreeThread creates a new tree cache thread.
+func NewTreeThread(logger log15.Logger) *TreeThread {
+	logger = logger.New("module", "workspace/treeThread")
+
+	return &TreeThread{
+		logger:  logger,
+		windows: make(map[int][]i3.WindowProperties),
+	}
+}
+
+// Start attempts to start the tree thread.
+func (t *TreeThread) Start() error {
+	t.Lock()
+	t.ctx, t.cfn = context.WithCancel(context.Background())
+	t.Unlock()
+
+	t.logger.Info("thread started")
+
+	defer func() {
+		t.logger.Info("thread stopped")
+	}()
+
+	// Events are coalesced, so a burst of them only causes one refresh.
+	events := make(chan struct{}, 1)
+
+	go t.subscribe(events)
+
+	ticker := time.NewTicker(TreeRefreshInterval)
+	defer ticker.Stop()
+
+	t.refresh()
+
+	for {
+		select {
+		case <-events:
+			t.refresh()
+		case <-ticker.C:
+			t.refresh()
+		case <-t.ctx.Done():
+			return t.ctx.Err()
+		}
+	}
+}
+
+// Stop attempts to stop the tree thread.
+func (t *TreeThread) Stop() error {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.ctx != nil && t.cfn != nil {
+		t.cfn()
+	}
+
+	return nil
+}
+
+// Windows returns the cached windows on each workspace, keyed by workspace number. The returned map
+// must not be modified.
+func (t *TreeThread) Windows() map[int][]i3.WindowProperties {
+	t.Lock()
+	defer t.Unlock()
+
+	return t.windows
+}
+
+// refresh fetches the tree from i3, and replaces the cached windows.
+func (t *TreeThread) refresh() {
+	tree, err := i3.FindTree()
+	if err != nil {
+		t.logger.Warn("couldn't refresh tree", "error", err)
+		return
+	}
+
+	windows := i3.WorkspaceWindows(tree)
+
+	t.Lock()
+	t.windows = windows
+	t.Unlock()
+}
+
+// subscribe listens for i3 events that may change the tree, notifying the given channel without
+// blocking. If the subscription ends, it is started again until the thread is stopped.
+func (t *TreeThread) subscribe(events chan<- struct{}) {
+	notify := func() {
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	}
+
+	for {
+		err := i3.Subscribe(t.ctx, []string{"window", "workspace"}, notify)
+
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-time.After(TreeSubscribeDelay):
+			t.logger.Debug("re-subscribing to i3 events", "error", err)
+		}
+	}
+}
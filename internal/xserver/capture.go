@@ -0,0 +1,231 @@
+package xserver
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"math/bits"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+)
+
+// Capturer takes screenshots of regions of the X root window. The connection to X is made when the
+// first screenshot is taken, and is made again if it's lost.
+type Capturer struct {
+	conn lazyConn
+}
+
+// NewCapturer creates a new Capturer.
+func NewCapturer() *Capturer {
+	return &Capturer{
+		conn: lazyConn{module: "xserver/capturer"},
+	}
+}
+
+// Capture takes a screenshot of the given region of the root window. The region is clipped to the
+// bounds of the screen.
+func (c *Capturer) Capture(region image.Rectangle) (*image.RGBA, error) {
+	var img *image.RGBA
+
+	err := c.conn.use(func(xconn *xgb.Conn) error {
+		var err error
+		img, err = capture(xconn, region)
+
+		return err
+	})
+
+	return img, err
+}
+
+// Close closes the Capturer's connection to X, if it has one.
+func (c *Capturer) Close() {
+	c.conn.close()
+}
+
+// capture does the actual work of Capture, once connected.
+func capture(xconn *xgb.Conn, region image.Rectangle) (*image.RGBA, error) {
+	setup := xproto.Setup(xconn)
+	screen := setup.DefaultScreen(xconn)
+
+	region = region.Intersect(image.Rect(0, 0, int(screen.WidthInPixels), int(screen.HeightInPixels)))
+	if region.Empty() {
+		return nil, fmt.Errorf("xserver/capturer: region is outside of the screen")
+	}
+
+	reply, err := xproto.GetImage(
+		xconn,
+		xproto.ImageFormatZPixmap,
+		xproto.Drawable(screen.Root),
+		int16(region.Min.X),
+		int16(region.Min.Y),
+		uint16(region.Dx()),
+		uint16(region.Dy()),
+		0xffffffff,
+	).Reply()
+
+	if err != nil {
+		return nil, fmt.Errorf("xserver/capturer: error getting image: %v", err)
+	}
+
+	format, ok := pixmapFormat(setup, reply.Depth)
+	if !ok {
+		return nil, fmt.Errorf("xserver/capturer: unknown pixmap format for depth %d", reply.Depth)
+	}
+
+	visual, ok := visualType(screen, reply.Visual)
+	if !ok {
+		return nil, fmt.Errorf("xserver/capturer: unknown visual: %d", reply.Visual)
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if setup.ImageByteOrder == xproto.ImageOrderMSBFirst {
+		order = binary.BigEndian
+	}
+
+	return DecodeZPixmap(reply.Data, region.Dx(), region.Dy(), ZPixmapFormat{
+		BitsPerPixel: int(format.BitsPerPixel),
+		ScanlinePad:  int(format.ScanlinePad),
+		ByteOrder:    order,
+		RedMask:      visual.RedMask,
+		GreenMask:    visual.GreenMask,
+		BlueMask:     visual.BlueMask,
+	})
+}
+
+// ZPixmapFormat describes how the pixels in a ZPixmap image are laid out.
+type ZPixmapFormat struct {
+	BitsPerPixel int
+	ScanlinePad  int
+	ByteOrder    binary.ByteOrder
+	RedMask      uint32
+	GreenMask    uint32
+	BlueMask     uint32
+}
+
+// DecodeZPixmap converts ZPixmap image data, as returned by X, into an RGBA image. Only true colour
+// images with 32 bits per pixel are supported, which covers the usual 24 and 32 bit depths.
+func DecodeZPixmap(data []byte, width, height int, format ZPixmapFormat) (*image.RGBA, error) {
+	if format.BitsPerPixel != 32 {
+		return nil, fmt.Errorf("xserver/capturer: unsupported bits per pixel: %d", format.BitsPerPixel)
+	}
+
+	pad := format.ScanlinePad
+	if pad == 0 {
+		pad = 32
+	}
+
+	rowBits := width * format.BitsPerPixel
+	stride := ((rowBits + pad - 1) / pad) * pad / 8
+
+	if len(data) < stride*height {
+		return nil, fmt.Errorf("xserver/capturer: expected %d bytes of image data, got %d", stride*height, len(data))
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		row := data[y*stride:]
+
+		for x := 0; x < width; x++ {
+			pixel := format.ByteOrder.Uint32(row[x*4:])
+
+			img.SetRGBA(x, y, color.RGBA{
+				R: maskComponent(pixel, format.RedMask),
+				G: maskComponent(pixel, format.GreenMask),
+				B: maskComponent(pixel, format.BlueMask),
+				A: 0xff,
+			})
+		}
+	}
+
+	return img, nil
+}
+
+// Thumbnail scales the given image down to the given width, keeping it's aspect ratio. Each pixel
+// of the thumbnail is the average of the pixels it covers in the source image.
+func Thumbnail(src image.Image, width int) *image.RGBA {
+	bounds := src.Bounds()
+	if width > bounds.Dx() {
+		width = bounds.Dx()
+	}
+
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		sy0 := bounds.Min.Y + (y * bounds.Dy() / height)
+		sy1 := bounds.Min.Y + ((y + 1) * bounds.Dy() / height)
+
+		for x := 0; x < width; x++ {
+			sx0 := bounds.Min.X + (x * bounds.Dx() / width)
+			sx1 := bounds.Min.X + ((x + 1) * bounds.Dx() / width)
+
+			var r, g, b, a, n uint32
+
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+
+					r += cr >> 8
+					g += cg >> 8
+					b += cb >> 8
+					a += ca >> 8
+					n++
+				}
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n),
+				G: uint8(g / n),
+				B: uint8(b / n),
+				A: uint8(a / n),
+			})
+		}
+	}
+
+	return dst
+}
+
+// maskComponent extracts the colour component with the given mask from the given pixel, scaled to
+// 8 bits.
+func maskComponent(pixel uint32, mask uint32) uint8 {
+	if mask == 0 {
+		return 0
+	}
+
+	shift := uint(bits.TrailingZeros32(mask))
+	max := mask >> shift
+	value := (pixel & mask) >> shift
+
+	return uint8(value * 0xff / max)
+}
+
+// pixmapFormat finds the pixmap format used for images of the given depth.
+func pixmapFormat(setup *xproto.SetupInfo, depth byte) (xproto.Format, bool) {
+	for _, format := range setup.PixmapFormats {
+		if format.Depth == depth {
+			return format, true
+		}
+	}
+
+	return xproto.Format{}, false
+}
+
+// visualType finds the visual with the given ID on the given screen.
+func visualType(screen *xproto.ScreenInfo, id xproto.Visualid) (xproto.VisualInfo, bool) {
+	for _, depth := range screen.AllowedDepths {
+		for _, visual := range depth.Visuals {
+			if visual.VisualId == id {
+				return visual, true
+			}
+		}
+	}
+
+	return xproto.VisualInfo{}, false
+}
@@ -0,0 +1,174 @@
+package xserver_test
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/seeruk/i3x3/internal/xserver"
+)
+
+func TestDecodeZPixmap(t *testing.T) {
+	format := xserver.ZPixmapFormat{
+		BitsPerPixel: 32,
+		ScanlinePad:  32,
+		ByteOrder:    binary.LittleEndian,
+		RedMask:      0xff0000,
+		GreenMask:    0x00ff00,
+		BlueMask:     0x0000ff,
+	}
+
+	// Two pixels, in BGRX order: red, then blue.
+	data := []byte{
+		0x00, 0x00, 0xff, 0x00,
+		0xff, 0x00, 0x00, 0x00,
+	}
+
+	img, err := xserver.DecodeZPixmap(data, 2, 1, format)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		x        int
+		expected color.RGBA
+	}{
+		{x: 0, expected: color.RGBA{R: 0xff, A: 0xff}},
+		{x: 1, expected: color.RGBA{B: 0xff, A: 0xff}},
+	}
+
+	for _, test := range tests {
+		actual := img.RGBAAt(test.x, 0)
+		if actual != test.expected {
+			t.Errorf("expected pixel %d to be %v, got %v", test.x, test.expected, actual)
+		}
+	}
+
+	_, err = xserver.DecodeZPixmap(data, 3, 1, format)
+	if err == nil {
+		t.Error("expected error decoding too little data")
+	}
+
+	format.BitsPerPixel = 16
+
+	_, err = xserver.DecodeZPixmap(data, 2, 1, format)
+	if err == nil {
+		t.Error("expected error decoding unsupported bits per pixel")
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	white := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	black := color.RGBA{A: 0xff}
+
+	// A 4x2 image, with the left half white, and the right half black.
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if x < 2 {
+				src.SetRGBA(x, y, white)
+			} else {
+				src.SetRGBA(x, y, black)
+			}
+		}
+	}
+
+	thumb := xserver.Thumbnail(src, 2)
+
+	if thumb.Bounds().Dx() != 2 || thumb.Bounds().Dy() != 1 {
+		t.Fatalf("expected 2x1 thumbnail, got %v", thumb.Bounds())
+	}
+
+	if actual := thumb.RGBAAt(0, 0); actual != white {
+		t.Errorf("expected left pixel to be %v, got %v", white, actual)
+	}
+
+	if actual := thumb.RGBAAt(1, 0); actual != black {
+		t.Errorf("expected right pixel to be %v, got %v", black, actual)
+	}
+
+	// Pixels covering a mix of colours are averaged.
+	mixed := xserver.Thumbnail(src, 1)
+	if actual := mixed.RGBAAt(0, 0); actual.R != 0x7f {
+		t.Errorf("expected mixed pixel to be grey, got %v", actual)
+	}
+
+	// Thumbnails are never larger than the source image.
+	large := xserver.Thumbnail(src, 8)
+	if large.Bounds().Dx() != 4 {
+		t.Errorf("expected thumbnail width to be limited to 4, got %d", large.Bounds().Dx())
+	}
+}
+
+// TestCapture needs an X server, such as Xvfb. It's skipped if DISPLAY isn't set, e.g.:
+//
+//	xvfb-run go test ./internal/xserver/
+func TestCapture(t *testing.T) {
+	if os.Getenv("DISPLAY") == "" {
+		t.Skip("DISPLAY is not set, skipping test that needs an X server")
+	}
+
+	xconn, err := xgb.NewConn()
+	if err != nil {
+		t.Fatalf("error connecting to X: %v", err)
+	}
+
+	defer xconn.Close()
+
+	screen := xproto.Setup(xconn).DefaultScreen(xconn)
+
+	// Put a red window in the top left corner of the screen, so we know what to expect.
+	wid, err := xproto.NewWindowId(xconn)
+	if err != nil {
+		t.Fatalf("error creating window ID: %v", err)
+	}
+
+	red := uint32(0xff0000)
+
+	err = xproto.CreateWindowChecked(xconn, screen.RootDepth, wid, screen.Root, 0, 0, 20, 20, 0,
+		xproto.WindowClassInputOutput, screen.RootVisual,
+		xproto.CwBackPixel|xproto.CwOverrideRedirect, []uint32{red, 1}).Check()
+	if err != nil {
+		t.Fatalf("error creating window: %v", err)
+	}
+
+	err = xproto.MapWindowChecked(xconn, wid).Check()
+	if err != nil {
+		t.Fatalf("error mapping window: %v", err)
+	}
+
+	// Give the X server a moment to draw the window.
+	time.Sleep(100 * time.Millisecond)
+
+	capturer := xserver.NewCapturer()
+	defer capturer.Close()
+
+	img, err := capturer.Capture(image.Rect(0, 0, 10, 10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if img.Bounds().Dx() != 10 || img.Bounds().Dy() != 10 {
+		t.Fatalf("expected 10x10 image, got %v", img.Bounds())
+	}
+
+	expected := color.RGBA{R: 0xff, A: 0xff}
+	if actual := img.RGBAAt(5, 5); actual != expected {
+		t.Errorf("expected captured pixel to be %v, got %v", expected, actual)
+	}
+
+	// Regions are clipped to the screen.
+	img, err = capturer.Capture(image.Rect(-5, -5, 5, 5))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if img.Bounds().Dx() != 5 || img.Bounds().Dy() != 5 {
+		t.Errorf("expected 5x5 image, got %v", img.Bounds())
+	}
+}
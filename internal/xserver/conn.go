@@ -0,0 +1,53 @@
+package xserver
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/BurntSushi/xgb"
+)
+
+// lazyConn is a connection to X that is made when it's first used, and is made again if it's lost.
+type lazyConn struct {
+	sync.Mutex
+
+	// module is used to prefix errors.
+	module string
+	xconn  *xgb.Conn
+}
+
+// use calls the given function with the connection, connecting to X first if needed. If the
+// function returns an error, the connection may have been lost, so it's closed, and made again the
+// next time it's used.
+func (c *lazyConn) use(fn func(xconn *xgb.Conn) error) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.xconn == nil {
+		xconn, err := xgb.NewConn()
+		if err != nil {
+			return fmt.Errorf("%s: error connecting to X: %v", c.module, err)
+		}
+
+		c.xconn = xconn
+	}
+
+	err := fn(c.xconn)
+	if err != nil {
+		c.xconn.Close()
+		c.xconn = nil
+	}
+
+	return err
+}
+
+// close closes the connection to X, if there is one.
+func (c *lazyConn) close() {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.xconn != nil {
+		c.xconn.Close()
+		c.xconn = nil
+	}
+}
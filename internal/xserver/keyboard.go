@@ -0,0 +1,55 @@
+package xserver
+
+import (
+	"fmt"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+)
+
+// HeldModifiers is the mask of the modifiers that are only active whilst their key is held down.
+// Caps Lock (Lock) and Num Lock (usually Mod2) stay active after being released, so they're left
+// out.
+const HeldModifiers = xproto.ModMaskShift | xproto.ModMaskControl | xproto.ModMask1 |
+	xproto.ModMask3 | xproto.ModMask4 | xproto.ModMask5
+
+// Keyboard reads the state of the keyboard from X. The connection to X is made when the state is
+// first read, and is made again if it's lost.
+type Keyboard struct {
+	conn lazyConn
+}
+
+// NewKeyboard creates a new Keyboard.
+func NewKeyboard() *Keyboard {
+	return &Keyboard{
+		conn: lazyConn{module: "xserver/keyboard"},
+	}
+}
+
+// Modifiers returns the mask of the modifiers that are currently held down, e.g. the modifiers of
+// the key binding that is being used. Lock modifiers are ignored.
+func (k *Keyboard) Modifiers() (uint16, error) {
+	var mask uint16
+
+	err := k.conn.use(func(xconn *xgb.Conn) error {
+		screen := xproto.Setup(xconn).DefaultScreen(xconn)
+
+		// The pointer's mask includes the state of the keyboard's modifiers, even whilst another
+		// client (like i3) has grabbed the keyboard.
+		reply, err := xproto.QueryPointer(xconn, screen.Root).Reply()
+		if err != nil {
+			return fmt.Errorf("xserver/keyboard: error querying pointer: %v", err)
+		}
+
+		mask = reply.Mask & HeldModifiers
+
+		return nil
+	})
+
+	return mask, err
+}
+
+// Close closes the Keyboard's connection to X, if it has one.
+func (k *Keyboard) Close() {
+	k.conn.close()
+}
@@ -0,0 +1,28 @@
+package xserver_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/seeruk/i3x3/internal/xserver"
+)
+
+// TestKeyboard needs an X server, such as Xvfb. It's skipped if DISPLAY isn't set.
+func TestKeyboard(t *testing.T) {
+	if os.Getenv("DISPLAY") == "" {
+		t.Skip("DISPLAY is not set, skipping test that needs an X server")
+	}
+
+	keyboard := xserver.NewKeyboard()
+	defer keyboard.Close()
+
+	mods, err := keyboard.Modifiers()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Nothing should be holding keys down whilst the tests run.
+	if mods != 0 {
+		t.Errorf("expected no modifiers to be held, got %#x", mods)
+	}
+}